@@ -0,0 +1,341 @@
+// Package assets loads textures, shaders, fonts and models in the background.
+//
+// File I/O and decoding happen on worker goroutines; the GL upload for each
+// asset is then queued onto the main thread with mainthread.CallNonBlock, so
+// the Manager may only be used inside mainthread.Run. Finished loads are
+// collected with Completed and looked up by name with Texture, Shader, Font
+// and Model.
+//
+// Watch polls the files behind every loaded asset and reloads those that
+// change, so shaders and textures can be edited while the game runs.
+package assets
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
+	"github.com/dcrosby42/go-game-sandbox/glfont"
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+	"github.com/faiface/mainthread"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/golang/freetype/truetype"
+)
+
+// Request identifies an asset. Path is a texture file, a shader name (as in
+// "<ShaderDir>/<name>.vert.glsl"), a font file, a material file or a glTF model
+// file. Size is the point size of a font.
+type Request struct {
+	Kind sideeffect.AssetKind
+	Path string
+	Size int
+}
+
+// Result reports a finished load. Err is nil on success.
+type Result struct {
+	Request
+	Err error
+
+	// Reload is true when a watched file changed and the asset was loaded
+	// again. A failed reload leaves the previous version in place.
+	Reload bool
+}
+
+// job is a Request on its way through the workers
+type job struct {
+	Request
+	reload bool
+	stamps []fileStamp
+}
+
+type Manager struct {
+	ShaderDir string
+
+	// ScreenWidth, ScreenHeight are handed to fonts for their resolution uniform
+	ScreenWidth, ScreenHeight int
+
+	queue chan job
+	stop  chan struct{}
+
+	mu        sync.Mutex
+	requested map[Request]bool
+	watched   map[Request][]fileStamp
+	textures  map[string]uint32
+	shaders   map[string]*helpers.ShaderProgram
+	fonts     map[Request]*glfont.Font2
+	materials map[string]*helpers.MaterialDef
+	models    map[string]*helpers.GLTFScene
+	completed []Result
+}
+
+// NewManager starts the given number of worker goroutines.
+func NewManager(workers int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	me := &Manager{
+		ShaderDir: "shaders",
+		queue:     make(chan job, 64),
+		requested: make(map[Request]bool),
+		watched:   make(map[Request][]fileStamp),
+		textures:  make(map[string]uint32),
+		shaders:   make(map[string]*helpers.ShaderProgram),
+		fonts:     make(map[Request]*glfont.Font2),
+		materials: make(map[string]*helpers.MaterialDef),
+		models:    make(map[string]*helpers.GLTFScene),
+	}
+	for i := 0; i < workers; i++ {
+		go me.work()
+	}
+	return me
+}
+
+// Request starts loading an asset. Repeat requests for an asset that is loading
+// or loaded are ignored; each asset completes once. Failed assets may be requested again.
+func (me *Manager) Request(req Request) {
+	if req.Kind != sideeffect.AssetFont {
+		req.Size = 0
+	}
+	me.mu.Lock()
+	if me.requested[req] {
+		me.mu.Unlock()
+		return
+	}
+	me.requested[req] = true
+	me.mu.Unlock()
+
+	me.enqueue(job{Request: req})
+}
+
+func (me *Manager) enqueue(j job) {
+	// Don't hold up the caller if the workers are busy
+	select {
+	case me.queue <- j:
+	default:
+		go func() { me.queue <- j }()
+	}
+}
+
+// Completed returns the loads that have finished since the last call.
+func (me *Manager) Completed() []Result {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	done := me.completed
+	me.completed = nil
+	return done
+}
+
+// Texture returns the GL texture for path, if it has finished loading.
+func (me *Manager) Texture(path string) (uint32, bool) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	tex, ok := me.textures[path]
+	return tex, ok
+}
+
+// Shader returns the GL program for the named shader, if it has finished loading.
+func (me *Manager) Shader(name string) (*helpers.ShaderProgram, bool) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	prog, ok := me.shaders[name]
+	return prog, ok
+}
+
+// Font returns the font loaded from file at size, if it has finished loading.
+func (me *Manager) Font(file string, size int) (*glfont.Font2, bool) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	font, ok := me.fonts[Request{Kind: sideeffect.AssetFont, Path: file, Size: size}]
+	return font, ok
+}
+
+// Material returns the definition read from a material file, if it has
+// finished loading. Its shader and textures are requested along with it but
+// may still be loading.
+func (me *Manager) Material(path string) (*helpers.MaterialDef, bool) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	def, ok := me.materials[path]
+	return def, ok
+}
+
+// Model returns the scene loaded from a .gltf or .glb file, if it has finished
+// loading. It's shared; place an Instance of it in the world. A reload replaces
+// it, and deletes the old scene's GL buffers.
+func (me *Manager) Model(path string) (*helpers.GLTFScene, bool) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	scene, ok := me.models[path]
+	return scene, ok
+}
+
+func (me *Manager) work() {
+	for j := range me.queue {
+		me.load(j)
+	}
+}
+
+// files lists the files an asset is loaded from
+func (me *Manager) files(req Request) []string {
+	if req.Kind == sideeffect.AssetShader {
+		return []string{
+			fmt.Sprintf("%s/%s.vert.glsl", me.ShaderDir, req.Path),
+			fmt.Sprintf("%s/%s.frag.glsl", me.ShaderDir, req.Path),
+		}
+	}
+	return []string{req.Path}
+}
+
+// load does the I/O and decoding for the job here on the worker, then queues the
+// GL part onto the main thread.
+func (me *Manager) load(j job) {
+	files := me.files(j.Request)
+	// stamp before reading so that a write during the read triggers another reload
+	j.stamps = stampFiles(files)
+
+	switch j.Kind {
+	case sideeffect.AssetTexture:
+		img, err := helpers.DecodeTextureFile(j.Path)
+		if err != nil {
+			me.finish(j, err)
+			return
+		}
+		mainthread.CallNonBlock(func() { me.uploadTexture(j, img) })
+
+	case sideeffect.AssetShader:
+		vertText, err := ioutil.ReadFile(files[0])
+		if err != nil {
+			me.finish(j, err)
+			return
+		}
+		fragText, err := ioutil.ReadFile(files[1])
+		if err != nil {
+			me.finish(j, err)
+			return
+		}
+		mainthread.CallNonBlock(func() { me.compileShader(j, string(vertText), string(fragText)) })
+
+	case sideeffect.AssetFont:
+		ttf, err := parseFont(j.Path)
+		if err != nil {
+			me.finish(j, err)
+			return
+		}
+		mainthread.CallNonBlock(func() { me.buildFont(j, ttf) })
+
+	case sideeffect.AssetMaterial:
+		// no GL needed, just the shader and textures it names
+		def, err := helpers.LoadMaterialDef(j.Path)
+		if err != nil {
+			me.finish(j, err)
+			return
+		}
+		me.mu.Lock()
+		me.materials[j.Path] = def
+		me.mu.Unlock()
+		me.Request(Request{Kind: sideeffect.AssetShader, Path: def.Shader})
+		for _, path := range def.TexturePaths() {
+			me.Request(Request{Kind: sideeffect.AssetTexture, Path: path})
+		}
+		me.finish(j, nil)
+
+	case sideeffect.AssetModel:
+		scene, err := helpers.ReadGLTF(j.Path)
+		if err != nil {
+			me.finish(j, err)
+			return
+		}
+		mainthread.CallNonBlock(func() { me.uploadModel(j, scene) })
+
+	default:
+		me.finish(j, fmt.Errorf("unknown asset kind %d", j.Kind))
+	}
+}
+
+func parseFont(path string) (*truetype.Font, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return glfont.ParseTrueType(fd)
+}
+
+// uploadTexture, compileShader, buildFont and uploadModel run on the main
+// thread. A reloaded asset replaces the old one, which is then deleted.
+
+func (me *Manager) uploadTexture(j job, img *image.NRGBA) {
+	tex := helpers.UploadTexture(img)
+	me.mu.Lock()
+	old, had := me.textures[j.Path]
+	me.textures[j.Path] = tex
+	me.mu.Unlock()
+	if had {
+		gl.DeleteTextures(1, &old)
+	}
+	me.finish(j, nil)
+}
+
+func (me *Manager) compileShader(j job, vertText, fragText string) {
+	prog, err := helpers.CompileShaderProgram(vertText, fragText)
+	if err != nil {
+		me.finish(j, err)
+		return
+	}
+	me.mu.Lock()
+	old, had := me.shaders[j.Path]
+	me.shaders[j.Path] = prog
+	me.mu.Unlock()
+	if had {
+		old.Delete()
+	}
+	me.finish(j, nil)
+}
+
+func (me *Manager) buildFont(j job, ttf *truetype.Font) {
+	font, err := glfont.NewFont2(ttf, int32(j.Size), me.ScreenWidth, me.ScreenHeight, nil)
+	if err != nil {
+		me.finish(j, err)
+		return
+	}
+	me.mu.Lock()
+	old, had := me.fonts[j.Request]
+	me.fonts[j.Request] = font
+	me.mu.Unlock()
+	if had {
+		old.Delete()
+	}
+	me.finish(j, nil)
+}
+
+func (me *Manager) uploadModel(j job, scene *helpers.GLTFScene) {
+	scene.Upload()
+	me.mu.Lock()
+	old, had := me.models[j.Path]
+	me.models[j.Path] = scene
+	me.mu.Unlock()
+	if had {
+		old.Delete()
+	}
+	me.finish(j, nil)
+}
+
+func (me *Manager) finish(j job, err error) {
+	if err != nil {
+		err = fmt.Errorf("loading %q: %s", j.Path, err)
+	}
+	me.mu.Lock()
+	if err != nil && !j.reload {
+		// let the game try again
+		delete(me.requested, j.Request)
+	} else {
+		// watch for changes from here on. A broken reload is retried once the
+		// files change again.
+		me.watched[j.Request] = j.stamps
+	}
+	me.completed = append(me.completed, Result{Request: j.Request, Err: err, Reload: j.reload})
+	me.mu.Unlock()
+}
@@ -0,0 +1,84 @@
+package assets
+
+import (
+	"os"
+	"time"
+)
+
+type fileStamp struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+func stampFiles(paths []string) []fileStamp {
+	stamps := make([]fileStamp, len(paths))
+	for i, path := range paths {
+		stamps[i].path = path
+		info, err := os.Stat(path)
+		if err != nil {
+			continue // a missing file has a zero stamp
+		}
+		stamps[i].modTime = info.ModTime()
+		stamps[i].size = info.Size()
+	}
+	return stamps
+}
+
+func stampsChanged(stamps []fileStamp) bool {
+	for _, stamp := range stamps {
+		now := stampFiles([]string{stamp.path})[0]
+		if now != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch starts polling the files of loaded assets every interval, reloading
+// any asset whose files have changed. Reloads are reported through Completed
+// with Reload set.
+func (me *Manager) Watch(interval time.Duration) {
+	me.StopWatching()
+	stop := make(chan struct{})
+	me.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				me.poll()
+			}
+		}
+	}()
+}
+
+func (me *Manager) StopWatching() {
+	if me.stop != nil {
+		close(me.stop)
+		me.stop = nil
+	}
+}
+
+func (me *Manager) poll() {
+	me.mu.Lock()
+	watched := make(map[Request][]fileStamp, len(me.watched))
+	for req, stamps := range me.watched {
+		watched[req] = stamps
+	}
+	me.mu.Unlock()
+
+	for req, stamps := range watched {
+		if !stampsChanged(stamps) {
+			continue
+		}
+		// stop watching until the reload is done
+		me.mu.Lock()
+		delete(me.watched, req)
+		me.mu.Unlock()
+		me.enqueue(job{Request: req, reload: true})
+	}
+}
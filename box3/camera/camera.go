@@ -10,6 +10,26 @@ import (
 
 var worldUp = mgl.Vec3{0, 1, 0}
 
+// Mode is how the Camera is steered.
+type Mode int
+
+const (
+	// FPS looks out from Position by Pitch and Yaw
+	FPS Mode = iota
+	// Orbit looks at Target from Distance away, by Pitch and Yaw; Position follows
+	Orbit
+	// Free looks out from Position by Orientation, turning about its own axes
+	// with no up, for six degrees of freedom
+	Free
+)
+
+// The camera's axes before it's rotated: at yaw and pitch 0 it faces +x
+var (
+	axisFront = mgl.Vec3{1, 0, 0}
+	axisUp    = mgl.Vec3{0, 1, 0}
+	axisLeft  = mgl.Vec3{0, 0, -1}
+)
+
 type Camera struct {
 	Matrix mgl.Mat4
 
@@ -17,8 +37,14 @@ type Camera struct {
 	MinPitch, MaxPitch       float64
 	Pitch, Yaw               float64
 	DirFront, DirLeft, DirUp mgl.Vec3
-	UseTarget                bool
-	Target                   *mgl.Vec3
+
+	Mode Mode
+	// Orientation turns the camera's axes in Free mode. Yaw and Pitch are kept
+	// in step with it, minus any roll.
+	Orientation mgl.Quat
+	Target      mgl.Vec3
+	// Distance from Target in Orbit mode, clamped to MinDistance..MaxDistance
+	Distance, MinDistance, MaxDistance float64
 
 	DebugUpdates bool
 }
@@ -26,15 +52,15 @@ type Camera struct {
 var v3s = helpers.Vec3String
 
 func (me *Camera) Update() {
-	if me.UseTarget {
-		// Set Matrix based on an Position + arbitrary world location at Target
-		me.Matrix = mgl.LookAtV(
-			me.Position,
-			*me.Target,
-			worldUp,
-		)
-		fmt.Printf("Camera.Update() pos=%s target=%s\n", v3s(&me.Position), v3s(me.Target))
-
+	var front, left, up mgl.Vec3
+	if me.Mode == Free {
+		// our axes are wherever the orientation has turned them
+		me.Orientation = me.Orientation.Normalize()
+		front = me.Orientation.Rotate(axisFront).Normalize()
+		left = me.Orientation.Rotate(axisLeft).Normalize()
+		up = me.Orientation.Rotate(axisUp).Normalize()
+		me.Yaw = math.Atan2(float64(-front[2]), float64(front[0]))
+		me.Pitch = math.Asin(float64(mgl.Clamp(front[1], -1, 1)))
 	} else {
 		// constrain pitch
 		me.Pitch = float64(mgl.Clamp(float32(me.Pitch), float32(me.MinPitch), float32(me.MaxPitch)))
@@ -43,30 +69,106 @@ func (me *Camera) Update() {
 		// Set Matrix based on Position + Pitch and Yaw
 		//
 		// "front" represents a forward-pointing vector based on pitch and yaw
-		front := mgl.Vec3{
+		front = mgl.Vec3{
 			float32(math.Cos(me.Pitch) * math.Cos(me.Yaw)),
 			float32(math.Sin(me.Pitch)),
 			float32(math.Cos(me.Pitch) * -math.Sin(me.Yaw)), // -sin makes it so positive yaw pivots around y-axis from x+ (right) toward z- (into screen).  Left hand coord system
 		}.Normalize()
 		// "left" is perpendicular to "front" in the local plane of the camera
-		left := mgl.Vec3{}.Sub(front.Cross(worldUp).Normalize())
+		left = mgl.Vec3{}.Sub(front.Cross(worldUp).Normalize())
 		// "up" vector is perpendicular to the local plane
-		up := front.Cross(left).Normalize()
-		// look at our own nose:
-		target := me.Position.Add(front)
-		me.Matrix = mgl.LookAtV(
-			me.Position,
-			target,
-			up,
-		)
-
-		// keep track of our current vecs in case someone on the outside is interested
-		me.DirFront = front
-		me.DirLeft = left
-		me.DirUp = up
-
-		if me.DebugUpdates {
-			fmt.Printf("Camera.Update() pos=%s yaw=%.2f pitch=%.2f front=%s left=%s up=%s\n", v3s(&me.Position), me.Yaw, me.Pitch, v3s(&me.DirFront), v3s(&me.DirLeft), v3s(&me.DirUp))
-		}
+		up = front.Cross(left).Normalize()
+	}
+
+	if me.Mode == Orbit {
+		// back off from the target along our line of sight
+		me.clampDistance()
+		me.Position = me.Target.Sub(front.Mul(float32(me.Distance)))
+	}
+
+	// look at our own nose:
+	target := me.Position.Add(front)
+	me.Matrix = mgl.LookAtV(
+		me.Position,
+		target,
+		up,
+	)
+
+	// keep track of our current vecs in case someone on the outside is interested
+	me.DirFront = front
+	me.DirLeft = left
+	me.DirUp = up
+
+	if me.DebugUpdates {
+		fmt.Printf("Camera.Update() mode=%d pos=%s yaw=%.2f pitch=%.2f front=%s left=%s up=%s\n", me.Mode, v3s(&me.Position), me.Yaw, me.Pitch, v3s(&me.DirFront), v3s(&me.DirLeft), v3s(&me.DirUp))
+	}
+}
+
+func (me *Camera) clampDistance() {
+	if me.MaxDistance > 0 {
+		me.Distance = math.Min(me.Distance, me.MaxDistance)
+	}
+	me.Distance = math.Max(me.Distance, me.MinDistance)
+}
+
+// SetMode switches modes without moving the view: orbiting starts around
+// the point Distance ahead, and FPS carries on from wherever the camera was
+// left. Leaving Free mode drops any roll, leveling the camera.
+func (me *Camera) SetMode(mode Mode) {
+	if mode == me.Mode {
+		return
+	}
+	if mode == Free {
+		me.Orientation = yawPitchQuat(me.Yaw, me.Pitch)
 	}
+	if mode == Orbit {
+		me.clampDistance()
+		me.Target = me.Position.Add(me.DirFront.Mul(float32(me.Distance)))
+	}
+	me.Mode = mode
+	me.Update()
+}
+
+// Turn turns the camera by the given angles, in radians: in place in FPS
+// mode, swinging around Target in Orbit mode. Roll only applies in Free mode,
+// where all three turn about the camera's own axes.
+func (me *Camera) Turn(yaw, pitch, roll float64) {
+	if me.Mode == Free {
+		local := mgl.QuatRotate(float32(yaw), axisUp).
+			Mul(mgl.QuatRotate(float32(pitch), axisLeft.Mul(-1))).
+			Mul(mgl.QuatRotate(float32(roll), axisFront))
+		me.Orientation = me.Orientation.Mul(local)
+	} else {
+		me.Yaw = math.Mod(me.Yaw+yaw, 2*math.Pi)
+		me.Pitch += pitch
+	}
+	me.Update()
+}
+
+// yawPitchQuat is the Orientation that faces the same way as yaw and pitch
+func yawPitchQuat(yaw, pitch float64) mgl.Quat {
+	return mgl.QuatRotate(float32(yaw), axisUp).Mul(mgl.QuatRotate(float32(pitch), axisLeft.Mul(-1)))
+}
+
+// Zoom scales the Orbit Distance by factor; less than 1 moves in.
+func (me *Camera) Zoom(factor float64) {
+	me.Distance *= factor
+	me.Update()
+}
+
+// Pan slides Target, and the camera with it, across the view: right and up
+// by the given amounts, in world units.
+func (me *Camera) Pan(right, up float32) {
+	move := me.DirLeft.Mul(-right).Add(me.DirUp.Mul(up))
+	me.Target = me.Target.Add(move)
+	if me.Mode != Orbit {
+		me.Position = me.Position.Add(move)
+	}
+	me.Update()
+}
+
+// ViewFrom builds the view matrix as if the camera were at the given position,
+// keeping its current orientation.
+func (me *Camera) ViewFrom(position mgl.Vec3) mgl.Mat4 {
+	return mgl.LookAtV(position, position.Add(me.DirFront), me.DirUp)
 }
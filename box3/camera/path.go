@@ -0,0 +1,195 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Keyframe is a recorded camera placement along a Path.
+type Keyframe struct {
+	Position   mgl.Vec3
+	Yaw, Pitch float64
+	FOV        float32 // vertical field of view, in radians
+}
+
+// KeyframeOf records where the camera is, with the given field of view.
+func KeyframeOf(c *Camera, fov float32) Keyframe {
+	return Keyframe{Position: c.Position, Yaw: c.Yaw, Pitch: c.Pitch, FOV: fov}
+}
+
+// Apply puts the camera at the Keyframe, in FPS mode.
+func (me Keyframe) Apply(c *Camera) {
+	c.SetMode(FPS)
+	c.Position = me.Position
+	c.Yaw = me.Yaw
+	c.Pitch = me.Pitch
+	c.Update()
+}
+
+// Path is a fly-through: a Catmull-Rom spline through its Keyframes, flown
+// at constant speed, easing in at the start and out at the end.
+type Path struct {
+	Keyframes []Keyframe
+
+	// table maps distance along the spline to spline parameter, see measure
+	table []arcSample
+}
+
+type arcSample struct {
+	distance float32
+	t        float32 // in keyframe units: 1.5 is halfway from the 2nd keyframe to the 3rd
+}
+
+// samplesPerSegment is how finely each segment is measured for its length
+const samplesPerSegment = 32
+
+// turnDistance is how far, in world units, turning a radian counts as
+// moving, so a keyframe that only turns the camera still takes some time
+const turnDistance = 1
+
+// Add appends a Keyframe. Yaw is unwrapped to within half a turn of the
+// previous Keyframe's, so the spline turns the short way round.
+func (me *Path) Add(k Keyframe) {
+	if n := len(me.Keyframes); n > 0 {
+		prev := me.Keyframes[n-1].Yaw
+		k.Yaw = prev + math.Remainder(k.Yaw-prev, 2*math.Pi)
+	}
+	me.Keyframes = append(me.Keyframes, k)
+	me.table = nil
+}
+
+// Length is the distance along the spline, in world units, with turns
+// counted by turnDistance.
+func (me *Path) Length() float32 {
+	me.measure()
+	if len(me.table) == 0 {
+		return 0
+	}
+	return me.table[len(me.table)-1].distance
+}
+
+// At is the camera placement u (0..1) of the way along the Path. u is eased,
+// then taken as a fraction of the Length, so the camera moves at an even
+// speed between keyframes however far apart they are. A Path whose keyframes
+// are all the same goes evenly by keyframe.
+func (me *Path) At(u float64) Keyframe {
+	n := len(me.Keyframes)
+	if n == 0 {
+		return Keyframe{}
+	}
+	if n == 1 {
+		return me.Keyframes[0]
+	}
+	eased := smoothstep(mgl.Clamp(float32(u), 0, 1))
+
+	var t float32
+	if length := me.Length(); length > 0 {
+		t = me.paramAt(eased * length)
+	} else {
+		t = eased * float32(n-1)
+	}
+	return me.sample(t)
+}
+
+// paramAt inverts the arc length table: the spline parameter distance along it.
+func (me *Path) paramAt(distance float32) float32 {
+	i := sort.Search(len(me.table), func(i int) bool { return me.table[i].distance >= distance })
+	if i == 0 {
+		return 0
+	}
+	if i == len(me.table) {
+		return me.table[i-1].t
+	}
+	a, b := me.table[i-1], me.table[i]
+	if b.distance == a.distance {
+		return b.t
+	}
+	f := (distance - a.distance) / (b.distance - a.distance)
+	return a.t + (b.t-a.t)*f
+}
+
+// measure builds the arc length table, if the Keyframes have changed.
+func (me *Path) measure() {
+	n := len(me.Keyframes)
+	if me.table != nil || n < 2 {
+		return
+	}
+	steps := (n - 1) * samplesPerSegment
+	me.table = make([]arcSample, 0, steps+1)
+	var distance float32
+	prev := me.Keyframes[0]
+	for i := 0; i <= steps; i++ {
+		t := float32(i) / samplesPerSegment
+		k := me.sample(t)
+		turn := math.Hypot(k.Yaw-prev.Yaw, k.Pitch-prev.Pitch)
+		distance += k.Position.Sub(prev.Position).Len() + float32(turn)*turnDistance
+		prev = k
+		me.table = append(me.table, arcSample{distance: distance, t: t})
+	}
+}
+
+// sample evaluates the spline at t, in keyframe units.
+func (me *Path) sample(t float32) Keyframe {
+	last := len(me.Keyframes) - 1
+	seg := int(t)
+	if seg >= last {
+		seg = last - 1
+	}
+	local := t - float32(seg)
+
+	// the ends are repeated to give the first and last segments their tangents
+	at := func(i int) Keyframe {
+		if i < 0 {
+			i = 0
+		}
+		if i > last {
+			i = last
+		}
+		return me.Keyframes[i]
+	}
+	k0, k1, k2, k3 := at(seg-1), at(seg), at(seg+1), at(seg+2)
+	var k Keyframe
+	for axis := 0; axis < 3; axis++ {
+		k.Position[axis] = catmullRom(k0.Position[axis], k1.Position[axis], k2.Position[axis], k3.Position[axis], local)
+	}
+	k.Yaw = float64(catmullRom(float32(k0.Yaw), float32(k1.Yaw), float32(k2.Yaw), float32(k3.Yaw), local))
+	k.Pitch = float64(catmullRom(float32(k0.Pitch), float32(k1.Pitch), float32(k2.Pitch), float32(k3.Pitch), local))
+	k.FOV = catmullRom(k0.FOV, k1.FOV, k2.FOV, k3.FOV, local)
+	return k
+}
+
+// catmullRom interpolates between p1 and p2 by t (0..1), with tangents from
+// their neighbours p0 and p3.
+func catmullRom(p0, p1, p2, p3, t float32) float32 {
+	t2, t3 := t*t, t*t*t
+	return 0.5 * (2*p1 + (p2-p0)*t +
+		(2*p0-5*p1+4*p2-p3)*t2 +
+		(3*p1-p0-3*p2+p3)*t3)
+}
+
+// smoothstep eases in and out: slow at 0 and 1, fastest at 0.5
+func smoothstep(u float32) float32 {
+	return u * u * (3 - 2*u)
+}
+
+// MarshalPath encodes the Keyframes as JSON, for saving.
+func MarshalPath(p *Path) ([]byte, error) {
+	return json.MarshalIndent(p.Keyframes, "", "  ")
+}
+
+// UnmarshalPath decodes Keyframes saved by MarshalPath.
+func UnmarshalPath(data []byte) (*Path, error) {
+	var keyframes []Keyframe
+	if err := json.Unmarshal(data, &keyframes); err != nil {
+		return nil, fmt.Errorf("camera path: %s", err)
+	}
+	p := &Path{}
+	for _, k := range keyframes {
+		p.Add(k)
+	}
+	return p, nil
+}
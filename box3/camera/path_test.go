@@ -0,0 +1,154 @@
+package camera
+
+import (
+	"math"
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func degrees(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+func nearKeyframe(a, b Keyframe) bool {
+	return a.Position.Sub(b.Position).Len() < 1e-4 &&
+		math.Abs(a.Yaw-b.Yaw) < 1e-4 &&
+		math.Abs(a.Pitch-b.Pitch) < 1e-4 &&
+		math.Abs(float64(a.FOV-b.FOV)) < 1e-4
+}
+
+func TestPathEnds(t *testing.T) {
+	first := Keyframe{Position: mgl.Vec3{1, 2, 3}, Yaw: 0.5, Pitch: -0.2, FOV: 0.8}
+	middle := Keyframe{Position: mgl.Vec3{4, 2, -1}, Yaw: 1, Pitch: 0, FOV: 0.7}
+	last := Keyframe{Position: mgl.Vec3{-2, 5, 0}, Yaw: 2, Pitch: 0.3, FOV: 1.2}
+	p := &Path{}
+	p.Add(first)
+	p.Add(middle)
+	p.Add(last)
+
+	for _, tc := range []struct {
+		u    float64
+		want Keyframe
+	}{
+		{0, first},
+		{1, last},
+		{-1, first}, // clamped
+		{2, last},
+	} {
+		if got := p.At(tc.u); !nearKeyframe(got, tc.want) {
+			t.Errorf("At(%v): got %+v, want %+v", tc.u, got, tc.want)
+		}
+	}
+}
+
+func TestPathFewKeyframes(t *testing.T) {
+	var empty Path
+	if got := empty.At(0.5); got != (Keyframe{}) {
+		t.Errorf("empty path: got %+v, want the zero Keyframe", got)
+	}
+	if got := empty.Length(); got != 0 {
+		t.Errorf("empty path: got length %v, want 0", got)
+	}
+
+	k := Keyframe{Position: mgl.Vec3{1, 2, 3}, Yaw: 1, FOV: 0.8}
+	one := &Path{}
+	one.Add(k)
+	if got := one.At(0.5); got != k {
+		t.Errorf("one keyframe: got %+v, want %+v", got, k)
+	}
+
+	// keyframes all alike go evenly by keyframe
+	same := &Path{}
+	same.Add(k)
+	same.Add(k)
+	if got := same.At(0.5); !nearKeyframe(got, k) {
+		t.Errorf("same keyframes: got %+v, want %+v", got, k)
+	}
+}
+
+func TestPathEvenSpeed(t *testing.T) {
+	// along x, the second keyframe a third of the way
+	p := &Path{}
+	for _, x := range []float32{0, 1, 3} {
+		p.Add(Keyframe{Position: mgl.Vec3{x, 0, 0}, FOV: 1})
+	}
+	if got := p.Length(); math.Abs(float64(got)-3) > 1e-3 {
+		t.Fatalf("got length %v, want 3", got)
+	}
+	for _, u := range []float64{0.1, 0.25, 0.4, 0.5, 0.6, 0.75, 0.9} {
+		// the distance covered follows the easing, however the keyframes are spaced
+		want := 3 * float64(smoothstep(float32(u)))
+		got := p.At(u).Position
+		if math.Abs(float64(got[0])-want) > 0.01 || got[1] != 0 || got[2] != 0 {
+			t.Errorf("At(%v): got %v, want x=%.3f", u, got, want)
+		}
+	}
+}
+
+func TestPathTurnsCount(t *testing.T) {
+	p := &Path{}
+	p.Add(Keyframe{Yaw: 0})
+	p.Add(Keyframe{Yaw: 2})
+	if got := p.Length(); math.Abs(float64(got)-2*turnDistance) > 1e-3 {
+		t.Errorf("got length %v, want %v for turning 2 radians", got, 2*turnDistance)
+	}
+	if got := p.At(0.5).Yaw; math.Abs(got-1) > 1e-3 {
+		t.Errorf("halfway: got yaw %v, want 1", got)
+	}
+}
+
+func TestPathYawShortWay(t *testing.T) {
+	p := &Path{}
+	p.Add(Keyframe{Yaw: degrees(350)})
+	p.Add(Keyframe{Yaw: degrees(10)})
+	if got, want := p.Keyframes[1].Yaw, degrees(370); math.Abs(got-want) > 1e-9 {
+		t.Errorf("second yaw unwrapped to %v, want %v", got, want)
+	}
+	// halfway is straight ahead at 360, not back round at 180
+	got := math.Mod(p.At(0.5).Yaw, 2*math.Pi)
+	if math.Abs(got) > 1e-3 && math.Abs(got-2*math.Pi) > 1e-3 {
+		t.Errorf("halfway: got yaw %v degrees, want 0", got*180/math.Pi)
+	}
+
+	// and the other way
+	p = &Path{}
+	p.Add(Keyframe{Yaw: degrees(10)})
+	p.Add(Keyframe{Yaw: degrees(350)})
+	if got, want := p.Keyframes[1].Yaw, degrees(-10); math.Abs(got-want) > 1e-9 {
+		t.Errorf("second yaw unwrapped to %v, want %v", got, want)
+	}
+}
+
+func TestPathMarshal(t *testing.T) {
+	p := &Path{}
+	p.Add(Keyframe{Position: mgl.Vec3{1, 2, 3}, Yaw: 0.5, Pitch: -0.2, FOV: 0.8})
+	p.Add(Keyframe{Position: mgl.Vec3{4, 2, -1}, Yaw: degrees(350), Pitch: 0.1, FOV: 0.7})
+	p.Add(Keyframe{Position: mgl.Vec3{-2, 5, 0}, Yaw: 2, Pitch: 0.3, FOV: 1.2})
+
+	data, err := MarshalPath(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := UnmarshalPath(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Keyframes) != len(p.Keyframes) {
+		t.Fatalf("got %d keyframes, want %d", len(loaded.Keyframes), len(p.Keyframes))
+	}
+	for i := range p.Keyframes {
+		if loaded.Keyframes[i] != p.Keyframes[i] {
+			t.Errorf("keyframe %d: got %+v, want %+v", i, loaded.Keyframes[i], p.Keyframes[i])
+		}
+	}
+	for _, u := range []float64{0, 0.3, 0.7, 1} {
+		if got, want := loaded.At(u), p.At(u); !nearKeyframe(got, want) {
+			t.Errorf("At(%v): got %+v, want %+v", u, got, want)
+		}
+	}
+
+	if _, err := UnmarshalPath([]byte("{not json")); err == nil {
+		t.Error("unmarshalled garbage without an error")
+	}
+}
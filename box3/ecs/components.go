@@ -0,0 +1,209 @@
+package ecs
+
+import mgl "github.com/go-gl/mathgl/mgl32"
+
+// Transform places an Entity relative to its Parent, or to the world if it has none.
+type Transform struct {
+	Parent Entity
+
+	Location      mgl.Vec3
+	Scale         mgl.Vec3
+	Rotation      mgl.Quat
+	LocalRotation mgl.Quat
+
+	// Prev* hold the transform as of the previous Tick, so the renderer can
+	// interpolate between simulation steps
+	PrevLocation      mgl.Vec3
+	PrevRotation      mgl.Quat
+	PrevLocalRotation mgl.Quat
+}
+
+// init fills in the zero values a Spec may leave out: a zero Scale means
+// {1,1,1}, zero rotations mean none, and Prev* start out matching.
+func (me *Transform) init() {
+	if me.Scale == (mgl.Vec3{}) {
+		me.Scale = mgl.Vec3{1, 1, 1}
+	}
+	if me.Rotation == (mgl.Quat{}) {
+		me.Rotation = mgl.QuatIdent()
+	}
+	if me.LocalRotation == (mgl.Quat{}) {
+		me.LocalRotation = mgl.QuatIdent()
+	}
+	me.SavePrevious()
+}
+
+// SavePrevious records the current transform as the previous one.
+func (me *Transform) SavePrevious() {
+	me.PrevLocation = me.Location
+	me.PrevRotation = me.Rotation
+	me.PrevLocalRotation = me.LocalRotation
+}
+
+// Matrix is the transform relative to the Parent, composed the same way as
+// helpers.Positioner: Rotation * Translation * LocalRotation * Scale.
+func (me *Transform) Matrix() mgl.Mat4 {
+	trans := mgl.Translate3D(me.Location[0], me.Location[1], me.Location[2])
+	scale := mgl.Scale3D(me.Scale[0], me.Scale[1], me.Scale[2])
+	return me.Rotation.Mat4().Mul4(trans).Mul4(me.LocalRotation.Mat4()).Mul4(scale)
+}
+
+type MeshKind int
+
+const (
+	MeshCube MeshKind = iota
+	MeshSphere
+	MeshPlaneXZ
+)
+
+// Mesh describes procedurally generated geometry; the renderer decides how to build it.
+type Mesh struct {
+	Kind MeshKind
+
+	// Min, Max are the corners of a MeshCube, or the X/Z extents of a MeshPlaneXZ
+	Min, Max mgl.Vec3
+
+	// Radius, Rings, Sectors describe a MeshSphere
+	Radius         float32
+	Rings, Sectors int
+
+	// Instanced meshes are drawn in a single batch along with every other
+	// entity having an identical Mesh and Material, eg a floor of tiles
+	Instanced bool
+}
+
+func CubeMesh(xmin, ymin, zmin, xmax, ymax, zmax float32) Mesh {
+	return Mesh{
+		Kind: MeshCube,
+		Min:  mgl.Vec3{xmin, ymin, zmin},
+		Max:  mgl.Vec3{xmax, ymax, zmax},
+	}
+}
+
+func SphereMesh(radius float32, rings, sectors int) Mesh {
+	return Mesh{
+		Kind:    MeshSphere,
+		Radius:  radius,
+		Rings:   rings,
+		Sectors: sectors,
+	}
+}
+
+// Material names the material file the renderer draws the Mesh with.
+type Material struct {
+	// Path of a material file, eg "materials/crate.json"
+	Path string
+
+	// Color tints the material
+	Color mgl.Vec4
+}
+
+// Spinner turns the Transform's LocalRotation about Axis at Speed radians per second.
+type Spinner struct {
+	Axis  mgl.Vec3
+	Speed float32
+	Angle float32
+}
+
+// Velocity moves the Transform's Location, in units per second. Entities with a
+// RigidBody are moved by the physics instead.
+type Velocity struct {
+	Linear mgl.Vec3
+}
+
+type ColliderKind int
+
+const (
+	ColliderBox ColliderKind = iota
+	ColliderSphere
+	ColliderPlane
+)
+
+// Collider is the shape an Entity occupies, centered on its Location and
+// placed in the world by its WorldPose: a box is turned and scaled along with
+// the Entity, a sphere grows with its largest scale. An Entity with a Collider
+// but no RigidBody is an immovable obstacle (though the game may still move it
+// about).
+type Collider struct {
+	Kind ColliderKind
+
+	// HalfSize is half the width, height and depth of a ColliderBox
+	HalfSize mgl.Vec3
+
+	// Radius of a ColliderSphere
+	Radius float32
+
+	// Normal and Offset define a ColliderPlane: the points p where
+	// Normal.Dot(p) == Offset, with the Transform's Location (if any) added in.
+	// Everything on the far side of it is solid.
+	Normal mgl.Vec3
+	Offset float32
+}
+
+// Scaled is the Collider grown by a world scale, as from WorldPose: a box along
+// each axis, a sphere by the largest of them. Planes aren't changed.
+func (me Collider) Scaled(scale mgl.Vec3) Collider {
+	switch me.Kind {
+	case ColliderBox:
+		me.HalfSize = mgl.Vec3{me.HalfSize[0] * scale[0], me.HalfSize[1] * scale[1], me.HalfSize[2] * scale[2]}
+	case ColliderSphere:
+		largest := scale[0]
+		for _, s := range scale[1:] {
+			if s > largest {
+				largest = s
+			}
+		}
+		me.Radius *= largest
+	}
+	return me
+}
+
+// MeshCollider makes a Collider matching a Mesh: a box around a (centered)
+// MeshCube, a sphere of a MeshSphere's radius, or a plane through a MeshPlaneXZ.
+func MeshCollider(mesh Mesh) Collider {
+	switch mesh.Kind {
+	case MeshSphere:
+		return Collider{Kind: ColliderSphere, Radius: mesh.Radius}
+	case MeshPlaneXZ:
+		return Collider{Kind: ColliderPlane, Normal: mgl.Vec3{0, 1, 0}, Offset: mesh.Min[1]}
+	default:
+		return Collider{Kind: ColliderBox, HalfSize: mesh.Max.Sub(mesh.Min).Mul(0.5)}
+	}
+}
+
+// RigidBody puts an Entity with a Collider under the control of the physics,
+// which moves its Transform's Location and LocalRotation each Tick. The
+// Transform mustn't have a Parent or a Rotation; the physics won't take
+// bodies that do.
+type RigidBody struct {
+	Mass float32
+
+	// LinearDamping, AngularDamping are the fraction of velocity kept each
+	// second, eg 0.99; zero means no damping
+	LinearDamping, AngularDamping float32
+
+	// Velocity and AngularVelocity (radians per second about each axis) are
+	// read from the physics after each step, and may be set to launch the body
+	Velocity, AngularVelocity mgl.Vec3
+}
+
+// Label is a line of text drawn at the Entity's Transform with the game's font.
+type Label struct {
+	Text string
+}
+
+// Model is a glTF file, drawn at the Transform by the renderer, playing one of
+// the file's animations.
+type Model struct {
+	// Path of a .gltf or .glb file, eg "models/bendy.gltf"
+	Path string
+
+	// Shader names the shader to draw it with, which must handle skinning
+	Shader string
+
+	// Clip is the name of the animation playing, or "" for none. Time is how
+	// long it's played, in seconds, advanced by Animate; the renderer cross-fades
+	// when Clip changes.
+	Clip string
+	Time float32
+}
@@ -0,0 +1,39 @@
+package ecs
+
+import mgl "github.com/go-gl/mathgl/mgl32"
+
+// The systems below run once per Tick, in this order, from game.Update.
+
+// SavePrevious records every Transform before it's moved, for interpolation.
+func SavePrevious(w *World) {
+	for _, e := range w.Query(ComponentTransform) {
+		w.Transforms[e].SavePrevious()
+	}
+}
+
+// Spin advances each Spinner and sets its Transform's LocalRotation.
+func Spin(w *World, dt float32) {
+	for _, e := range w.Query(ComponentTransform | ComponentSpinner) {
+		s := w.Spinners[e]
+		s.Angle += s.Speed * dt
+		w.Transforms[e].LocalRotation = mgl.QuatRotate(s.Angle, s.Axis)
+	}
+}
+
+// Move applies each Velocity to its Transform's Location, leaving RigidBodies to the physics.
+func Move(w *World, dt float32) {
+	for _, e := range w.Query(ComponentTransform | ComponentVelocity) {
+		if w.Has(e, ComponentRigidBody) {
+			continue
+		}
+		t := w.Transforms[e]
+		t.Location = t.Location.Add(w.Velocities[e].Linear.Mul(dt))
+	}
+}
+
+// Animate advances each Model's animation Time.
+func Animate(w *World, dt float32) {
+	for _, e := range w.Query(ComponentModel) {
+		w.Models[e].Time += dt
+	}
+}
@@ -0,0 +1,265 @@
+// Package ecs holds the box3 game world as entities made up of components.
+//
+// An Entity is just an id. Its components live in the World's per-type maps,
+// and a mask per Entity records which ones it has, so systems can find what
+// they operate on with Query. Entities are kept in creation order, which
+// keeps every system deterministic for record/replay.
+//
+// Like the rest of the game state, nothing here makes GL calls.
+package ecs
+
+import mgl "github.com/go-gl/mathgl/mgl32"
+
+// Entity identifies a thing in the World. The zero Entity is never used, so it
+// can mean "none", eg a Transform with no Parent.
+type Entity int32
+
+type ComponentMask uint32
+
+const (
+	ComponentTransform ComponentMask = 1 << iota
+	ComponentMesh
+	ComponentMaterial
+	ComponentSpinner
+	ComponentVelocity
+	ComponentCollider
+	ComponentLabel
+	ComponentRigidBody
+	ComponentModel
+)
+
+type World struct {
+	Transforms map[Entity]*Transform
+	Meshes     map[Entity]*Mesh
+	Materials  map[Entity]*Material
+	Spinners   map[Entity]*Spinner
+	Velocities map[Entity]*Velocity
+	Colliders  map[Entity]*Collider
+	Labels     map[Entity]*Label
+	Bodies     map[Entity]*RigidBody
+	Models     map[Entity]*Model
+
+	entities []Entity
+	masks    map[Entity]ComponentMask
+	last     Entity
+}
+
+func NewWorld() *World {
+	return &World{
+		Transforms: make(map[Entity]*Transform),
+		Meshes:     make(map[Entity]*Mesh),
+		Materials:  make(map[Entity]*Material),
+		Spinners:   make(map[Entity]*Spinner),
+		Velocities: make(map[Entity]*Velocity),
+		Colliders:  make(map[Entity]*Collider),
+		Labels:     make(map[Entity]*Label),
+		Bodies:     make(map[Entity]*RigidBody),
+		Models:     make(map[Entity]*Model),
+		masks:      make(map[Entity]ComponentMask),
+	}
+}
+
+// Spec describes an Entity by its components; nil ones are left off. Spawn
+// copies the components, so a Spec can be used over and over as a prefab.
+type Spec struct {
+	Transform *Transform
+	Mesh      *Mesh
+	Material  *Material
+	Spinner   *Spinner
+	Velocity  *Velocity
+	Collider  *Collider
+	Label     *Label
+	RigidBody *RigidBody
+	Model     *Model
+
+	// Children are spawned along with the Entity, with their Transform's
+	// Parent set to it
+	Children []Spec
+}
+
+// Spawn creates an Entity, and its children, from spec.
+func (me *World) Spawn(spec Spec) Entity {
+	me.last++
+	e := me.last
+	me.entities = append(me.entities, e)
+
+	var mask ComponentMask
+	if spec.Transform != nil {
+		t := *spec.Transform
+		t.init()
+		me.Transforms[e] = &t
+		mask |= ComponentTransform
+	}
+	if spec.Mesh != nil {
+		m := *spec.Mesh
+		me.Meshes[e] = &m
+		mask |= ComponentMesh
+	}
+	if spec.Material != nil {
+		m := *spec.Material
+		me.Materials[e] = &m
+		mask |= ComponentMaterial
+	}
+	if spec.Spinner != nil {
+		s := *spec.Spinner
+		me.Spinners[e] = &s
+		mask |= ComponentSpinner
+	}
+	if spec.Velocity != nil {
+		v := *spec.Velocity
+		me.Velocities[e] = &v
+		mask |= ComponentVelocity
+	}
+	if spec.Collider != nil {
+		c := *spec.Collider
+		me.Colliders[e] = &c
+		mask |= ComponentCollider
+	}
+	if spec.Label != nil {
+		l := *spec.Label
+		me.Labels[e] = &l
+		mask |= ComponentLabel
+	}
+	if spec.RigidBody != nil {
+		b := *spec.RigidBody
+		me.Bodies[e] = &b
+		mask |= ComponentRigidBody
+	}
+	if spec.Model != nil {
+		m := *spec.Model
+		me.Models[e] = &m
+		mask |= ComponentModel
+	}
+	me.masks[e] = mask
+
+	for _, childSpec := range spec.Children {
+		child := me.Spawn(childSpec)
+		if t, ok := me.Transforms[child]; ok {
+			t.Parent = e
+		}
+	}
+	return e
+}
+
+// Grid lays out Columns x Rows copies of Prefab across the XZ plane, Spacing
+// apart, with the first at Origin.
+type Grid struct {
+	Columns, Rows int
+	Spacing       float32
+	Origin        mgl.Vec3
+	Prefab        Spec
+}
+
+// SpawnGrid spawns the Grid's entities, a column at a time. The Prefab must have a Transform.
+func (me *World) SpawnGrid(g Grid) []Entity {
+	var spawned []Entity
+	for i := 0; i < g.Columns; i++ {
+		for j := 0; j < g.Rows; j++ {
+			t := *g.Prefab.Transform
+			t.Location = t.Location.Add(g.Origin).Add(mgl.Vec3{float32(i) * g.Spacing, 0, float32(j) * g.Spacing})
+			spec := g.Prefab
+			spec.Transform = &t
+			spawned = append(spawned, me.Spawn(spec))
+		}
+	}
+	return spawned
+}
+
+// Remove deletes the Entity and all its components. Its children are left
+// where they are, parentless.
+func (me *World) Remove(e Entity) {
+	if !me.Alive(e) {
+		return
+	}
+	for i, other := range me.entities {
+		if other == e {
+			me.entities = append(me.entities[:i], me.entities[i+1:]...)
+			break
+		}
+	}
+	delete(me.masks, e)
+	delete(me.Transforms, e)
+	delete(me.Meshes, e)
+	delete(me.Materials, e)
+	delete(me.Spinners, e)
+	delete(me.Velocities, e)
+	delete(me.Colliders, e)
+	delete(me.Labels, e)
+	delete(me.Bodies, e)
+	delete(me.Models, e)
+
+	for _, t := range me.Transforms {
+		if t.Parent == e {
+			t.Parent = 0
+		}
+	}
+}
+
+func (me *World) Alive(e Entity) bool {
+	_, ok := me.masks[e]
+	return ok
+}
+
+// WorldMatrix is the Entity's Transform, including its parents', in world
+// space. An Entity without a Transform is at the origin.
+func (me *World) WorldMatrix(e Entity) mgl.Mat4 {
+	m := mgl.Ident4()
+	for e != 0 {
+		t, ok := me.Transforms[e]
+		if !ok {
+			break
+		}
+		m = t.Matrix().Mul4(m)
+		e = t.Parent
+	}
+	return m
+}
+
+// WorldPose splits the Entity's WorldMatrix into a location, a rotation and
+// the scale along each of the rotated axes. Any shear, from a non-uniform
+// Scale on a parent of a rotated Entity, is lost.
+func (me *World) WorldPose(e Entity) (location mgl.Vec3, rotation mgl.Quat, scale mgl.Vec3) {
+	m := me.WorldMatrix(e)
+	var axes mgl.Mat3
+	for i := 0; i < 3; i++ {
+		axis := m.Col(i).Vec3()
+		scale[i] = axis.Len()
+		if scale[i] != 0 {
+			axis = axis.Mul(1 / scale[i])
+		}
+		axes.SetCol(i, axis)
+	}
+	return m.Col(3).Vec3(), mgl.Mat4ToQuat(axes.Mat4()).Normalize(), scale
+}
+
+// WorldPlane is the Entity's ColliderPlane in world space: the points p where
+// normal.Dot(p) == offset, with normal of unit length.
+func (me *World) WorldPlane(e Entity) (normal mgl.Vec3, offset float32) {
+	col := me.Colliders[e]
+	n := col.Normal.Normalize()
+	m := me.WorldMatrix(e)
+	normal = m.Mat3().Inv().Transpose().Mul3x1(n).Normalize()
+	point := m.Mul4x1(n.Mul(col.Offset).Vec4(1)).Vec3()
+	return normal, normal.Dot(point)
+}
+
+// Entities lists every Entity in creation order.
+func (me *World) Entities() []Entity {
+	return me.entities
+}
+
+// Has reports whether the Entity has all the components in mask.
+func (me *World) Has(e Entity, mask ComponentMask) bool {
+	return me.masks[e]&mask == mask
+}
+
+// Query lists, in creation order, the entities having all the components in mask.
+func (me *World) Query(mask ComponentMask) []Entity {
+	var found []Entity
+	for _, e := range me.entities {
+		if me.masks[e]&mask == mask {
+			found = append(found, e)
+		}
+	}
+	return found
+}
@@ -0,0 +1,189 @@
+package ecs
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSpawnChildren(t *testing.T) {
+	w := NewWorld()
+	e := w.Spawn(Spec{
+		Transform: &Transform{Location: mgl.Vec3{1, 0, 0}},
+		Children: []Spec{
+			{Transform: &Transform{Location: mgl.Vec3{0, 1, 0}}},
+			{Label: &Label{Text: "no transform"}},
+			{
+				Transform: &Transform{},
+				Children:  []Spec{{Transform: &Transform{Location: mgl.Vec3{0, 0, 1}}}},
+			},
+		},
+	})
+	if got, want := w.Entities(), []Entity{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got entities %v, want %v", got, want)
+	}
+	if e != 1 {
+		t.Errorf("got entity %d, want 1", e)
+	}
+	for _, tc := range []struct {
+		e, parent Entity
+	}{
+		{1, 0},
+		{2, 1},
+		{4, 1},
+		{5, 4},
+	} {
+		if got := w.Transforms[tc.e].Parent; got != tc.parent {
+			t.Errorf("entity %d: got parent %d, want %d", tc.e, got, tc.parent)
+		}
+	}
+	if !w.Has(3, ComponentLabel) || w.Has(3, ComponentTransform) {
+		t.Errorf("entity 3 should have only a Label")
+	}
+
+	if got, want := w.WorldMatrix(5).Col(3).Vec3(), (mgl.Vec3{1, 0, 1}); got != want {
+		t.Errorf("grandchild at %v, want %v", got, want)
+	}
+}
+
+func TestSpawnCopiesSpec(t *testing.T) {
+	w := NewWorld()
+	spec := Spec{Transform: &Transform{}, Label: &Label{Text: "a"}}
+	a := w.Spawn(spec)
+	b := w.Spawn(spec)
+	w.Transforms[a].Location = mgl.Vec3{1, 2, 3}
+	w.Labels[a].Text = "changed"
+	if w.Transforms[b].Location != (mgl.Vec3{}) || w.Labels[b].Text != "a" {
+		t.Errorf("changing one spawned entity changed another")
+	}
+	if spec.Transform.Scale != (mgl.Vec3{}) {
+		t.Errorf("Spawn changed the Spec's Transform")
+	}
+	if w.Transforms[a].Scale != (mgl.Vec3{1, 1, 1}) {
+		t.Errorf("got scale %v, want {1,1,1}", w.Transforms[a].Scale)
+	}
+}
+
+func TestSpawnGrid(t *testing.T) {
+	w := NewWorld()
+	spawned := w.SpawnGrid(Grid{
+		Columns: 3,
+		Rows:    2,
+		Spacing: 2,
+		Origin:  mgl.Vec3{-1, 5, 10},
+		Prefab: Spec{
+			Transform: &Transform{Location: mgl.Vec3{0, 1, 0}},
+			Mesh:      &Mesh{Kind: MeshCube, Instanced: true},
+		},
+	})
+	want := []mgl.Vec3{
+		{-1, 6, 10}, {-1, 6, 12},
+		{1, 6, 10}, {1, 6, 12},
+		{3, 6, 10}, {3, 6, 12},
+	}
+	if len(spawned) != len(want) {
+		t.Fatalf("spawned %d, want %d", len(spawned), len(want))
+	}
+	for i, e := range spawned {
+		if got := w.Transforms[e].Location; got != want[i] {
+			t.Errorf("entity %d: got location %v, want %v", i, got, want[i])
+		}
+		if !w.Has(e, ComponentTransform|ComponentMesh) {
+			t.Errorf("entity %d is missing components", i)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	w := NewWorld()
+	parent := w.Spawn(Spec{
+		Transform: &Transform{Location: mgl.Vec3{1, 0, 0}},
+		Mesh:      &Mesh{},
+		Children: []Spec{
+			{Transform: &Transform{Location: mgl.Vec3{0, 1, 0}}, Mesh: &Mesh{}},
+			{Transform: &Transform{Location: mgl.Vec3{0, 2, 0}}},
+		},
+	})
+	other := w.Spawn(Spec{Transform: &Transform{}, Mesh: &Mesh{}})
+
+	w.Remove(parent)
+	if w.Alive(parent) || w.Has(parent, ComponentTransform) {
+		t.Errorf("removed entity is still alive")
+	}
+	if _, ok := w.Transforms[parent]; ok {
+		t.Errorf("removed entity still has a Transform")
+	}
+	if got, want := w.Query(ComponentTransform), []Entity{2, 3, other}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got query %v, want %v", got, want)
+	}
+	if got, want := w.Query(ComponentMesh), []Entity{2, other}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got query %v, want %v", got, want)
+	}
+
+	// the children stay, now at their own locations
+	for _, e := range []Entity{2, 3} {
+		if p := w.Transforms[e].Parent; p != 0 {
+			t.Errorf("entity %d still has parent %d", e, p)
+		}
+	}
+	if got, want := w.WorldMatrix(2).Col(3).Vec3(), (mgl.Vec3{0, 1, 0}); got != want {
+		t.Errorf("orphan at %v, want %v", got, want)
+	}
+
+	// removing again, or something that never was, does nothing
+	w.Remove(parent)
+	w.Remove(99)
+	if got := len(w.Entities()); got != 3 {
+		t.Errorf("got %d entities, want 3", got)
+	}
+
+	// and ids aren't reused
+	if e := w.Spawn(Spec{}); e != other+1 {
+		t.Errorf("got entity %d, want %d", e, other+1)
+	}
+}
+
+func TestWorldPose(t *testing.T) {
+	w := NewWorld()
+	turn := mgl.QuatRotate(mgl.DegToRad(90), mgl.Vec3{0, 1, 0})
+	e := w.Spawn(Spec{
+		Transform: &Transform{Location: mgl.Vec3{0, 1, 0}, Scale: mgl.Vec3{2, 2, 2}},
+		Children: []Spec{{
+			Transform: &Transform{Location: mgl.Vec3{0, 0, -1}, Rotation: turn, Scale: mgl.Vec3{1, 3, 1}},
+		}},
+	}) + 1
+
+	location, rotation, scale := w.WorldPose(e)
+	if want := (mgl.Vec3{-2, 1, 0}); location.Sub(want).Len() > 1e-5 {
+		t.Errorf("got location %v, want %v", location, want)
+	}
+	if !rotation.ApproxEqualThreshold(turn, 1e-5) {
+		t.Errorf("got rotation %v, want %v", rotation, turn)
+	}
+	if want := (mgl.Vec3{2, 6, 2}); scale.Sub(want).Len() > 1e-5 {
+		t.Errorf("got scale %v, want %v", scale, want)
+	}
+}
+
+func TestWorldPlane(t *testing.T) {
+	w := NewWorld()
+	e := w.Spawn(Spec{
+		Transform: &Transform{
+			Location: mgl.Vec3{0, 1, 0},
+			Rotation: mgl.QuatRotate(mgl.DegToRad(90), mgl.Vec3{0, 0, 1}),
+			Scale:    mgl.Vec3{1, 2, 1},
+		},
+		Collider: &Collider{Kind: ColliderPlane, Normal: mgl.Vec3{0, 2, 0}, Offset: 0.5},
+	})
+
+	// the plane, scaled up to y=1 and raised to y=2, is orbited round to face -x, 2 out
+	normal, offset := w.WorldPlane(e)
+	if want := (mgl.Vec3{-1, 0, 0}); normal.Sub(want).Len() > 1e-5 {
+		t.Errorf("got normal %v, want %v", normal, want)
+	}
+	if math.Abs(float64(offset-2)) > 1e-5 {
+		t.Errorf("got offset %v, want 2", offset)
+	}
+}
@@ -1,6 +1,11 @@
 package game
 
-import "github.com/go-gl/glfw/v3.2/glfw"
+import (
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
 
 //go:generate stringer -type=ActionType
 
@@ -15,18 +20,27 @@ const (
 	Keyboard
 	Char
 	WindowSize
+	Error
+	AssetLoaded
+	AssetFailed
+	PhysicsStep
+	FileRead
 )
 
 type Action struct {
 	Type        ActionType
-	Tick        *TickAction
-	MouseEnter  *MouseEnterAction
-	MouseMove   *MouseMoveAction
-	MouseButton *MouseButtonAction
-	MouseScroll *MouseScrollAction
-	Keyboard    *KeyboardAction
-	Char        *CharAction
-	WindowSize  *WindowSizeAction
+	Tick        *TickAction        `json:",omitempty"`
+	MouseEnter  *MouseEnterAction  `json:",omitempty"`
+	MouseMove   *MouseMoveAction   `json:",omitempty"`
+	MouseButton *MouseButtonAction `json:",omitempty"`
+	MouseScroll *MouseScrollAction `json:",omitempty"`
+	Keyboard    *KeyboardAction    `json:",omitempty"`
+	Char        *CharAction        `json:",omitempty"`
+	WindowSize  *WindowSizeAction  `json:",omitempty"`
+	Error       *ErrorAction       `json:",omitempty"`
+	Asset       *AssetAction       `json:",omitempty"`
+	Physics     *PhysicsAction     `json:",omitempty"`
+	File        *FileAction        `json:",omitempty"`
 }
 
 type TickAction struct {
@@ -50,6 +64,55 @@ func (me CharAction) String() string {
 	return string(me.Char)
 }
 
+// ErrorAction reports that the harness failed to carry out a side effect.
+type ErrorAction struct {
+	Message    string
+	SideEffect string // type of the side effect that failed, eg "*sideeffect.PlaySound"
+}
+
+// AssetAction reports the outcome of a LoadAsset side effect, for both
+// AssetLoaded and AssetFailed. Reload is set when the asset's files changed
+// on disk and it was loaded again; a failed reload keeps the old version.
+type AssetAction struct {
+	Kind   sideeffect.AssetKind
+	Path   string
+	Size   int    `json:",omitempty"`
+	Reload bool   `json:",omitempty"`
+	Error  string `json:",omitempty"` // why an AssetFailed failed, eg the GLSL compile log
+}
+
+// PhysicsAction is the outcome of one physics step, following a Tick: where
+// the RigidBodies moved to, and which pairs of entities began or stopped
+// touching.
+type PhysicsAction struct {
+	Bodies []BodyMotion
+	Began  []ContactAction `json:",omitempty"`
+	Ended  []ContactAction `json:",omitempty"`
+}
+
+// BodyMotion is where the physics has moved a RigidBody entity.
+type BodyMotion struct {
+	Entity                    ecs.Entity
+	Location                  mgl.Vec3
+	Rotation                  mgl.Quat // the Transform's LocalRotation
+	Velocity, AngularVelocity mgl.Vec3
+}
+
+// ContactAction says two entities are touching, or were. B is the immovable
+// one, if either is.
+type ContactAction struct {
+	A, B        ecs.Entity
+	Point       mgl.Vec3
+	Normal      mgl.Vec3
+	Penetration float32
+}
+
+// FileAction carries the contents of a file asked for with a ReadFile side effect.
+type FileAction struct {
+	Path string
+	Data []byte
+}
+
 type WindowSizeAction struct {
 	FbWidth, FbHeight int
 	Width, Height     int
@@ -65,6 +128,27 @@ type MouseMoveAction struct {
 	InBounds                 bool
 }
 
+// NewMouseMoveAction builds a MouseMoveAction from a cursor pixel position and
+// pixel delta, normalizing both against the window size so that (-1,-1) is the
+// lower-left corner and (1,1) the upper-right.
+func NewMouseMoveAction(xpos, ypos, dx, dy float32, winWidth, winHeight int) *MouseMoveAction {
+	w2 := float32(winWidth) / 2
+	h2 := float32(winHeight) / 2
+	nx := (xpos - w2) / w2
+	ny := -(ypos - h2) / h2
+	return &MouseMoveAction{
+		PixX:     xpos,
+		PixY:     ypos,
+		PixDx:    dx,
+		PixDy:    dy,
+		X:        nx, // mgl.Clamp(nx, -1, 1),
+		Y:        ny, //mgl.Clamp(ny, -1, 1),
+		Dx:       dx / w2,
+		Dy:       dy / h2,
+		InBounds: nx >= -1.0 && nx <= 1.0 && ny <= 1.0 && ny >= -1.0,
+	}
+}
+
 // MouseButton:
 //   glfw.MouseButton1
 //   glfw.MouseButton2  ...8, Last, Left, Right, Middle
@@ -4,9 +4,28 @@ package game
 
 import "strconv"
 
-const _ActionType_name = "Tick"
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Tick-0]
+	_ = x[MouseEnter-1]
+	_ = x[MouseMove-2]
+	_ = x[MouseButton-3]
+	_ = x[MouseScroll-4]
+	_ = x[Keyboard-5]
+	_ = x[Char-6]
+	_ = x[WindowSize-7]
+	_ = x[Error-8]
+	_ = x[AssetLoaded-9]
+	_ = x[AssetFailed-10]
+	_ = x[PhysicsStep-11]
+	_ = x[FileRead-12]
+}
+
+const _ActionType_name = "TickMouseEnterMouseMoveMouseButtonMouseScrollKeyboardCharWindowSizeErrorAssetLoadedAssetFailedPhysicsStepFileRead"
 
-var _ActionType_index = [...]uint8{0, 4}
+var _ActionType_index = [...]uint8{0, 4, 14, 23, 34, 45, 53, 57, 67, 72, 83, 94, 105, 113}
 
 func (i ActionType) String() string {
 	if i < 0 || i >= ActionType(len(_ActionType_index)-1) {
@@ -0,0 +1,136 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/camera"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+const (
+	defaultFOV      = Pi_4
+	cameraPathFile  = "camera_path.json"
+	flyThroughSpeed = 2.0 // world units per second along the path
+	minKeyframeTime = 1.0 // seconds, at least, per keyframe
+)
+
+// FlyThrough plays the recorded CameraPath back on the camera, one Tick at a time.
+type FlyThrough struct {
+	Playing  bool
+	Time     float64
+	Duration float64
+}
+
+// updateFlyThroughKeys handles the camera path keys: K records a keyframe,
+// Ctrl-K clears them, P plays or stops the fly-through, F5 saves the path and
+// F9 loads it.
+func updateFlyThroughKeys(s *State, ka *KeyboardAction) []sideeffect.Event {
+	if ka.Action != glfw.Press {
+		return nil
+	}
+	switch ka.Key {
+	case glfw.KeyK:
+		if ka.Modifier&glfw.ModControl != 0 {
+			s.CameraPath = camera.Path{}
+			fmt.Printf("game.Update() camera path cleared\n")
+			if s.FlyThrough.Playing {
+				return []sideeffect.Event{stopFlyThrough(s)}
+			}
+		} else {
+			s.CameraPath.Add(camera.KeyframeOf(&s.Camera, s.FOV))
+			fmt.Printf("game.Update() camera keyframe %d at %s\n", len(s.CameraPath.Keyframes), v3s(&s.Camera.Position))
+		}
+	case glfw.KeyP:
+		if s.FlyThrough.Playing {
+			return []sideeffect.Event{stopFlyThrough(s)}
+		}
+		return startFlyThrough(s)
+	case glfw.KeyF5:
+		data, err := camera.MarshalPath(&s.CameraPath)
+		if err != nil {
+			return []sideeffect.Event{sideeffect.NewError(err)}
+		}
+		return []sideeffect.Event{&sideeffect.WriteFile{Path: cameraPathFile, Data: data}}
+	case glfw.KeyF9:
+		return []sideeffect.Event{&sideeffect.ReadFile{Path: cameraPathFile}}
+	}
+	return nil
+}
+
+// startFlyThrough flies the camera along the CameraPath, which takes as long
+// as its Length at flyThroughSpeed, or minKeyframeTime per keyframe if that's
+// longer. The mouse is let go so it can't steer.
+func startFlyThrough(s *State) []sideeffect.Event {
+	n := len(s.CameraPath.Keyframes)
+	if n < 2 {
+		fmt.Printf("game.Update() can't fly through %d camera keyframes, need 2\n", n)
+		return nil
+	}
+	s.FlyThrough = FlyThrough{
+		Playing:  true,
+		Duration: math.Max(float64(s.CameraPath.Length())/flyThroughSpeed, minKeyframeTime*float64(n-1)),
+	}
+	s.Mouse.GameMode = false
+	placeOnPath(s)
+	s.PrevCameraPos = s.Camera.Position // don't interpolate the jump to the start
+	return []sideeffect.Event{&sideeffect.MouseMode_UI{}}
+}
+
+// stopFlyThrough leaves the camera where it got to, with the player there,
+// and takes the mouse back for looking.
+func stopFlyThrough(s *State) sideeffect.Event {
+	s.FlyThrough.Playing = false
+	s.Player.PlaceAt(s.Camera.Position)
+	s.Mouse.GameMode = true
+	return &sideeffect.MouseMode_Game{}
+}
+
+// updateFlyThrough moves the camera along the path for one Tick. It stops if
+// the path has been cut short of 2 keyframes.
+func updateFlyThrough(s *State, dt float32) []sideeffect.Event {
+	if len(s.CameraPath.Keyframes) < 2 {
+		return []sideeffect.Event{stopFlyThrough(s)}
+	}
+	f := &s.FlyThrough
+	f.Time += float64(dt)
+	placeOnPath(s)
+	if f.Time >= f.Duration {
+		return []sideeffect.Event{stopFlyThrough(s)}
+	}
+	return nil
+}
+
+func placeOnPath(s *State) {
+	k := s.CameraPath.At(s.FlyThrough.Time / s.FlyThrough.Duration)
+	k.Apply(&s.Camera)
+	// a bad keyframe mustn't leave a degenerate projection
+	if k.FOV > 0 && k.FOV != s.FOV {
+		s.FOV = k.FOV
+		recalcProjectionMatrix(s)
+	}
+}
+
+// loadCameraPath replaces the CameraPath with one saved by F5, stopping any
+// fly-through along the old one.
+func loadCameraPath(s *State, data []byte) []sideeffect.Event {
+	path, err := camera.UnmarshalPath(data)
+	if err != nil {
+		fmt.Printf("!! ERROR game.Update() loading %s: %s\n", cameraPathFile, err)
+		s.LastError = err.Error()
+		return nil
+	}
+	for i := range path.Keyframes {
+		if path.Keyframes[i].FOV <= 0 {
+			path.Keyframes[i].FOV = defaultFOV
+		}
+	}
+	var sideEffects []sideeffect.Event
+	if s.FlyThrough.Playing {
+		sideEffects = append(sideEffects, stopFlyThrough(s))
+	}
+	s.CameraPath = *path
+	fmt.Printf("game.Update() loaded %d camera keyframes\n", len(path.Keyframes))
+	return sideEffects
+}
@@ -5,11 +5,9 @@ import (
 	"math"
 
 	"github.com/dcrosby42/go-game-sandbox/box3/camera"
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
 	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
-	"github.com/dcrosby42/go-game-sandbox/glfont"
-	"github.com/dcrosby42/go-game-sandbox/helpers"
-	"github.com/go-gl/gl/v3.3-core/gl"
-	_ "github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/dcrosby42/go-game-sandbox/lighting"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	mgl "github.com/go-gl/mathgl/mgl32"
 )
@@ -22,6 +20,10 @@ const (
 	TwoPi                = math.Pi * 2
 	cameraMoveSpeed      = 5
 	mouseLookSensitivity = 0.001
+	orbitSensitivity     = 0.005 // radians per pixel dragged
+	panSensitivity       = 0.001 // world units per pixel, per unit of orbit distance
+	zoomStep             = 1.1   // distance scale per scroll notch
+	freeRollSpeed        = Pi_2  // radians per second
 )
 
 type State struct {
@@ -29,16 +31,29 @@ type State struct {
 	Height            int
 	Camera            camera.Camera
 	StartCamera       camera.Camera
-	Renderables       []*helpers.Renderable
-	Angle             float32
+	World             *ecs.World
 	Projection        mgl.Mat4
+	PrevCameraPos     mgl.Vec3
 	CameraMoveControl DirControl
+	CameraRollControl DirControl // Left and Right roll the Free camera
+	CameraPath        camera.Path
+	FlyThrough        FlyThrough
+	FOV               float32 // vertical field of view of the Projection, in radians
+	Player            Player
+	Selected          Pick // what was last clicked on; Entity 0 if nothing
 	Mouse             Mouse
 	FontSize          int
 	FontFile          string
 	FontTimer         float64
-	FontPositioner    *helpers.Positioner
-	Font              *glfont.Font2
+	LastError         string
+
+	// Lights illuminate the scene on top of the Ambient color, see package lighting
+	Ambient mgl.Vec3
+	Lights  []lighting.Light
+
+	// Assets holds the paths of the assets requested so far: false while
+	// loading, true once loaded. Failed assets are removed.
+	Assets map[string]bool
 }
 type Mouse struct {
 	NormX, NormY float32
@@ -52,7 +67,9 @@ type DirControl struct {
 	Up, Left, Down, Right bool
 }
 
-func Init(s *State) (*State, sideeffect.Event) {
+// Init sets up the game world. It makes no GL calls; GPU resources for the
+// entities are created by the renderer.
+func Init(s *State) (*State, []sideeffect.Event) {
 	if s.Width <= 0 {
 		s.Width = 500
 		fmt.Printf("game.Init - default width %d\n", s.Width)
@@ -63,102 +80,86 @@ func Init(s *State) (*State, sideeffect.Event) {
 	}
 	s.Mouse = Mouse{}
 
-	diffuseShader, err := helpers.LoadShaderProgramFromFile(
-		"shaders/diffuse_texture.vert.glsl",
-		"shaders/diffuse_texture.frag.glsl",
-	)
-	if err != nil {
-		return s, sideeffect.NewError(err)
+	s.World = ecs.NewWorld()
+	for _, spec := range sceneCrates {
+		s.World.Spawn(spec)
 	}
-
-	crateTexture := helpers.MustLoadTexture("assets/crate1_diffuse.png")
-
-	cube1 := helpers.CreateCube(-0.5, -0.5, -0.5, 0.5, 0.5, 0.5)
-	cube1.Shader = diffuseShader
-	cube1.Color = mgl.Vec4{1.0, 1.0, 1.0, 1.0}
-	cube1.Location = mgl.Vec3{0, 0, 0}
-	cube1.Tex0 = crateTexture
-
-	cube2 := helpers.CreateCube(-0.5, -0.5, -0.5, 0.5, 0.5, 0.5)
-	cube2.Shader = diffuseShader
-	cube2.Color = mgl.Vec4{1.0, 1.0, 1.0, 1.0}
-	cube2.Location = mgl.Vec3{-2, -2, 0}
-	cube2.Tex0 = crateTexture
-
-	cube3 := helpers.CreateCube(-0.25, -0.25, -0.25, 0.25, 0.25, 0.25)
-	cube3.Shader = diffuseShader
-	cube3.Color = mgl.Vec4{0.25, 1.0, 0.25, 1.0}
-	cube3.Location = mgl.Vec3{2, 2, 0}
-	cube3.Tex0 = crateTexture
-
-	s.Renderables = []*helpers.Renderable{
-		cube1,
-		cube2,
-		cube3,
+	s.World.SpawnGrid(sceneFloor)
+	for _, spec := range sceneBodies {
+		s.World.Spawn(spec)
 	}
+	s.World.Spawn(sceneModel)
 
-	w := 15           // how many blocks wide
-	h := 15           // how many blocks long
-	bs := float32(1)  // block side length
-	yc := float32(-3) // fixed y coord
-	xc_off := -(bs * float32(w) / 2)
-	zc_off := -(bs * float32(h) / 2)
-	for i := 0; i < w; i++ {
-		for j := 0; j < h; j++ {
-			xc := float32(i)*bs + xc_off
-			zc := float32(j)*bs + zc_off
-			cube := helpers.CreateCube(-0.5, -0.5, -0.5, 0.5, 0.5, 0.5)
-			cube.Shader = diffuseShader
-			cube.Color = mgl.Vec4{1.0, 0.75, 0.6, 1.0}
-			cube.Location = mgl.Vec3{xc, yc, zc}
-			cube.Tex0 = crateTexture
-			s.Renderables = append(s.Renderables, cube)
-		}
+	s.Ambient = mgl.Vec3{0.1, 0.1, 0.1}
+	s.Lights = []lighting.Light{
+		lighting.NewDirectional(mgl.Vec3{1, -1, -1}, mgl.Vec3{1, 1, 1}, 0.6),
+		lighting.NewPoint(mgl.Vec3{2, 3, 2}, mgl.Vec3{1, 0.8, 0.5}, 1),
+		lighting.NewSpot(mgl.Vec3{-2, 4, 0}, mgl.Vec3{0, -1, 0}, mgl.Vec3{0.5, 0.6, 1}, 1.5, Pi_6/2, Pi_6),
 	}
 
-	s.Projection = mgl.Perspective(Pi_4, float32(s.Width)/float32(s.Height), 0.01, 20.0)
+	s.FOV = defaultFOV
+	recalcProjectionMatrix(s)
 
 	s.Camera = camera.Camera{
-		Position:  mgl.Vec3{0, 0, 7},
-		Yaw:       Pi_2,
-		Pitch:     0,
-		MinPitch:  Pi/-2 + 0.0001,
-		MaxPitch:  Pi/2 - 0.0001,
-		UseTarget: false,
-		Target:    &mgl.Vec3{0, 0, 0},
+		Position:    mgl.Vec3{0, 0, 7},
+		Yaw:         Pi_2,
+		Pitch:       0,
+		MinPitch:    Pi/-2 + 0.0001,
+		MaxPitch:    Pi/2 - 0.0001,
+		Mode:        camera.FPS,
+		Distance:    7,
+		MinDistance: 1,
+		MaxDistance: 15,
 	}
 	s.StartCamera = s.Camera //copy
 
 	s.Camera.Update()
+	s.Player.PlaceAt(s.Camera.Position)
 
 	s.Mouse.Buttons = make(map[glfw.MouseButton]glfw.Action)
 	s.Mouse.GameMode = true
 
 	s.FontSize = 40
 	s.FontFile = "/Library/Fonts/Trebuchet MS.ttf"
-	s.FontPositioner = helpers.NewPositioner()
-	// s.FontPositioner.LocalRotation = mgl.QuatRotate(Pi_6, mgl.Vec3{0, 1, 0})
-	s.FontPositioner.Location = mgl.Vec3{0, 0, -3}
 	// s.FontFile = "/Library/Fonts/Microsoft/Consolas.ttf"
 	// s.FontFile = "/Library/Fonts/Microsoft/Abadi MT Condensed Light"
 	// s.FontFile = "/Users/crosby/Downloads/open-sans/OpenSans-Light.ttf"
 	// s.FontFile = "/Library/Fonts/Andale Mono.ttf"
-	resetFonts(s)
 
-	return s, &sideeffect.MouseMode_Game{}
+	savePrevious(s)
+
+	s.Assets = make(map[string]bool)
+	sideEffects := []sideeffect.Event{&sideeffect.MouseMode_Game{}}
+	for _, e := range s.World.Query(ecs.ComponentMaterial) {
+		path := s.World.Materials[e].Path
+		if _, requested := s.Assets[path]; !requested {
+			sideEffects = append(sideEffects, requestAsset(s, sideeffect.AssetMaterial, path, 0))
+		}
+	}
+	for _, e := range s.World.Query(ecs.ComponentModel) {
+		m := s.World.Models[e]
+		if _, requested := s.Assets[m.Path]; !requested {
+			sideEffects = append(sideEffects, requestAsset(s, sideeffect.AssetModel, m.Path, 0))
+		}
+		if _, requested := s.Assets[m.Shader]; !requested {
+			sideEffects = append(sideEffects, requestAsset(s, sideeffect.AssetShader, m.Shader, 0))
+		}
+	}
+	sideEffects = append(sideEffects, requestAsset(s, sideeffect.AssetFont, s.FontFile, s.FontSize))
+	return s, sideEffects
 }
 
-func Update(s *State, action *Action) (*State, sideeffect.Event) {
-	var sideEffect sideeffect.Event
+func Update(s *State, action *Action) (*State, []sideeffect.Event) {
+	var sideEffects []sideeffect.Event
 
 	switch action.Type {
 	case Tick:
-		// Update box's rotation
-		s.Angle += Pi / 2 * float32(action.Tick.Dt)
+		savePrevious(s)
 
-		s.Renderables[0].LocalRotation = mgl.QuatRotate(s.Angle, mgl.Vec3{1, 0, 0})
-		s.Renderables[1].LocalRotation = mgl.QuatRotate(s.Angle, mgl.Vec3{0, 1, 0})
-		s.Renderables[2].LocalRotation = mgl.QuatRotate(s.Angle, mgl.Vec3{0, 0, 1})
+		dt := float32(action.Tick.Dt)
+		ecs.Spin(s.World, dt)
+		ecs.Move(s.World, dt)
+		ecs.Animate(s.World, dt)
 
 		s.FontTimer = action.Tick.Gt
 		// descend camera
@@ -167,55 +168,82 @@ func Update(s *State, action *Action) (*State, sideeffect.Event) {
 		// if eye[1] < 0 {
 		// 	eye[1] = 0
 		// }
-		// move camera
-		speed := float32(cameraMoveSpeed * action.Tick.Dt)
-		// if movePositionSimple(&s.Camera.Position, &s.CameraMoveControl, speed) {
-		if movePositionFps(&s.Camera.Position, &s.Camera.DirFront, &s.Camera.DirLeft, &s.Camera.DirUp, &s.CameraMoveControl, speed) {
-			s.Camera.Update()
+		// move the player, and the camera with it; an orbiting or free camera leaves the player be
+		switch {
+		case s.FlyThrough.Playing:
+			sideEffects = append(sideEffects, updateFlyThrough(s, dt)...)
+		case s.Camera.Mode == camera.FPS:
+			updatePlayer(s, dt)
+		case s.Camera.Mode == camera.Free:
+			updateFreeCamera(s, dt)
 		}
 
 	case Keyboard:
 		updateWasdDirControl(&s.CameraMoveControl, action.Keyboard)
+		updateRollControl(&s.CameraRollControl, action.Keyboard)
+		sideEffects = append(sideEffects, updateFlyThroughKeys(s, action.Keyboard)...)
+		updatePlayerKeys(&s.Player, action.Keyboard)
 
 		// Reset Camera
 		if action.Keyboard.Key == glfw.Key0 && action.Keyboard.Action == glfw.Press {
+			if s.FlyThrough.Playing {
+				sideEffects = append(sideEffects, stopFlyThrough(s))
+			}
+			s.FOV = defaultFOV
+			recalcProjectionMatrix(s)
 			s.Camera = s.StartCamera
 			s.Camera.Update()
+			// the start camera is an FPS one; capture the mouse again after Orbit mode
+			sideEffects = append(sideEffects, backToFPS(s))
+			s.PrevCameraPos = s.Camera.Position // don't interpolate the jump
 		}
 
 		if action.Keyboard.Key == glfw.KeyT && action.Keyboard.Action == glfw.Press {
-			s.Camera.UseTarget = !s.Camera.UseTarget
-			s.Camera.Update()
+			sideEffects = append(sideEffects, toggleOrbit(s))
+		}
+		if action.Keyboard.Key == glfw.KeyG && action.Keyboard.Action == glfw.Press {
+			sideEffects = append(sideEffects, toggleFree(s))
+		}
+		if action.Keyboard.Key == glfw.KeyM && action.Keyboard.Action == glfw.Press {
+			nextModelClip(s.World)
 		}
 
 		// Rotate camera via arrow keys
 		if action.Keyboard.Key == glfw.KeyLeft && action.Keyboard.Action == glfw.Press {
-			s.Camera.Yaw += Pi_6 / 2
-			s.Camera.Update()
+			s.Camera.Turn(Pi_6/2, 0, 0)
 		}
 		if action.Keyboard.Key == glfw.KeyRight && action.Keyboard.Action == glfw.Press {
-			s.Camera.Yaw -= Pi_6 / 2
-			s.Camera.Update()
+			s.Camera.Turn(-Pi_6/2, 0, 0)
 		}
 		if action.Keyboard.Key == glfw.KeyUp && action.Keyboard.Action == glfw.Press {
-			s.Camera.Pitch += Pi_6 / 2
-			s.Camera.Update()
+			s.Camera.Turn(0, Pi_6/2, 0)
 		}
 		if action.Keyboard.Key == glfw.KeyDown && action.Keyboard.Action == glfw.Press {
-			s.Camera.Pitch -= Pi_6 / 2
-			s.Camera.Update()
+			s.Camera.Turn(0, -Pi_6/2, 0)
 		}
 
 		if glfw.KeyEscape == action.Keyboard.Key && glfw.Press == action.Keyboard.Action {
 			if s.Mouse.GameMode {
 				s.Mouse.GameMode = false
-				sideEffect = &sideeffect.MouseMode_UI{}
+				sideEffects = append(sideEffects, &sideeffect.MouseMode_UI{})
 			} else {
 				s.Mouse.GameMode = true
-				sideEffect = &sideeffect.MouseMode_Game{}
+				sideEffects = append(sideEffects, &sideeffect.MouseMode_Game{})
 			}
 		}
 
+		if glfw.KeyF11 == action.Keyboard.Key && glfw.Press == action.Keyboard.Action {
+			sideEffects = append(sideEffects, &sideeffect.ToggleFullscreen{})
+		}
+
+		if glfw.KeyF12 == action.Keyboard.Key && glfw.Press == action.Keyboard.Action {
+			sideEffects = append(sideEffects, &sideeffect.TakeScreenshot{})
+		}
+
+		if glfw.KeyQ == action.Keyboard.Key && glfw.Press == action.Keyboard.Action && action.Keyboard.Modifier&(glfw.ModControl|glfw.ModSuper) != 0 {
+			sideEffects = append(sideEffects, &sideeffect.Quit{})
+		}
+
 	case Char:
 		// fmt.Printf("game.Update() Char: %s mods=%d\n", action.Char.String(), action.Char.Modifier)
 	case MouseEnter:
@@ -226,10 +254,10 @@ func Update(s *State, action *Action) (*State, sideeffect.Event) {
 		s.Mouse.PixY = a.PixY
 		s.Mouse.NormX = a.X
 		s.Mouse.NormY = a.Y
-		if s.Mouse.GameMode {
-			s.Camera.Yaw -= math.Mod(float64(a.PixDx*mouseLookSensitivity), TwoPi)
-			s.Camera.Pitch -= float64(a.PixDy * mouseLookSensitivity)
-			s.Camera.Update()
+		if s.Camera.Mode == camera.Orbit {
+			dragCamera(s, a)
+		} else if s.Mouse.GameMode {
+			s.Camera.Turn(float64(-a.PixDx*mouseLookSensitivity), float64(-a.PixDy*mouseLookSensitivity), 0)
 		}
 		// if action.MouseMove.InBounds {
 		// fmt.Printf("MouseMove(%f,%f, %v)\n", action.MouseMove.X, action.MouseMove.Y, action.MouseMove.InBounds)
@@ -237,53 +265,54 @@ func Update(s *State, action *Action) (*State, sideeffect.Event) {
 	case MouseButton:
 		s.Mouse.Buttons[action.MouseButton.Button] = action.MouseButton.Action
 		fmt.Printf("game.Update() MouseButton: %#v @ pix=(%d, %d) norm=(%.2f, %.2f)\n", action.MouseButton, int(math.Round(float64(s.Mouse.PixX))), int(math.Round(float64(s.Mouse.PixY))), s.Mouse.NormX, s.Mouse.NormY)
+		if action.MouseButton.Button == glfw.MouseButtonLeft && action.MouseButton.Action == glfw.Press {
+			if pick, ok := PickEntity(s.World, cursorRay(s)); ok {
+				s.Selected = pick
+				fmt.Printf("game.Update() picked %d at %s normal=%s distance=%.2f\n", pick.Entity, v3s(&pick.Point), v3s(&pick.Normal), pick.Distance)
+			} else {
+				s.Selected = Pick{}
+			}
+		}
 	case MouseScroll:
 		// fmt.Printf("game.Update() MouseScroll: %#v\n", action.MouseScroll)
+		if s.Camera.Mode == camera.Orbit {
+			s.Camera.Zoom(math.Pow(zoomStep, -action.MouseScroll.Y))
+		}
 
 	case WindowSize:
 		s.Width = action.WindowSize.Width
 		s.Height = action.WindowSize.Height
 		recalcProjectionMatrix(s)
-		// resetFonts(s)
-	}
 
-	if sideEffect != nil {
-		return s, sideEffect
-	}
-	return s, nil
-}
+	case Error:
+		fmt.Printf("!! game.Update() Error from %s: %s\n", action.Error.SideEffect, action.Error.Message)
+		s.LastError = action.Error.Message
 
-func Draw(s *State) {
-	cameraView := s.Camera.Matrix
+	case AssetLoaded:
+		s.Assets[action.Asset.Path] = true
+		if action.Asset.Reload {
+			fmt.Printf("game.Update() reloaded %q\n", action.Asset.Path)
+			s.LastError = ""
+		}
 
-	for _, node := range s.Renderables {
-		node.Draw(s.Projection, cameraView)
-	}
+	case AssetFailed:
+		fmt.Printf("!! game.Update() AssetFailed %q: %s\n", action.Asset.Path, action.Asset.Error)
+		if !action.Asset.Reload {
+			// (a failed reload leaves the previous version in use)
+			delete(s.Assets, action.Asset.Path)
+		}
+		s.LastError = action.Asset.Error
 
-	drawText(s, s.Projection, cameraView)
-}
+	case PhysicsStep:
+		applyPhysics(s.World, action.Physics)
 
-func drawText(s *State, perspective, view mgl.Mat4) {
-	if s.Font == nil {
-		return
+	case FileRead:
+		if action.File.Path == cameraPathFile {
+			sideEffects = append(sideEffects, loadCameraPath(s, action.File.Data)...)
+		}
 	}
-	gl.Disable(gl.CULL_FACE) // glfont seems to do backward triangles?
-
-	x := float32(0)
-	y := float32(45)
-	scale := float32(1)
-	//set color and draw text
-	s.Font.SetColor(1.0, 1.0, 1.0, 1.0) //r,g,b,a font color
-
-	fontPos := s.FontPositioner
-	model := fontPos.GetTransform()
-	transmat := perspective.Mul4(model)
-	// transmat = mgl.Ident4()
-
-	s.Font.Tprintf(x, y, scale, transmat, "Hello World") //x,y,scale,string,printf args
-	// s.Font.Printf(x, y, scale, "Hello World") //x,y,scale,string,printf args
 
-	gl.Enable(gl.CULL_FACE)
+	return s, sideEffects
 }
 
 func updateWasdDirControl(wasd *DirControl, ka *KeyboardAction) {
@@ -308,6 +337,28 @@ func updateWasdDirControl(wasd *DirControl, ka *KeyboardAction) {
 		wasd.Right = pressed
 	}
 }
+
+// updateRollControl tracks the roll keys: Q rolls left, E right. Ctrl-Q quits
+// rather than rolling.
+func updateRollControl(roll *DirControl, ka *KeyboardAction) {
+	pressed := false
+	switch ka.Action {
+	case glfw.Press:
+		pressed = ka.Modifier&(glfw.ModControl|glfw.ModSuper) == 0
+	case glfw.Release:
+		pressed = false
+	default:
+		return
+	}
+
+	switch ka.Key {
+	case glfw.KeyQ:
+		roll.Left = pressed
+	case glfw.KeyE:
+		roll.Right = pressed
+	}
+}
+
 func updateArrowDirControl(wasd *DirControl, ka *KeyboardAction) {
 	pressed := false
 	switch ka.Action {
@@ -351,43 +402,117 @@ func movePositionSimple(pos *mgl.Vec3, dirControl *DirControl, dist float32) (ch
 	return changed
 }
 
-func movePositionFps(pos, front, left, up *mgl.Vec3, dirControl *DirControl, dist float32) (changed bool) {
-	if dirControl.Up {
-		// pos[2] -= dist
-		*pos = pos.Add(front.Mul(dist))
-		changed = true
-	}
-	if dirControl.Down {
-		// pos[2] += dist
-		*pos = pos.Sub(front.Mul(dist))
-		changed = true
+func recalcProjectionMatrix(s *State) {
+	s.Projection = mgl.Perspective(s.FOV, float32(s.Width)/float32(s.Height), 0.01, 20.0)
+}
+
+// nextModelClip starts every Model on the animation after its current one in modelClips.
+func nextModelClip(w *ecs.World) {
+	for _, e := range w.Query(ecs.ComponentModel) {
+		m := w.Models[e]
+		next := 0
+		for i, clip := range modelClips {
+			if clip == m.Clip {
+				next = (i + 1) % len(modelClips)
+			}
+		}
+		m.Clip = modelClips[next]
+		m.Time = 0
 	}
-	if dirControl.Right {
-		// pos[0] += dist
-		*pos = pos.Sub(left.Mul(dist))
-		changed = true
+}
+
+// requestAsset notes the asset as loading and returns the side effect that will load it.
+func requestAsset(s *State, kind sideeffect.AssetKind, path string, size int) sideeffect.Event {
+	s.Assets[path] = false
+	return &sideeffect.LoadAsset{Kind: kind, Path: path, Size: size}
+}
+
+// toggleOrbit switches the camera between Orbit and FPS. Orbiting frees the
+// cursor for dragging.
+func toggleOrbit(s *State) sideeffect.Event {
+	if s.Camera.Mode != camera.Orbit {
+		s.Camera.SetMode(camera.Orbit)
+		s.Mouse.GameMode = false
+		return &sideeffect.MouseMode_UI{}
 	}
-	if dirControl.Left {
-		*pos = pos.Add(left.Mul(dist))
-		// pos[0] -= dist
-		changed = true
+	return backToFPS(s)
+}
+
+// toggleFree switches the camera between Free flight and FPS.
+func toggleFree(s *State) sideeffect.Event {
+	if s.Camera.Mode != camera.Free {
+		s.Camera.SetMode(camera.Free)
+		s.Mouse.GameMode = true
+		return &sideeffect.MouseMode_Game{}
 	}
-	return changed
+	return backToFPS(s)
 }
 
-func recalcProjectionMatrix(s *State) {
-	s.Projection = mgl.Perspective(Pi_4, float32(s.Width)/float32(s.Height), 0.01, 20.0)
+// backToFPS puts the player where the camera is and captures the mouse for looking.
+func backToFPS(s *State) sideeffect.Event {
+	s.Camera.SetMode(camera.FPS)
+	s.Player.PlaceAt(s.Camera.Position)
+	s.Mouse.GameMode = true
+	return &sideeffect.MouseMode_Game{}
 }
 
-func resetFonts(s *State) {
-	var err error
-	w := s.Width
-	h := s.Height
-	// fmt.Printf("!!!! Resetting font %q based on screen dim [%d, %d]\n", fontFile, w, h)
+// updateFreeCamera flies the Free camera for one Tick: the movement keys go
+// along its own axes, Rise and Sink along its up, and the roll keys roll it.
+// It doesn't collide with anything.
+func updateFreeCamera(s *State, dt float32) {
+	c := &s.Camera
+	roll := 0.0
+	if s.CameraRollControl.Left {
+		roll -= freeRollSpeed
+	}
+	if s.CameraRollControl.Right {
+		roll += freeRollSpeed
+	}
+	if roll != 0 {
+		c.Turn(0, 0, roll*float64(dt))
+	}
 
-	s.Font, err = glfont.LoadFont2(s.FontFile, int32(s.FontSize), w, h, nil)
-	if err != nil {
-		fmt.Printf("!! ERROR game.resetFonts(%q) err=%s\n", s.FontFile, err)
-		s.Font = nil
+	wish := wishDirection(&s.CameraMoveControl, c.DirFront, c.DirLeft)
+	if s.Player.Rise {
+		wish = wish.Add(c.DirUp)
+	}
+	if s.Player.Sink {
+		wish = wish.Sub(c.DirUp)
+	}
+	if wish.Len() > 0 {
+		c.Position = c.Position.Add(wish.Normalize().Mul(cameraMoveSpeed * dt))
+		c.Update()
 	}
 }
+
+// dragCamera orbits the camera while the left button is held, and pans it
+// while the middle one is.
+func dragCamera(s *State, a *MouseMoveAction) {
+	if s.Mouse.Buttons[glfw.MouseButtonLeft] == glfw.Press {
+		s.Camera.Turn(float64(-a.PixDx*orbitSensitivity), float64(a.PixDy*orbitSensitivity), 0)
+	}
+	if s.Mouse.Buttons[glfw.MouseButtonMiddle] == glfw.Press {
+		scale := float32(s.Camera.Distance) * panSensitivity
+		s.Camera.Pan(-a.PixDx*scale, a.PixDy*scale)
+	}
+}
+
+// applyPhysics moves the RigidBodies to where the physics step left them.
+func applyPhysics(w *ecs.World, p *PhysicsAction) {
+	for _, bm := range p.Bodies {
+		if t, ok := w.Transforms[bm.Entity]; ok {
+			t.Location = bm.Location
+			t.LocalRotation = bm.Rotation
+		}
+		if rb, ok := w.Bodies[bm.Entity]; ok {
+			rb.Velocity = bm.Velocity
+			rb.AngularVelocity = bm.AngularVelocity
+		}
+	}
+}
+
+// savePrevious remembers transforms from before this Tick for render interpolation
+func savePrevious(s *State) {
+	s.PrevCameraPos = s.Camera.Position
+	ecs.SavePrevious(s.World)
+}
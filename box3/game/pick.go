@@ -0,0 +1,70 @@
+package game
+
+import (
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	"github.com/dcrosby42/go-game-sandbox/geom"
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+var v3s = helpers.Vec3String
+
+// Pick is what a ray cast into the World struck first.
+type Pick struct {
+	Entity ecs.Entity
+	geom.Hit
+}
+
+// cursorRay is the ray into the scene under the mouse. In game mode the
+// cursor is captured, so it's straight out of the middle of the screen.
+func cursorRay(s *State) geom.Ray {
+	x, y := s.Mouse.NormX, s.Mouse.NormY
+	if s.Mouse.GameMode {
+		x, y = 0, 0
+	}
+	return geom.Unproject(x, y, s.Projection, s.Camera.Matrix)
+}
+
+// PickEntity finds the nearest Entity with a Mesh that the ray hits, testing
+// against the Mesh's actual shape as placed by its world transform.
+func PickEntity(w *ecs.World, ray geom.Ray) (Pick, bool) {
+	var best Pick
+	found := false
+	for _, e := range w.Query(ecs.ComponentMesh) {
+		model := w.WorldMatrix(e)
+		if model.Det() == 0 {
+			continue // scaled flat, can't be hit
+		}
+		inv := model.Inv()
+		hit, ok := intersectMesh(w.Meshes[e], ray.Transform(inv))
+		if !ok || (found && hit.Distance >= best.Distance) {
+			continue
+		}
+		// back out to world space; normals go by the inverse transpose
+		hit.Point = ray.At(hit.Distance)
+		hit.Normal = inv.Transpose().Mul4x1(hit.Normal.Vec4(0)).Vec3().Normalize()
+		best = Pick{Entity: e, Hit: hit}
+		found = true
+	}
+	return best, found
+}
+
+// intersectMesh tests a ray, in the Mesh's model space, against it.
+func intersectMesh(mesh *ecs.Mesh, ray geom.Ray) (geom.Hit, bool) {
+	switch mesh.Kind {
+	case ecs.MeshSphere:
+		return ray.IntersectSphere(mgl.Vec3{}, mesh.Radius)
+	case ecs.MeshPlaneXZ:
+		y := mesh.Min[1]
+		a := mgl.Vec3{mesh.Min[0], y, mesh.Min[2]}
+		b := mgl.Vec3{mesh.Max[0], y, mesh.Min[2]}
+		c := mgl.Vec3{mesh.Max[0], y, mesh.Max[2]}
+		d := mgl.Vec3{mesh.Min[0], y, mesh.Max[2]}
+		if hit, ok := ray.IntersectTriangle(a, b, c); ok {
+			return hit, true
+		}
+		return ray.IntersectTriangle(a, c, d)
+	default:
+		return ray.IntersectAABB(geom.AABB{Min: mesh.Min, Max: mesh.Max})
+	}
+}
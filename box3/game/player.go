@@ -0,0 +1,225 @@
+package game
+
+import (
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	"github.com/dcrosby42/go-game-sandbox/geom"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	playerHalfWidth = 0.3
+	playerHeight    = 1.8
+	playerEyeHeight = 1.6
+	playerStepUp    = 0.55 // the highest ledge the player walks up without jumping
+	playerGravity   = 20
+	playerJumpSpeed = 7
+	playerMaxFall   = 50
+	groundProbe     = 0.05
+	worldExtent     = 1e6
+)
+
+// Player is the box the FPS camera rides around in. It collides with the
+// World's Colliders, and falls and jumps unless Flying.
+type Player struct {
+	// Feet is the bottom center of the player's box
+	Feet     mgl.Vec3
+	Velocity mgl.Vec3
+	OnGround bool
+	Flying   bool
+
+	// Rise and Sink are held down: Rise jumps when walking and climbs when
+	// flying, Sink descends when flying
+	Rise, Sink bool
+}
+
+// PlaceAt puts the player's eyes at the given position, at rest.
+func (me *Player) PlaceAt(eye mgl.Vec3) {
+	me.Feet = eye.Sub(mgl.Vec3{0, playerEyeHeight, 0})
+	me.Velocity = mgl.Vec3{}
+	me.OnGround = false
+}
+
+func (me *Player) Eye() mgl.Vec3 {
+	return me.Feet.Add(mgl.Vec3{0, playerEyeHeight, 0})
+}
+
+func (me *Player) Box() geom.AABB {
+	return geom.AABB{
+		Min: me.Feet.Sub(mgl.Vec3{playerHalfWidth, 0, playerHalfWidth}),
+		Max: me.Feet.Add(mgl.Vec3{playerHalfWidth, playerHeight, playerHalfWidth}),
+	}
+}
+
+// updatePlayer moves the player for one Tick according to the movement keys
+// and keeps the camera at its eyes.
+func updatePlayer(s *State, dt float32) {
+	p := &s.Player
+	speed := float32(cameraMoveSpeed)
+
+	if p.Flying {
+		wish := wishDirection(&s.CameraMoveControl, s.Camera.DirFront, s.Camera.DirLeft)
+		p.Velocity = wish.Mul(speed)
+		if p.Rise {
+			p.Velocity[1] += speed
+		}
+		if p.Sink {
+			p.Velocity[1] -= speed
+		}
+	} else {
+		// walk along the ground whichever way the camera is pitched
+		front := flatten(s.Camera.DirFront)
+		left := flatten(s.Camera.DirLeft)
+		wish := wishDirection(&s.CameraMoveControl, front, left)
+		p.Velocity[0] = wish[0] * speed
+		p.Velocity[2] = wish[2] * speed
+		if p.OnGround && p.Rise {
+			p.Velocity[1] = playerJumpSpeed
+		}
+		p.Velocity[1] -= playerGravity * dt
+		if p.Velocity[1] < -playerMaxFall {
+			p.Velocity[1] = -playerMaxFall
+		}
+	}
+
+	solids := solidBoxes(s.World)
+	box := p.Box()
+	delta := p.Velocity.Mul(dt)
+
+	// vertical first, so a step is taken from wherever we land
+	dy := box.Sweep(1, delta[1], solids)
+	box = box.Translate(mgl.Vec3{0, dy, 0})
+	if dy != delta[1] {
+		p.Velocity[1] = 0
+	}
+
+	for _, axis := range []int{0, 2} {
+		d := box.Sweep(axis, delta[axis], solids)
+		if d != delta[axis] && p.OnGround && !p.Flying {
+			if stepped, ok := stepUp(box, axis, delta[axis], d, solids); ok {
+				box = stepped
+				continue
+			}
+		}
+		var move mgl.Vec3
+		move[axis] = d
+		box = box.Translate(move)
+	}
+
+	p.Feet = mgl.Vec3{(box.Min[0] + box.Max[0]) / 2, box.Min[1], (box.Min[2] + box.Max[2]) / 2}
+	p.OnGround = p.Velocity[1] <= 0 && box.Sweep(1, -groundProbe, solids) > -groundProbe
+	if p.OnGround && !p.Flying {
+		p.Velocity[1] = 0
+	}
+
+	if eye := p.Eye(); eye != s.Camera.Position {
+		s.Camera.Position = eye
+		s.Camera.Update()
+	}
+}
+
+// stepUp tries moving the box along axis by want after lifting it by up to
+// playerStepUp, then lowers it back down onto whatever it's stepped onto. It
+// only succeeds if that gets further than the blocked move did.
+func stepUp(box geom.AABB, axis int, want, blocked float32, solids []geom.AABB) (geom.AABB, bool) {
+	lift := box.Sweep(1, playerStepUp, solids)
+	raised := box.Translate(mgl.Vec3{0, lift, 0})
+	d := raised.Sweep(axis, want, solids)
+	if abs32(d) <= abs32(blocked) {
+		return box, false
+	}
+	var move mgl.Vec3
+	move[axis] = d
+	moved := raised.Translate(move)
+	drop := moved.Sweep(1, -lift, solids)
+	return moved.Translate(mgl.Vec3{0, drop, 0}), true
+}
+
+// wishDirection is the unit direction the movement keys ask for, or zero.
+func wishDirection(dirControl *DirControl, front, left mgl.Vec3) mgl.Vec3 {
+	var wish mgl.Vec3
+	if dirControl.Up {
+		wish = wish.Add(front)
+	}
+	if dirControl.Down {
+		wish = wish.Sub(front)
+	}
+	if dirControl.Left {
+		wish = wish.Add(left)
+	}
+	if dirControl.Right {
+		wish = wish.Sub(left)
+	}
+	if wish.Len() == 0 {
+		return wish
+	}
+	return wish.Normalize()
+}
+
+// flatten projects v onto the XZ plane
+func flatten(v mgl.Vec3) mgl.Vec3 {
+	v[1] = 0
+	if v.Len() == 0 {
+		return v
+	}
+	return v.Normalize()
+}
+
+// solidBoxes bounds every Collider in the World, placed as the physics places
+// them. Boxes are bounded as rotated; only upward facing planes are solid, as a
+// slab below them.
+func solidBoxes(w *ecs.World) []geom.AABB {
+	var boxes []geom.AABB
+	for _, e := range w.Query(ecs.ComponentCollider) {
+		c := w.Colliders[e]
+		switch c.Kind {
+		case ecs.ColliderBox:
+			boxes = append(boxes, geom.BoxAround(mgl.Vec3{}, c.HalfSize).Transform(w.WorldMatrix(e)))
+		case ecs.ColliderSphere:
+			location, _, scale := w.WorldPose(e)
+			r := c.Scaled(scale).Radius
+			boxes = append(boxes, geom.BoxAround(location, mgl.Vec3{r, r, r}))
+		case ecs.ColliderPlane:
+			n, offset := w.WorldPlane(e)
+			if n[1] < 0.99 {
+				continue
+			}
+			top := offset / n[1]
+			boxes = append(boxes, geom.AABB{
+				Min: mgl.Vec3{-worldExtent, -worldExtent, -worldExtent},
+				Max: mgl.Vec3{worldExtent, top, worldExtent},
+			})
+		}
+	}
+	return boxes
+}
+
+// updatePlayerKeys tracks the jump/fly keys; F toggles flying.
+func updatePlayerKeys(p *Player, ka *KeyboardAction) {
+	switch ka.Action {
+	case glfw.Press:
+		switch ka.Key {
+		case glfw.KeySpace:
+			p.Rise = true
+		case glfw.KeyLeftShift:
+			p.Sink = true
+		case glfw.KeyF:
+			p.Flying = !p.Flying
+			p.Velocity = mgl.Vec3{}
+		}
+	case glfw.Release:
+		switch ka.Key {
+		case glfw.KeySpace:
+			p.Rise = false
+		case glfw.KeyLeftShift:
+			p.Sink = false
+		}
+	}
+}
+
+func abs32(a float32) float32 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
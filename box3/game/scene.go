@@ -0,0 +1,102 @@
+package game
+
+import (
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// The starting scene, spawned into the World by Init.
+
+const crateMaterial = "materials/crate.json"
+
+// modelClips are the animations in sceneModel's file, in the order M cycles through them
+var modelClips = []string{"bend", "sway"}
+
+var (
+	crateMesh          = ecs.CubeMesh(-0.5, -0.5, -0.5, 0.5, 0.5, 0.5)
+	smallCrateMesh     = ecs.CubeMesh(-0.25, -0.25, -0.25, 0.25, 0.25, 0.25)
+	crateCollider      = ecs.MeshCollider(crateMesh)
+	smallCrateCollider = ecs.MeshCollider(smallCrateMesh)
+	ballMesh           = ecs.SphereMesh(0.4, 16, 16)
+	ballCollider       = ecs.MeshCollider(ballMesh)
+	floorMesh          = ecs.Mesh{Kind: ecs.MeshCube, Min: crateMesh.Min, Max: crateMesh.Max, Instanced: true}
+)
+
+// sceneCrates spin in mid air. The small one carries a label around with it.
+var sceneCrates = []ecs.Spec{
+	{
+		Transform: &ecs.Transform{Location: mgl.Vec3{0, 0, 0}},
+		Mesh:      &crateMesh,
+		Material:  &ecs.Material{Path: crateMaterial, Color: mgl.Vec4{1.0, 1.0, 1.0, 1.0}},
+		Spinner:   &ecs.Spinner{Axis: mgl.Vec3{1, 0, 0}, Speed: Pi_2},
+		Collider:  &crateCollider,
+	},
+	{
+		Transform: &ecs.Transform{Location: mgl.Vec3{-2, -2, 0}},
+		Mesh:      &crateMesh,
+		Material:  &ecs.Material{Path: crateMaterial, Color: mgl.Vec4{1.0, 1.0, 1.0, 1.0}},
+		Spinner:   &ecs.Spinner{Axis: mgl.Vec3{0, 1, 0}, Speed: Pi_2},
+		Collider:  &crateCollider,
+	},
+	{
+		Transform: &ecs.Transform{Location: mgl.Vec3{2, 2, 0}},
+		Mesh:      &smallCrateMesh,
+		Material:  &ecs.Material{Path: crateMaterial, Color: mgl.Vec4{0.25, 1.0, 0.25, 1.0}},
+		Spinner:   &ecs.Spinner{Axis: mgl.Vec3{0, 0, 1}, Speed: Pi_2},
+		Collider:  &smallCrateCollider,
+		Children: []ecs.Spec{
+			{
+				Transform: &ecs.Transform{
+					Location: mgl.Vec3{0.28, -0.05, 0},
+					Scale:    mgl.Vec3{0.5, 0.5, 0.5},
+				},
+				Label: &ecs.Label{Text: "Hello World"},
+			},
+		},
+	},
+}
+
+// sceneFloor is a 15x15 grid of crates below the others, drawn as one batch.
+var sceneFloor = ecs.Grid{
+	Columns: 15,
+	Rows:    15,
+	Spacing: 1,
+	Origin:  mgl.Vec3{-7.5, -3, -7.5},
+	Prefab: ecs.Spec{
+		Transform: &ecs.Transform{},
+		Mesh:      &floorMesh,
+		Material:  &ecs.Material{Path: crateMaterial, Color: mgl.Vec4{1.0, 0.75, 0.6, 1.0}},
+		Collider:  &crateCollider,
+	},
+}
+
+// sceneBodies are dropped onto the floor by the physics.
+var sceneBodies = []ecs.Spec{
+	{
+		Transform: &ecs.Transform{
+			Location:      mgl.Vec3{-1, 3, 2},
+			LocalRotation: mgl.QuatRotate(Pi_6, mgl.Vec3{1, 0, 1}.Normalize()),
+		},
+		Mesh:      &crateMesh,
+		Material:  &ecs.Material{Path: crateMaterial, Color: mgl.Vec4{0.6, 0.8, 1.0, 1.0}},
+		Collider:  &crateCollider,
+		RigidBody: &ecs.RigidBody{Mass: 2, LinearDamping: 0.95, AngularDamping: 0.8},
+	},
+	{
+		Transform: &ecs.Transform{Location: mgl.Vec3{1, 4, 2}},
+		Mesh:      &ballMesh,
+		Material:  &ecs.Material{Path: crateMaterial, Color: mgl.Vec4{1.0, 0.5, 0.5, 1.0}},
+		Collider:  &ballCollider,
+		RigidBody: &ecs.RigidBody{Mass: 1, LinearDamping: 0.95, AngularDamping: 0.8},
+	},
+	{
+		// keeps anything from falling forever once it rolls off the floor
+		Collider: &ecs.Collider{Kind: ecs.ColliderPlane, Normal: mgl.Vec3{0, 1, 0}, Offset: -10},
+	},
+}
+
+// sceneModel is a skinned glTF model standing on the floor, bending back and forth.
+var sceneModel = ecs.Spec{
+	Transform: &ecs.Transform{Location: mgl.Vec3{3.5, -2.5, -2}},
+	Model:     &ecs.Model{Path: "models/bendy.gltf", Shader: "diffuse_texture", Clip: modelClips[0]},
+}
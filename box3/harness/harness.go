@@ -2,25 +2,50 @@ package harness
 
 import (
 	"fmt"
-	"reflect"
 	"time"
 
+	"github.com/dcrosby42/go-game-sandbox/box3/assets"
 	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/record"
 	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
+	"github.com/dcrosby42/go-game-sandbox/box3/physics"
+	"github.com/dcrosby42/go-game-sandbox/box3/renderer"
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+	"github.com/dcrosby42/go-game-sandbox/runloop"
 	"github.com/dcrosby42/go-game-sandbox/window"
+	"github.com/faiface/mainthread"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	_ "github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
+// simStep is the fixed simulation time step; each one becomes a game.Tick
+const simStep = 1.0 / 60
+
 type Harness struct {
 	fps                 int
 	winWidth, winHeight int
 	fbWidth, fbHeight   int
 	win                 *glfw.Window
 	state               *game.State
-	lastGameTime        float64
+	renderer            *renderer.Renderer
+	assets              *assets.Manager
+	physics             *physics.World
+	loop                *runloop.FixedStep
 	cursor              CursorState
+	recorder            *record.Recorder
+	replay              []record.Entry
+	windowed            windowPlacement
+	screenshotPath      string
+	reportingError      bool
+
+	// title is the window title the game asked for; the renderer's Stats
+	// are shown after it, and shownStats is what's there now
+	title      string
+	shownStats helpers.DrawStats
+
+	// Sound plays PlaySound side effects. Without one, PlaySound reports an error to the game.
+	Sound SoundPlayer
 
 	DebugInput       bool
 	DebugSideEffects bool
@@ -29,9 +54,10 @@ type Harness struct {
 func New() (*Harness, error) {
 	winWidth := 500
 	winHeight := 500
+	title := "Box"
 
 	win, err := window.New(window.Options{
-		Title:     "Box",
+		Title:     title,
 		Width:     winWidth,
 		Height:    winHeight,
 		Resizable: true,
@@ -43,14 +69,16 @@ func New() (*Harness, error) {
 	fbWidth, fbHeight := win.GetFramebufferSize()
 
 	har := &Harness{
-		fps:          120,
-		winWidth:     winWidth,
-		winHeight:    winHeight,
-		fbWidth:      fbWidth,
-		fbHeight:     fbHeight,
-		win:          win,
-		state:        nil,
-		lastGameTime: 0,
+		fps:       120,
+		winWidth:  winWidth,
+		winHeight: winHeight,
+		fbWidth:   fbWidth,
+		fbHeight:  fbHeight,
+		win:       win,
+		title:     title,
+		state:     nil,
+		loop:      runloop.NewFixedStep(simStep),
+		physics:   physics.NewWorld(),
 	}
 
 	// har.DebugInput = true
@@ -66,95 +94,202 @@ func New() (*Harness, error) {
 	win.SetFramebufferSizeCallback(har.FramebufferSizeCallback)
 
 	state := &game.State{Width: winWidth, Height: winHeight}
-	state, sideEffect := game.Init(state)
+	state, sideEffects := game.Init(state)
 	har.state = state
 
-	err = har.HandleSideEffect(sideEffect)
+	har.assets = assets.NewManager(4)
+	har.assets.ScreenWidth = winWidth
+	har.assets.ScreenHeight = winHeight
+	har.assets.Watch(500 * time.Millisecond)
+	har.renderer = renderer.New(har.assets)
+	err = har.renderer.Init(state)
 	if err != nil {
 		return nil, err
 	}
+	err = har.renderer.Resize(fbWidth, fbHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range sideEffects {
+		err = har.HandleSideEffect(e)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// har.MouseModeGame()
 
 	return har, nil
 }
 
+// Play runs the game until the window closes. Must be called from within
+// mainthread.Run; each frame runs on the main thread, and asset uploads queued
+// by the loader's workers run in between frames.
 func (me *Harness) Play() {
-	action := game.Action{
-		Type: game.Tick,
-		Tick: &game.TickAction{},
+	mainthread.Call(func() {
+		gl.Enable(gl.DEPTH_TEST)
+		gl.DepthFunc(gl.LESS)
+		gl.ClearColor(0.0, 0.0, 0.0, 0.0)
+	})
+
+	done := false
+	for !done {
+		t := time.Now()
+
+		mainthread.Call(func() {
+			me.frame()
+			done = me.win.ShouldClose()
+		})
+
+		// WAIT
+		time.Sleep(time.Second/time.Duration(me.fps) - time.Since(t))
 	}
 
-	gl.Enable(gl.DEPTH_TEST)
-	gl.DepthFunc(gl.LESS)
-	gl.ClearColor(0.0, 0.0, 0.0, 0.0)
+	me.StopRecording()
+}
 
-	for !me.win.ShouldClose() {
-		t := time.Now()
-		gameTime := glfw.GetTime()
+func (me *Harness) frame() {
+	glfw.PollEvents()
 
-		glfw.PollEvents()
+	me.deliverAssets()
 
-		dt := gameTime - me.lastGameTime
-		if dt > 0.2 {
-			dt = 0.2
+	// UPDATE
+	alpha, _ := me.loop.Advance(func(gameTime, dt float64) error {
+		if me.replay != nil {
+			me.replayFrame()
+		} else {
+			me.ApplyUpdate(&game.Action{
+				Type: game.Tick,
+				Tick: &game.TickAction{Gt: gameTime, Dt: dt},
+			})
 		}
-		me.lastGameTime = gameTime
+		me.stepPhysics(dt)
+		return nil
+	})
 
-		// UPDATE
-		action.Tick.Gt = gameTime
-		action.Tick.Dt = dt
-		me.ApplyUpdate(&action)
+	// DRAW
+	me.renderer.Draw(me.state, alpha)
+	if me.renderer.Stats != me.shownStats {
+		me.shownStats = me.renderer.Stats
+		me.showTitle()
+	}
 
-		// DRAW
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	if me.screenshotPath != "" {
+		me.saveScreenshot()
+	}
 
-		game.Draw(me.state)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	me.renderer.Present(me.fbWidth, me.fbHeight)
+	me.win.SwapBuffers()
+}
 
-		me.win.SwapBuffers()
+// showTitle sets the window title to the game's, followed by how many
+// meshes were drawn and culled in the last frame.
+func (me *Harness) showTitle() {
+	me.win.SetTitle(fmt.Sprintf("%s - %d drawn, %d culled", me.title, me.shownStats.Drawn, me.shownStats.Culled))
+}
 
-		// WAIT
-		time.Sleep(time.Second/time.Duration(me.fps) - time.Since(t))
+// stepPhysics moves the physics along by one Tick and hands the outcome to
+// the game. While replaying, the recorded PhysicsStep actions are used instead.
+func (me *Harness) stepPhysics(dt float64) {
+	if me.replay != nil {
+		return
 	}
+	me.ApplyUpdate(me.physics.Step(me.state.World, dt))
 }
 
-func (me *Harness) ApplyUpdate(action *game.Action) {
-	var e sideeffect.Event
-	me.state, e = game.Update(me.state, action)
-	err := me.HandleSideEffect(e)
-	if err != nil {
-		panic(fmt.Sprintf("FAILED ApplyUpdate() err=%s", err)) // TODO c'mon man, we can do better than panic
+// deliverAssets tells the game about assets that have finished loading.
+func (me *Harness) deliverAssets() {
+	for _, res := range me.assets.Completed() {
+		action := &game.Action{
+			Type: game.AssetLoaded,
+			Asset: &game.AssetAction{
+				Kind:   res.Kind,
+				Path:   res.Path,
+				Size:   res.Size,
+				Reload: res.Reload,
+			},
+		}
+		if res.Err != nil {
+			action.Type = game.AssetFailed
+			action.Asset.Error = res.Err.Error()
+		} else {
+			me.renderer.AssetsChanged()
+		}
+		me.ApplyUpdate(action)
 	}
 }
 
-func (me *Harness) HandleSideEffect(e sideeffect.Event) error {
-	if e == nil {
-		return nil
-	}
-	if me.DebugSideEffects {
-		fmt.Printf("Harness.HandleSideEffect(): %v\n", reflect.TypeOf(e))
+// StartRecording writes every action subsequently applied to the game into
+// the file at path, see package record.
+func (me *Harness) StartRecording(path string) error {
+	me.StopRecording()
+	rec, err := record.Create(path)
+	if err != nil {
+		return err
 	}
-	switch event := e.(type) {
-	case *sideeffect.Error:
-		return event.Error
-	case *sideeffect.MouseMode_Game:
-		me.MouseModeGame()
-	case *sideeffect.MouseMode_UI:
-		me.MouseModeUI()
-	default:
+	me.recorder = rec
+	return nil
+}
 
+func (me *Harness) StopRecording() {
+	if me.recorder == nil {
+		return
+	}
+	err := me.recorder.Close()
+	if err != nil {
+		fmt.Printf("!! ERROR Harness.StopRecording() err=%s\n", err)
 	}
+	me.recorder = nil
+}
 
-	return nil
+// StartReplay feeds the recorded entries to the game instead of live input,
+// one recorded Tick per simulation step. Live input resumes once the entries run out.
+func (me *Harness) StartReplay(entries []record.Entry) {
+	me.replay = entries
+}
 
+// replayFrame applies recorded entries up to and including the next Tick and
+// the physics step that followed it, standing in for one simulation step.
+func (me *Harness) replayFrame() {
+	for len(me.replay) > 0 {
+		entry := me.replay[0]
+		me.replay = me.replay[1:]
+		me.update(&entry.Action)
+		if entry.Action.Type == game.PhysicsStep {
+			break
+		}
+		if entry.Action.Type == game.Tick && (len(me.replay) == 0 || me.replay[0].Action.Type != game.PhysicsStep) {
+			break
+		}
+	}
+	if len(me.replay) == 0 {
+		fmt.Printf("Harness: replay finished\n")
+		me.replay = nil
+	}
 }
 
-func (me *Harness) MouseModeGame() {
-	me.win.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+// ApplyUpdate feeds a live action to the game. Live actions are dropped while a replay is running.
+func (me *Harness) ApplyUpdate(action *game.Action) {
+	if me.replay != nil {
+		return
+	}
+	me.update(action)
 }
 
-func (me *Harness) MouseModeUI() {
-	me.win.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+func (me *Harness) update(action *game.Action) {
+	if me.recorder != nil {
+		err := me.recorder.Record(glfw.GetTime(), action)
+		if err != nil {
+			fmt.Printf("!! ERROR Harness recording failed, stopping. err=%s\n", err)
+			me.StopRecording()
+		}
+	}
+
+	var sideEffects []sideeffect.Event
+	me.state, sideEffects = game.Update(me.state, action)
+	me.handleSideEffects(sideEffects)
 }
 
 func (me *Harness) ScrollCallback(w *glfw.Window, xoff, yoff float64) {
@@ -202,31 +337,15 @@ func (me *Harness) CursorPosCallback(w *glfw.Window, x float64, y float64) {
 	me.cursor.x = xpos
 	me.cursor.y = ypos
 
-	w2 := float32(me.winWidth) / 2
-	h2 := float32(me.winHeight) / 2
-	nx := (xpos - w2) / w2
-	ny := -(ypos - h2) / h2
-	inbounds := nx >= -1.0 && nx <= 1.0 && ny <= 1.0 && ny >= -1.0
-	ndx := float32(dx) / w2
-	ndy := float32(dy) / h2
+	mm := game.NewMouseMoveAction(xpos, ypos, dx, dy, me.winWidth, me.winHeight)
 	action := game.Action{
-		Type: game.MouseMove,
-		MouseMove: &game.MouseMoveAction{
-			PixX:     xpos,
-			PixY:     ypos,
-			PixDx:    dx,
-			PixDy:    dy,
-			X:        nx, // mgl.Clamp(nx, -1, 1),
-			Y:        ny, //mgl.Clamp(ny, -1, 1),
-			Dx:       ndx,
-			Dy:       ndy,
-			InBounds: inbounds,
-		},
+		Type:      game.MouseMove,
+		MouseMove: mm,
 	}
 
 	me.ApplyUpdate(&action)
 	if me.DebugInput {
-		fmt.Printf("Harness.CursorPosCallback(): Pix(%.2f, %.2f) PixD(%.2f, %.2f) Norm(%.4f, %.4f) NormD(%.4f, %.4f) InBounds=%v\n", xpos, ypos, dx, dy, nx, ny, ndx, ndy, inbounds)
+		fmt.Printf("Harness.CursorPosCallback(): Pix(%.2f, %.2f) PixD(%.2f, %.2f) Norm(%.4f, %.4f) NormD(%.4f, %.4f) InBounds=%v\n", xpos, ypos, dx, dy, mm.X, mm.Y, mm.Dx, mm.Dy, mm.InBounds)
 	}
 }
 
@@ -303,6 +422,11 @@ func (me *Harness) FramebufferSizeCallback(w *glfw.Window, fbWidth, fbHeight int
 	me.fbWidth = fbWidth
 	me.fbHeight = fbHeight
 	me.winWidth, me.winHeight = me.win.GetSize()
+	err := me.renderer.Resize(fbWidth, fbHeight)
+	if err != nil {
+		// eg minimized to 0x0; keep drawing at the old size
+		fmt.Printf("!! ERROR Harness.FramebufferSizeCallback() err=%s\n", err)
+	}
 	waction := game.Action{
 		Type: game.WindowSize,
 		WindowSize: &game.WindowSizeAction{
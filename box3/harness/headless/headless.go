@@ -0,0 +1,270 @@
+// Package headless drives game.Update without a window or GL context, so the
+// box3 game logic can be exercised from tests and CI machines with no display.
+package headless
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/record"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
+	"github.com/dcrosby42/go-game-sandbox/box3/physics"
+	"github.com/dcrosby42/go-game-sandbox/runloop"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// FakeClock stands in for glfw.GetTime; it only moves when told to.
+type FakeClock struct {
+	T float64
+}
+
+func (me *FakeClock) Now() float64 {
+	return me.T
+}
+
+func (me *FakeClock) Advance(dt float64) {
+	me.T += dt
+}
+
+type Harness struct {
+	State       *game.State
+	Clock       *FakeClock
+	SideEffects []sideeffect.Event
+	Loop        *runloop.FixedStep
+	Physics     *physics.World
+	cursor      cursorState
+
+	// Recorder, when set, receives every action applied to the game. If
+	// recording fails, RecordErr is set and the Recorder dropped.
+	Recorder  *record.Recorder
+	RecordErr error
+
+	// MouseGameMode reflects the last MouseMode_* side effect, ie, what the
+	// real harness would have done to the window's cursor.
+	MouseGameMode bool
+
+	// Quit, Title, Fullscreen and Vsync reflect the window-related side effects seen so far
+	Quit       bool
+	Title      string
+	Fullscreen bool
+	Vsync      bool
+
+	// Files stands in for the disk: WriteFile side effects land here and
+	// ReadFile ones are answered from it
+	Files map[string][]byte
+
+	DebugSideEffects bool
+}
+
+type cursorState struct {
+	x, y     float32
+	tracking bool
+}
+
+// New builds a Harness around a State set up by game.Init.
+func New(width, height int) *Harness {
+	har := &Harness{
+		Clock:   &FakeClock{},
+		Physics: physics.NewWorld(),
+	}
+	har.Loop = runloop.NewFixedStep(1.0 / 60)
+	har.Loop.Clock = har.Clock.Now
+	har.Loop.Start()
+	var effects []sideeffect.Event
+	har.State, effects = game.Init(&game.State{Width: width, Height: height})
+	har.handleSideEffects(effects)
+	return har
+}
+
+// Apply runs one action through game.Update and handles the resulting side effects.
+func (me *Harness) Apply(action *game.Action) {
+	if me.Recorder != nil {
+		err := me.Recorder.Record(me.Clock.Now(), action)
+		if err != nil {
+			me.RecordErr = err
+			me.Recorder = nil
+		}
+	}
+	var effects []sideeffect.Event
+	me.State, effects = game.Update(me.State, action)
+	me.handleSideEffects(effects)
+}
+
+// tick applies a Tick action then steps the physics, the way harness.frame does.
+func (me *Harness) tick(gameTime, dt float64) {
+	me.Apply(&game.Action{
+		Type: game.Tick,
+		Tick: &game.TickAction{Gt: gameTime, Dt: dt},
+	})
+	me.Apply(me.Physics.Step(me.State.World, dt))
+}
+
+// Run applies each action of the script in order.
+// Tick actions advance the fake clock by their Dt; their Gt is filled in from the clock.
+func (me *Harness) Run(script []game.Action) {
+	for i := range script {
+		action := script[i]
+		if action.Type == game.Tick {
+			dt := 0.0
+			if action.Tick != nil {
+				dt = action.Tick.Dt
+			}
+			me.Tick(dt)
+			continue
+		}
+		me.Apply(&action)
+	}
+}
+
+// Replay applies recorded entries verbatim (Tick Gt/Dt included), setting the
+// fake clock to each entry's timestamp as it goes. The physics isn't stepped:
+// the recorded PhysicsStep actions move the bodies instead.
+func (me *Harness) Replay(entries []record.Entry) {
+	for i := range entries {
+		me.Clock.T = entries[i].T
+		me.Apply(&entries[i].Action)
+	}
+}
+
+// Tick advances the fake clock by dt and applies a single Tick action of that
+// dt, followed by a physics step.
+func (me *Harness) Tick(dt float64) {
+	me.Clock.Advance(dt)
+	me.tick(me.Clock.Now(), dt)
+}
+
+// Frame advances the fake clock by elapsed and lets Loop apply however many
+// fixed-step Ticks that covers, the way harness.Play does once per frame.
+// Returns the interpolation alpha that would be handed to the renderer.
+func (me *Harness) Frame(elapsed float64) float64 {
+	me.Clock.Advance(elapsed)
+	alpha, _ := me.Loop.Advance(func(gameTime, dt float64) error {
+		me.tick(gameTime, dt)
+		return nil
+	})
+	return alpha
+}
+
+// TickFor applies Ticks of dt until the given duration has elapsed.
+func (me *Harness) TickFor(duration, dt float64) {
+	n := int(math.Round(duration / dt))
+	for i := 0; i < n; i++ {
+		me.Tick(dt)
+	}
+}
+
+func (me *Harness) Key(key glfw.Key, action glfw.Action) {
+	me.Apply(&game.Action{
+		Type: game.Keyboard,
+		Keyboard: &game.KeyboardAction{
+			Key:    key,
+			Action: action,
+		},
+	})
+}
+
+func (me *Harness) KeyPress(key glfw.Key) {
+	me.Key(key, glfw.Press)
+}
+
+func (me *Harness) KeyRelease(key glfw.Key) {
+	me.Key(key, glfw.Release)
+}
+
+// MouseMove moves the cursor to the given pixel position, tracking deltas the
+// same way harness.CursorPosCallback does.
+func (me *Harness) MouseMove(xpos, ypos float32) {
+	dx := xpos - me.cursor.x
+	dy := ypos - me.cursor.y
+	if !me.cursor.tracking {
+		dx = 0
+		dy = 0
+		me.cursor.tracking = true
+	}
+	me.cursor.x = xpos
+	me.cursor.y = ypos
+
+	me.Apply(&game.Action{
+		Type:      game.MouseMove,
+		MouseMove: game.NewMouseMoveAction(xpos, ypos, dx, dy, me.State.Width, me.State.Height),
+	})
+}
+
+func (me *Harness) MouseButton(button glfw.MouseButton, action glfw.Action) {
+	me.Apply(&game.Action{
+		Type: game.MouseButton,
+		MouseButton: &game.MouseButtonAction{
+			Button: button,
+			Action: action,
+		},
+	})
+}
+
+func (me *Harness) WindowSize(width, height int) {
+	me.Apply(&game.Action{
+		Type: game.WindowSize,
+		WindowSize: &game.WindowSizeAction{
+			Width:    width,
+			Height:   height,
+			FbWidth:  width,
+			FbHeight: height,
+		},
+	})
+}
+
+func (me *Harness) handleSideEffects(effects []sideeffect.Event) {
+	for _, e := range effects {
+		me.HandleSideEffect(e)
+	}
+}
+
+// HandleSideEffect records the event and mimics what the windowed harness would do.
+func (me *Harness) HandleSideEffect(e sideeffect.Event) {
+	if e == nil {
+		return
+	}
+	if me.DebugSideEffects {
+		fmt.Printf("headless.HandleSideEffect(): %v\n", reflect.TypeOf(e))
+	}
+	me.SideEffects = append(me.SideEffects, e)
+	switch event := e.(type) {
+	case *sideeffect.MouseMode_Game:
+		me.MouseGameMode = true
+	case *sideeffect.MouseMode_UI:
+		me.MouseGameMode = false
+	case *sideeffect.Quit:
+		me.Quit = true
+	case *sideeffect.SetWindowTitle:
+		me.Title = event.Title
+	case *sideeffect.ToggleFullscreen:
+		me.Fullscreen = !me.Fullscreen
+	case *sideeffect.SetVsync:
+		me.Vsync = event.Enabled
+	case *sideeffect.ResizeWindow:
+		// A real window would report its new size back via the size callback
+		me.WindowSize(event.Width, event.Height)
+	case *sideeffect.WriteFile:
+		if me.Files == nil {
+			me.Files = make(map[string][]byte)
+		}
+		me.Files[event.Path] = append([]byte(nil), event.Data...)
+	case *sideeffect.ReadFile:
+		data, ok := me.Files[event.Path]
+		if !ok {
+			me.Apply(&game.Action{
+				Type:  game.Error,
+				Error: &game.ErrorAction{Message: "no such file: " + event.Path, SideEffect: fmt.Sprintf("%T", e)},
+			})
+			break
+		}
+		me.Apply(&game.Action{Type: game.FileRead, File: &game.FileAction{Path: event.Path, Data: data}})
+	case *sideeffect.LoadAsset:
+		// Nothing is really loaded; pretend it finished straight away
+		me.Apply(&game.Action{
+			Type:  game.AssetLoaded,
+			Asset: &game.AssetAction{Kind: event.Kind, Path: event.Path, Size: event.Size},
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package headless
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/camera"
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/record"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// playerHalfWidth matches the game's
+const playerHalfWidth = 0.3
+
+const dt = 1.0 / 60
+
+func TestInit(t *testing.T) {
+	h := New(640, 480)
+	if !h.MouseGameMode {
+		t.Error("mouse isn't captured after Init")
+	}
+	for path, loaded := range h.State.Assets {
+		if !loaded {
+			t.Errorf("asset %q still loading", path)
+		}
+	}
+	if h.State.Width != 640 || h.State.Height != 480 {
+		t.Errorf("state is %dx%d, want 640x480", h.State.Width, h.State.Height)
+	}
+	// models load in the background like everything else
+	var model bool
+	for _, e := range h.SideEffects {
+		if load, ok := e.(*sideeffect.LoadAsset); ok && load.Kind == sideeffect.AssetModel {
+			model = h.State.Assets[load.Path]
+		}
+	}
+	if !model {
+		t.Error("no model was loaded")
+	}
+}
+
+func TestPlayerWalks(t *testing.T) {
+	h := New(500, 500)
+	start := h.State.Camera.Position
+
+	// the player drops onto the floor
+	h.TickFor(1, dt)
+	if !h.State.Player.OnGround {
+		t.Fatalf("player isn't on the ground, feet at %v", h.State.Player.Feet)
+	}
+	eye := h.State.Camera.Position
+	if eye[1] >= start[1] {
+		t.Errorf("camera at %v, want it lower than the start %v", eye, start)
+	}
+	if eye != h.State.Player.Eye() {
+		t.Errorf("camera at %v, want it at the player's eye %v", eye, h.State.Player.Eye())
+	}
+
+	// walking forward goes along -z, the way the camera starts out facing
+	h.KeyPress(glfw.KeyW)
+	h.TickFor(0.5, dt)
+	h.KeyRelease(glfw.KeyW)
+	walked := eye[2] - h.State.Camera.Position[2]
+	if math.Abs(float64(walked)-2.5) > 0.1 {
+		t.Errorf("walked %.2f in half a second, want 2.5", walked)
+	}
+
+	// and stops when the key's let go
+	stopped := h.State.Camera.Position
+	h.TickFor(0.5, dt)
+	if h.State.Camera.Position != stopped {
+		t.Errorf("camera moved from %v to %v with no keys held", stopped, h.State.Camera.Position)
+	}
+}
+
+// ledgeTest lands the player on the floor, puts a ledge of the given height
+// across the way ahead, from z=4 to z=5.5, and walks into it for the given time.
+func ledgeTest(t *testing.T, height float32, walk float64) *Harness {
+	h := New(500, 500)
+	h.TickFor(1, dt)
+	if !h.State.Player.OnGround {
+		t.Fatalf("player isn't on the ground, feet at %v", h.State.Player.Feet)
+	}
+	if got, want := h.State.Player.Feet[1], float32(floorTop); math.Abs(float64(got-want)) > 1e-3 {
+		t.Fatalf("player landed at %v, want on the floor at %v", got, want)
+	}
+
+	half := mgl.Vec3{3, height / 2, 0.75}
+	h.State.World.Spawn(ecs.Spec{
+		Transform: &ecs.Transform{Location: mgl.Vec3{0, floorTop + half[1], 4.75}},
+		Collider:  &ecs.Collider{Kind: ecs.ColliderBox, HalfSize: half},
+	})
+	h.KeyPress(glfw.KeyW)
+	h.TickFor(walk, dt)
+	return h
+}
+
+// floorTop is the height of the top of the scene's floor
+const floorTop = -2.5
+
+func TestPlayerStepsUp(t *testing.T) {
+	// 2.5 along from z=7 is halfway across
+	h := ledgeTest(t, 0.5, 0.5)
+	p := h.State.Player
+	if math.Abs(float64(p.Feet[2])-4.5) > 0.1 {
+		t.Errorf("player at z=%v, want it to walk on to 4.5", p.Feet[2])
+	}
+	if math.Abs(float64(p.Feet[1]-(floorTop+0.5))) > 1e-3 || !p.OnGround {
+		t.Errorf("player at %v, on the ground %v, want it standing on the ledge", p.Feet, p.OnGround)
+	}
+}
+
+func TestPlayerBlockedByWall(t *testing.T) {
+	h := ledgeTest(t, 1, 1)
+	p := h.State.Player
+	if want := 5.5 + playerHalfWidth; math.Abs(float64(p.Feet[2])-want) > 1e-3 {
+		t.Errorf("player at z=%v, want it stopped at the wall at %v", p.Feet[2], want)
+	}
+	if math.Abs(float64(p.Feet[1]-floorTop)) > 1e-3 {
+		t.Errorf("player at y=%v, want it still on the floor", p.Feet[1])
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	h := New(500, 500)
+	h.Run([]game.Action{
+		{Type: game.Tick, Tick: &game.TickAction{Dt: dt}},
+		{Type: game.Keyboard, Keyboard: &game.KeyboardAction{Key: glfw.KeyLeft, Action: glfw.Press}},
+		{Type: game.Tick, Tick: &game.TickAction{Dt: dt}},
+	})
+	if got := h.Clock.Now(); math.Abs(got-2*dt) > 1e-9 {
+		t.Errorf("clock at %f after two Ticks, want %f", got, 2*dt)
+	}
+	if got, want := h.State.Camera.Yaw, game.Pi_2+game.Pi_6/2; math.Abs(got-want) > 1e-6 {
+		t.Errorf("camera yaw %.4f, want %.4f after turning left", got, want)
+	}
+}
+
+func TestSideEffects(t *testing.T) {
+	h := New(500, 500)
+
+	h.KeyPress(glfw.KeyEscape)
+	if h.MouseGameMode {
+		t.Error("Escape didn't free the mouse")
+	}
+	h.KeyPress(glfw.KeyEscape)
+	if !h.MouseGameMode {
+		t.Error("Escape again didn't capture the mouse")
+	}
+
+	h.KeyPress(glfw.KeyF11)
+	if !h.Fullscreen {
+		t.Error("F11 didn't go fullscreen")
+	}
+
+	h.SideEffects = nil
+	h.KeyPress(glfw.KeyF12)
+	if len(h.SideEffects) != 1 {
+		t.Fatalf("F12 made %d side effects, want 1", len(h.SideEffects))
+	}
+	if _, ok := h.SideEffects[0].(*sideeffect.TakeScreenshot); !ok {
+		t.Errorf("F12 made a %T, want a TakeScreenshot", h.SideEffects[0])
+	}
+
+	h.KeyPress(glfw.KeyQ)
+	if h.Quit {
+		t.Error("Q without Ctrl quit")
+	}
+	h.Apply(&game.Action{
+		Type:     game.Keyboard,
+		Keyboard: &game.KeyboardAction{Key: glfw.KeyQ, Action: glfw.Press, Modifier: glfw.ModControl},
+	})
+	if !h.Quit {
+		t.Error("Ctrl-Q didn't quit")
+	}
+}
+
+func TestResetCamera(t *testing.T) {
+	h := New(500, 500)
+	start := h.State.StartCamera.Position
+
+	h.KeyPress(glfw.KeyT)
+	if h.State.Camera.Mode != camera.Orbit || h.MouseGameMode {
+		t.Fatalf("T didn't switch to Orbit with the mouse free")
+	}
+	h.MouseButton(glfw.MouseButtonLeft, glfw.Press)
+	h.MouseMove(300, 200)
+	h.MouseMove(350, 150)
+	h.MouseButton(glfw.MouseButtonLeft, glfw.Release)
+
+	h.KeyPress(glfw.Key0)
+	if h.State.Camera.Mode != camera.FPS {
+		t.Errorf("camera mode %v after reset, want FPS", h.State.Camera.Mode)
+	}
+	if !h.MouseGameMode || !h.State.Mouse.GameMode {
+		t.Error("mouse still free after reset")
+	}
+	if h.State.Camera.Position != start {
+		t.Errorf("camera at %v after reset, want %v", h.State.Camera.Position, start)
+	}
+	if h.State.Player.Eye() != start {
+		t.Errorf("player's eye at %v after reset, want %v", h.State.Player.Eye(), start)
+	}
+}
+
+// recordPath walks the player forward, keeping a camera keyframe before and
+// after, and saves the path with F5.
+func recordPath(t *testing.T, h *Harness) {
+	t.Helper()
+	h.TickFor(1, dt)
+	h.KeyPress(glfw.KeyK)
+	h.KeyPress(glfw.KeyW)
+	h.TickFor(1, dt)
+	h.KeyRelease(glfw.KeyW)
+	h.KeyPress(glfw.KeyK)
+	h.KeyPress(glfw.KeyF5)
+	if len(h.State.CameraPath.Keyframes) != 2 || h.Files["camera_path.json"] == nil {
+		t.Fatalf("camera path wasn't recorded and saved")
+	}
+}
+
+func TestLoadPathStopsFlyThrough(t *testing.T) {
+	h := New(500, 500)
+	recordPath(t, h)
+	walkedTo := h.State.Camera.Position
+
+	h.KeyPress(glfw.KeyP)
+	h.TickFor(0.25, dt)
+	if !h.State.FlyThrough.Playing || h.MouseGameMode {
+		t.Fatalf("P didn't start the fly-through with the mouse free")
+	}
+
+	// F9 reads the file back, delivering a FileRead mid-flight
+	h.KeyPress(glfw.KeyF9)
+	stopped := h.State.Camera.Position
+	if h.State.FlyThrough.Playing {
+		t.Error("fly-through still playing after loading a path")
+	}
+	if !h.MouseGameMode || !h.State.Mouse.GameMode {
+		t.Error("mouse still free after loading a path")
+	}
+	if h.State.Player.Eye() != stopped {
+		t.Errorf("player's eye at %v, want it where the camera stopped %v", h.State.Player.Eye(), stopped)
+	}
+
+	// and the camera stays there rather than going back to the player's old spot
+	h.Tick(dt)
+	if d := h.State.Camera.Position.Sub(stopped).Len(); d > 0.1 {
+		t.Errorf("camera moved %.2f from %v to %v after the fly-through stopped (player was at %v)", d, stopped, h.State.Camera.Position, walkedTo)
+	}
+}
+
+func TestClearPathStopsFlyThrough(t *testing.T) {
+	h := New(500, 500)
+	recordPath(t, h)
+	h.KeyPress(glfw.KeyP)
+	h.TickFor(0.25, dt)
+	stopped := h.State.Camera.Position
+
+	h.Apply(&game.Action{
+		Type:     game.Keyboard,
+		Keyboard: &game.KeyboardAction{Key: glfw.KeyK, Action: glfw.Press, Modifier: glfw.ModControl},
+	})
+	if len(h.State.CameraPath.Keyframes) != 0 {
+		t.Fatalf("Ctrl-K left %d keyframes", len(h.State.CameraPath.Keyframes))
+	}
+	if h.State.FlyThrough.Playing || !h.MouseGameMode {
+		t.Error("fly-through still playing after clearing the path")
+	}
+
+	h.TickFor(0.5, dt)
+	if h.State.FOV <= 0 {
+		t.Errorf("FOV is %v", h.State.FOV)
+	}
+	if d := h.State.Camera.Position.Sub(stopped).Len(); d > 1 {
+		t.Errorf("camera moved from %v to %v after the path was cleared", stopped, h.State.Camera.Position)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRecordingFails(t *testing.T) {
+	h := New(500, 500)
+	h.Recorder = record.NewRecorder(failingWriter{})
+	h.KeyPress(glfw.KeyLeft)
+	if h.RecordErr == nil {
+		t.Error("no RecordErr")
+	}
+	if h.Recorder != nil {
+		t.Error("Recorder kept after failing")
+	}
+	if got, want := h.State.Camera.Yaw, game.Pi_2+game.Pi_6/2; math.Abs(got-want) > 1e-6 {
+		t.Errorf("camera yaw %.4f, want %.4f: the action should still be applied", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+// Package record saves the stream of game.Actions fed to game.Update so a
+// session can be replayed frame-for-frame.
+//
+// Recordings are JSON lines, one Entry per line.
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+)
+
+// Entry is one action along with the harness time (seconds) it was applied at.
+type Entry struct {
+	T      float64
+	Action game.Action
+}
+
+type Recorder struct {
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Create opens (truncating) the file at path and returns a Recorder writing to it.
+func Create(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	rec := NewRecorder(f)
+	rec.closer = f
+	return rec, nil
+}
+
+func (me *Recorder) Record(t float64, action *game.Action) error {
+	return me.enc.Encode(Entry{T: t, Action: *action})
+}
+
+func (me *Recorder) Close() error {
+	if me.closer == nil {
+		return nil
+	}
+	return me.closer.Close()
+}
+
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("record.Read: line %d: %s", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
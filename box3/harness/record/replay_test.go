@@ -0,0 +1,112 @@
+package record_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/headless"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/record"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+const dt = 1.0 / 60
+
+// play drives a session of walking, looking and turning around the scene
+// while the bodies drop onto the floor.
+func play(h *headless.Harness) {
+	h.TickFor(0.5, dt)
+	h.KeyPress(glfw.KeyW)
+	for i := 0; i < 30; i++ {
+		h.MouseMove(float32(250+i*3), float32(250-i))
+		h.Tick(dt)
+	}
+	h.KeyRelease(glfw.KeyW)
+	h.KeyPress(glfw.KeyLeft)
+	h.KeyPress(glfw.KeyD)
+	h.Frame(0.25)
+	h.KeyRelease(glfw.KeyD)
+	h.KeyPress(glfw.KeySpace)
+	h.Tick(dt)
+	h.KeyRelease(glfw.KeySpace)
+	h.MouseButton(glfw.MouseButtonLeft, glfw.Press)
+	h.MouseButton(glfw.MouseButtonLeft, glfw.Release)
+	h.TickFor(1, dt)
+}
+
+func TestReplayMatches(t *testing.T) {
+	var buf bytes.Buffer
+	recorded := headless.New(500, 500)
+	recorded.Recorder = record.NewRecorder(&buf)
+	play(recorded)
+	if recorded.RecordErr != nil {
+		t.Fatal(recorded.RecordErr)
+	}
+
+	entries, err := record.Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("nothing recorded")
+	}
+
+	replayed := headless.New(500, 500)
+	replayed.Replay(entries)
+
+	want := record.TakeSnapshot(recorded.State)
+	got := record.TakeSnapshot(replayed.State)
+	if diffs := want.Diff(got, 1e-6); len(diffs) > 0 {
+		for _, d := range diffs {
+			t.Error(d)
+		}
+	}
+	if got.CameraPosition == record.TakeSnapshot(headless.New(500, 500).State).CameraPosition {
+		t.Error("the session didn't move the camera, so proves nothing")
+	}
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	h := headless.New(500, 500)
+	h.TickFor(0.5, dt)
+	a := record.TakeSnapshot(h.State)
+	if diffs := a.Diff(a, 0); len(diffs) > 0 {
+		t.Errorf("snapshot differs from itself: %v", diffs)
+	}
+
+	h.KeyPress(glfw.KeyRight)
+	h.Tick(dt)
+	b := record.TakeSnapshot(h.State)
+	diffs := a.Diff(b, 1e-6)
+	if len(diffs) == 0 {
+		t.Fatal("no differences after turning and ticking")
+	}
+	found := false
+	for _, d := range diffs {
+		if strings.HasPrefix(d, "CameraYaw") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("turning wasn't noticed in %v", diffs)
+	}
+}
+
+func TestSaveLoadSnapshot(t *testing.T) {
+	h := headless.New(500, 500)
+	h.TickFor(0.5, dt)
+	snap := record.TakeSnapshot(h.State)
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := record.SaveSnapshot(path, snap); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := record.LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := snap.Diff(loaded, 0); len(diffs) > 0 {
+		t.Errorf("loaded snapshot differs: %v", diffs)
+	}
+}
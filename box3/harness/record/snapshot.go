@@ -0,0 +1,114 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Snapshot captures the parts of game.State worth comparing between a
+// recorded session and its replay.
+type Snapshot struct {
+	CameraPosition mgl.Vec3
+	CameraYaw      float64
+	CameraPitch    float64
+	MouseGameMode  bool
+	Entities       []EntitySnapshot
+}
+
+// EntitySnapshot is the Transform of an entity, listed in creation order.
+type EntitySnapshot struct {
+	Entity        ecs.Entity
+	Location      mgl.Vec3
+	Rotation      mgl.Quat
+	LocalRotation mgl.Quat
+}
+
+func TakeSnapshot(s *game.State) Snapshot {
+	snap := Snapshot{
+		CameraPosition: s.Camera.Position,
+		CameraYaw:      s.Camera.Yaw,
+		CameraPitch:    s.Camera.Pitch,
+		MouseGameMode:  s.Mouse.GameMode,
+	}
+	for _, e := range s.World.Query(ecs.ComponentTransform) {
+		t := s.World.Transforms[e]
+		snap.Entities = append(snap.Entities, EntitySnapshot{
+			Entity:        e,
+			Location:      t.Location,
+			Rotation:      t.Rotation,
+			LocalRotation: t.LocalRotation,
+		})
+	}
+	return snap
+}
+
+// Diff describes each difference between two snapshots greater than epsilon.
+// An empty result means they match.
+func (me Snapshot) Diff(other Snapshot, epsilon float64) []string {
+	var diffs []string
+	differs := func(a, b float64) bool { return math.Abs(a-b) > epsilon }
+	vec3Differs := func(a, b mgl.Vec3) bool {
+		return differs(float64(a[0]), float64(b[0])) || differs(float64(a[1]), float64(b[1])) || differs(float64(a[2]), float64(b[2]))
+	}
+	quatDiffers := func(a, b mgl.Quat) bool {
+		return differs(float64(a.W), float64(b.W)) || vec3Differs(a.V, b.V)
+	}
+
+	if vec3Differs(me.CameraPosition, other.CameraPosition) {
+		diffs = append(diffs, fmt.Sprintf("CameraPosition: %v != %v", me.CameraPosition, other.CameraPosition))
+	}
+	if differs(me.CameraYaw, other.CameraYaw) {
+		diffs = append(diffs, fmt.Sprintf("CameraYaw: %v != %v", me.CameraYaw, other.CameraYaw))
+	}
+	if differs(me.CameraPitch, other.CameraPitch) {
+		diffs = append(diffs, fmt.Sprintf("CameraPitch: %v != %v", me.CameraPitch, other.CameraPitch))
+	}
+	if me.MouseGameMode != other.MouseGameMode {
+		diffs = append(diffs, fmt.Sprintf("MouseGameMode: %v != %v", me.MouseGameMode, other.MouseGameMode))
+	}
+	if len(me.Entities) != len(other.Entities) {
+		diffs = append(diffs, fmt.Sprintf("len(Entities): %d != %d", len(me.Entities), len(other.Entities)))
+		return diffs
+	}
+	for i, a := range me.Entities {
+		b := other.Entities[i]
+		if a.Entity != b.Entity {
+			diffs = append(diffs, fmt.Sprintf("Entities[%d]: entity %d != %d", i, a.Entity, b.Entity))
+			continue
+		}
+		if vec3Differs(a.Location, b.Location) {
+			diffs = append(diffs, fmt.Sprintf("Entity %d Location: %v != %v", a.Entity, a.Location, b.Location))
+		}
+		if quatDiffers(a.Rotation, b.Rotation) {
+			diffs = append(diffs, fmt.Sprintf("Entity %d Rotation: %v != %v", a.Entity, a.Rotation, b.Rotation))
+		}
+		if quatDiffers(a.LocalRotation, b.LocalRotation) {
+			diffs = append(diffs, fmt.Sprintf("Entity %d LocalRotation: %v != %v", a.Entity, a.LocalRotation, b.LocalRotation))
+		}
+	}
+	return diffs
+}
+
+func SaveSnapshot(path string, snap Snapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func LoadSnapshot(path string) (Snapshot, error) {
+	var snap Snapshot
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	err = json.Unmarshal(data, &snap)
+	return snap, err
+}
@@ -25,3 +25,79 @@ type MouseMode_Game struct {
 type MouseMode_UI struct {
 	eventBase
 }
+
+// Quit asks the harness to close the window and end the run loop.
+type Quit struct {
+	eventBase
+}
+
+// SetWindowTitle names the window. The harness follows it with the renderer's draw counts.
+type SetWindowTitle struct {
+	eventBase
+	Title string
+}
+
+// ToggleFullscreen switches between windowed mode and fullscreen on the primary monitor.
+type ToggleFullscreen struct {
+	eventBase
+}
+
+// ResizeWindow requests a new window size in screen coordinates.
+// The game will hear about the result via a WindowSize action.
+type ResizeWindow struct {
+	eventBase
+	Width, Height int
+}
+
+type PlaySound struct {
+	eventBase
+	Name   string
+	Volume float32
+}
+
+type AssetKind int
+
+const (
+	AssetTexture AssetKind = iota
+	AssetShader
+	AssetFont
+	AssetMaterial
+	AssetModel
+)
+
+// LoadAsset asks the harness to load an asset in the background. The game
+// hears back via an AssetLoaded or AssetFailed action.
+// Path is a texture file, a shader name (as in "shaders/<name>.vert.glsl"), a
+// font file, a material file (see helpers.MaterialDef) or a .gltf or .glb
+// model file.
+type LoadAsset struct {
+	eventBase
+	Kind AssetKind
+	Path string
+	Size int // point size, for AssetFont
+}
+
+// TakeScreenshot saves the next rendered frame to a PNG file at Path.
+type TakeScreenshot struct {
+	eventBase
+	Path string
+}
+
+// WriteFile saves Data to the file at Path, replacing it.
+type WriteFile struct {
+	eventBase
+	Path string
+	Data []byte
+}
+
+// ReadFile asks the harness to read the file at Path. The game hears back
+// via a FileRead action, or an Error action if it can't be read.
+type ReadFile struct {
+	eventBase
+	Path string
+}
+
+type SetVsync struct {
+	eventBase
+	Enabled bool
+}
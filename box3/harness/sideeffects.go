@@ -0,0 +1,165 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"time"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/assets"
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// SoundPlayer is whatever audio backend the harness has been given.
+type SoundPlayer interface {
+	Play(name string, volume float32) error
+}
+
+// windowPlacement remembers where the window was before going fullscreen
+type windowPlacement struct {
+	x, y          int
+	width, height int
+}
+
+// handleSideEffects carries out each side effect in turn. Failures are
+// reported back to the game as Error actions rather than stopping the harness.
+func (me *Harness) handleSideEffects(sideEffects []sideeffect.Event) {
+	for _, e := range sideEffects {
+		if gameErr, ok := e.(*sideeffect.Error); ok {
+			// The game already knows about its own errors
+			fmt.Printf("!! ERROR from game: %s\n", gameErr.Error)
+			continue
+		}
+		err := me.HandleSideEffect(e)
+		if err != nil {
+			me.reportError(e, err)
+		}
+	}
+}
+
+// reportError delivers a failed side effect to the game as an Error action.
+func (me *Harness) reportError(e sideeffect.Event, err error) {
+	if me.reportingError {
+		// Handling the Error action failed too; don't go around in circles.
+		fmt.Printf("!! ERROR Harness: %T failed while reporting an earlier error: %s\n", e, err)
+		return
+	}
+	me.reportingError = true
+	me.update(&game.Action{
+		Type: game.Error,
+		Error: &game.ErrorAction{
+			Message:    err.Error(),
+			SideEffect: fmt.Sprintf("%T", e),
+		},
+	})
+	me.reportingError = false
+}
+
+func (me *Harness) HandleSideEffect(e sideeffect.Event) error {
+	if e == nil {
+		return nil
+	}
+	if me.DebugSideEffects {
+		fmt.Printf("Harness.HandleSideEffect(): %v\n", reflect.TypeOf(e))
+	}
+	switch event := e.(type) {
+	case *sideeffect.Error:
+		return event.Error
+	case *sideeffect.MouseMode_Game:
+		me.MouseModeGame()
+	case *sideeffect.MouseMode_UI:
+		me.MouseModeUI()
+	case *sideeffect.Quit:
+		me.win.SetShouldClose(true)
+	case *sideeffect.SetWindowTitle:
+		me.title = event.Title
+		me.showTitle()
+	case *sideeffect.ToggleFullscreen:
+		return me.toggleFullscreen()
+	case *sideeffect.ResizeWindow:
+		if event.Width <= 0 || event.Height <= 0 {
+			return fmt.Errorf("invalid window size %dx%d", event.Width, event.Height)
+		}
+		me.win.SetSize(event.Width, event.Height)
+	case *sideeffect.PlaySound:
+		if me.Sound == nil {
+			return fmt.Errorf("can't play sound %q: no SoundPlayer", event.Name)
+		}
+		return me.Sound.Play(event.Name, event.Volume)
+	case *sideeffect.LoadAsset:
+		return me.loadAsset(event)
+	case *sideeffect.TakeScreenshot:
+		me.screenshotPath = event.Path
+		if me.screenshotPath == "" {
+			me.screenshotPath = fmt.Sprintf("screenshot-%s.png", time.Now().Format("20060102-150405"))
+		}
+	case *sideeffect.WriteFile:
+		return ioutil.WriteFile(event.Path, event.Data, 0644)
+	case *sideeffect.ReadFile:
+		data, err := ioutil.ReadFile(event.Path)
+		if err != nil {
+			return err
+		}
+		me.ApplyUpdate(&game.Action{Type: game.FileRead, File: &game.FileAction{Path: event.Path, Data: data}})
+	case *sideeffect.SetVsync:
+		if event.Enabled {
+			glfw.SwapInterval(1)
+		} else {
+			glfw.SwapInterval(0)
+		}
+	default:
+		return fmt.Errorf("unhandled side effect %T", e)
+	}
+
+	return nil
+}
+
+func (me *Harness) MouseModeGame() {
+	me.win.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+}
+
+func (me *Harness) MouseModeUI() {
+	me.win.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+}
+
+func (me *Harness) toggleFullscreen() error {
+	if me.win.GetMonitor() != nil {
+		w := me.windowed
+		me.win.SetMonitor(nil, w.x, w.y, w.width, w.height, 0)
+		return nil
+	}
+	monitor := glfw.GetPrimaryMonitor()
+	if monitor == nil {
+		return errors.New("no monitor available for fullscreen")
+	}
+	me.windowed.x, me.windowed.y = me.win.GetPos()
+	me.windowed.width, me.windowed.height = me.win.GetSize()
+	mode := monitor.GetVideoMode()
+	me.win.SetMonitor(monitor, 0, 0, mode.Width, mode.Height, mode.RefreshRate)
+	return nil
+}
+
+func (me *Harness) loadAsset(e *sideeffect.LoadAsset) error {
+	if e.Path == "" {
+		return errors.New("LoadAsset with no path")
+	}
+	me.assets.Request(assets.Request{Kind: e.Kind, Path: e.Path, Size: e.Size})
+	return nil
+}
+
+// saveScreenshot writes the just-drawn frame to the pending screenshot path
+func (me *Harness) saveScreenshot() {
+	path := me.screenshotPath
+	me.screenshotPath = ""
+	img := me.renderer.Screenshot()
+	err := helpers.SavePNG(path, img)
+	if err != nil {
+		me.reportError(&sideeffect.TakeScreenshot{Path: path}, err)
+		return
+	}
+	fmt.Printf("Harness: saved screenshot %s\n", path)
+}
@@ -3,23 +3,58 @@ package main
 // https://kylewbanks.com/blog/tutorial-opengl-with-golang-part-1-hello-opengl
 
 import (
+	"flag"
 	"log"
 	"runtime"
 
 	"github.com/dcrosby42/go-game-sandbox/box3/harness"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/record"
+	"github.com/faiface/mainthread"
 	_ "github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
+var (
+	recordFile = flag.String("record", "", "record all game actions to this file")
+	replayFile = flag.String("replay", "", "replay game actions from a file made with -record")
+)
+
 func main() {
+	flag.Parse()
 	runtime.LockOSThread()
 	defer glfw.Terminate()
 
-	h, err := harness.New()
+	// Window and GL calls must stay on the main thread; mainthread.Run keeps
+	// it free to take them, letting asset loading work in the background.
+	mainthread.Run(run)
+}
+
+func run() {
+	var (
+		h   *harness.Harness
+		err error
+	)
+	mainthread.Call(func() {
+		h, err = harness.New()
+	})
 	if err != nil {
 		log.Fatalf("Harness setup failed. err=%s", err)
 		return
 	}
 
+	if *replayFile != "" {
+		entries, err := record.Load(*replayFile)
+		if err != nil {
+			log.Fatalf("Loading replay failed. err=%s", err)
+		}
+		h.StartReplay(entries)
+	}
+	if *recordFile != "" {
+		err = h.StartRecording(*recordFile)
+		if err != nil {
+			log.Fatalf("Recording setup failed. err=%s", err)
+		}
+	}
+
 	h.Play()
 }
@@ -0,0 +1,352 @@
+// Package physics simulates the ecs entities that have Colliders, using the
+// cubez rigid body engine.
+//
+// Step is called once per fixed simulation tick, after game.Update has handled
+// the Tick. It makes cubez bodies for new entities, integrates the RigidBodies
+// and resolves collisions, but leaves the ecs.World alone: where the bodies
+// ended up, and which pairs of entities started or stopped touching, come back
+// as a PhysicsStep action for the harness to pass to game.Update.
+//
+// Entities with a Collider but no RigidBody are immovable as far as the
+// physics is concerned, but follow their Transform, and its parents, if the
+// game moves them. Entities with a RigidBody whose Transform has a Parent or a
+// Rotation are left out of the simulation, since where the physics moves them
+// couldn't be written back to their Location and LocalRotation.
+package physics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/tbogdala/cubez"
+	m "github.com/tbogdala/cubez/math"
+)
+
+type World struct {
+	// Gravity accelerates every RigidBody
+	Gravity mgl.Vec3
+
+	// Friction and Restitution (bounciness) apply to every contact
+	Friction, Restitution float32
+
+	// Iterations per contact allowed for resolving collisions
+	Iterations int
+
+	bodies   map[ecs.Entity]*body
+	order    []ecs.Entity
+	touching map[pair]game.ContactAction
+
+	// rejected holds the entities newBody refused, so they're reported once
+	rejected map[ecs.Entity]bool
+}
+
+// body is the cubez side of an entity
+type body struct {
+	collider cubez.Collider
+	kind     ecs.ColliderKind
+	dynamic  bool
+	scale    mgl.Vec3 // the world scale the collider was sized for
+
+	// what we last read from the entity's Transform and RigidBody, or sent
+	// to the game to write there, so we can tell when the game has changed them
+	synced          bool
+	world           mgl.Mat4 // of a plane
+	location        mgl.Vec3
+	rotation        mgl.Quat
+	velocity        mgl.Vec3
+	angularVelocity mgl.Vec3
+}
+
+type pair struct {
+	a, b ecs.Entity
+}
+
+func NewWorld() *World {
+	return &World{
+		Gravity:     mgl.Vec3{0, -9.8, 0},
+		Friction:    0.6,
+		Restitution: 0.3,
+		Iterations:  8,
+		bodies:      make(map[ecs.Entity]*body),
+		touching:    make(map[pair]game.ContactAction),
+		rejected:    make(map[ecs.Entity]bool),
+	}
+}
+
+// Step advances the simulation by dt seconds, returning a PhysicsStep action
+// with where the RigidBodies moved to and the contacts that began and ended.
+// w is only read; applying the action moves the entities.
+func (me *World) Step(w *ecs.World, dt float64) *game.Action {
+	me.sync(w)
+	duration := m.Real(dt)
+
+	for _, e := range me.order {
+		b := me.bodies[e]
+		if b.dynamic {
+			b.collider.GetBody().Integrate(duration)
+			b.collider.CalculateDerivedData()
+		}
+	}
+
+	var contacts []*cubez.Contact
+	now := make(map[pair]game.ContactAction)
+	for i, e1 := range me.order {
+		b1 := me.bodies[e1]
+		if !b1.dynamic {
+			continue
+		}
+		for j, e2 := range me.order {
+			b2 := me.bodies[e2]
+			if b2.dynamic && j <= i {
+				continue // each dynamic pair once
+			}
+			before := len(contacts)
+			var found bool
+			found, contacts = cubez.CheckForCollisions(b1.collider, b2.collider, contacts)
+			if !found || len(contacts) == before {
+				continue
+			}
+			for _, c := range contacts[before:] {
+				c.Friction = m.Real(me.Friction)
+				c.Restitution = m.Real(me.Restitution)
+			}
+			c := contacts[before]
+			var contact game.ContactAction
+			contact.A, contact.B = e1, e2
+			helpers.SetGlVector3(&contact.Point, &c.ContactPoint)
+			helpers.SetGlVector3(&contact.Normal, &c.ContactNormal)
+			contact.Penetration = float32(c.Penetration)
+			now[pair{e1, e2}] = contact
+		}
+	}
+	if len(contacts) > 0 {
+		cubez.ResolveContacts(len(contacts)*me.Iterations, contacts, duration)
+	}
+
+	result := &game.PhysicsAction{}
+	for _, e := range me.order {
+		b := me.bodies[e]
+		if b.dynamic {
+			result.Bodies = append(result.Bodies, b.motion(e))
+		}
+	}
+
+	for p, c := range now {
+		if _, was := me.touching[p]; !was {
+			result.Began = append(result.Began, c)
+		}
+	}
+	for p, c := range me.touching {
+		if _, is := now[p]; !is {
+			result.Ended = append(result.Ended, c)
+		}
+	}
+	me.touching = now
+	sortContacts(result.Began)
+	sortContacts(result.Ended)
+	return &game.Action{Type: game.PhysicsStep, Physics: result}
+}
+
+// sync makes bodies for new entities, drops those of removed ones, and picks
+// up changes the game has made to Transforms and RigidBodies.
+func (me *World) sync(w *ecs.World) {
+	var gone []ecs.Entity
+	for _, e := range me.order {
+		if !w.Has(e, ecs.ComponentCollider) {
+			gone = append(gone, e)
+		}
+	}
+	for _, e := range gone {
+		me.remove(e)
+	}
+	for e := range me.rejected {
+		if !w.Has(e, ecs.ComponentCollider) {
+			delete(me.rejected, e)
+		}
+	}
+
+	for _, e := range w.Query(ecs.ComponentCollider) {
+		_, _, scale := w.WorldPose(e)
+		b, ok := me.bodies[e]
+		if ok && (b.kind != w.Colliders[e].Kind || b.dynamic != w.Has(e, ecs.ComponentRigidBody) || b.scale != scale) {
+			me.remove(e)
+			ok = false
+		}
+		if !ok {
+			var err error
+			b, err = me.newBody(w, e, scale)
+			if err != nil {
+				if !me.rejected[e] {
+					fmt.Printf("!! ERROR physics.World.sync() %s\n", err)
+					me.rejected[e] = true
+				}
+				continue
+			}
+			delete(me.rejected, e)
+			me.bodies[e] = b
+			me.order = append(me.order, e)
+		}
+		b.readFrom(w, e, me.Gravity)
+	}
+}
+
+func (me *World) remove(e ecs.Entity) {
+	delete(me.bodies, e)
+	for i, other := range me.order {
+		if other == e {
+			me.order = append(me.order[:i], me.order[i+1:]...)
+			break
+		}
+	}
+	for p := range me.touching {
+		if p.a == e || p.b == e {
+			delete(me.touching, p)
+		}
+	}
+}
+
+// newBody makes the cubez body for an entity's Collider at the given world
+// scale. It fails for a RigidBody that has a Parent or a Rotation.
+func (me *World) newBody(w *ecs.World, e ecs.Entity, scale mgl.Vec3) (*body, error) {
+	col := w.Colliders[e]
+	rb, dynamic := w.Bodies[e]
+	b := &body{kind: col.Kind, dynamic: dynamic, scale: scale}
+
+	var inertia m.Matrix3
+	switch col.Kind {
+	case ecs.ColliderPlane:
+		// readFrom places it
+		b.collider = cubez.NewCollisionPlane(vector3(col.Normal.Normalize()), m.Real(col.Offset))
+		b.dynamic = false // planes can't move
+		return b, nil
+	}
+
+	if t, ok := w.Transforms[e]; ok && dynamic && (t.Parent != 0 || t.Rotation != mgl.QuatIdent()) {
+		return nil, fmt.Errorf("entity %d has a RigidBody, so its Transform can't have a Parent or a Rotation", e)
+	}
+
+	sized := col.Scaled(scale)
+	switch col.Kind {
+	case ecs.ColliderSphere:
+		sphere := cubez.NewCollisionSphere(nil, m.Real(sized.Radius))
+		if dynamic {
+			// solid sphere: 2/5 m r^2 about each axis
+			i := m.Real(0.4 * rb.Mass * sized.Radius * sized.Radius)
+			inertia = m.Matrix3{i, 0, 0, 0, i, 0, 0, 0, i}
+		}
+		b.collider = sphere
+
+	default:
+		cube := cubez.NewCollisionCube(nil, vector3(sized.HalfSize))
+		if dynamic {
+			inertia.SetBlockInertiaTensor(&cube.HalfSize, m.Real(rb.Mass))
+		}
+		b.collider = cube
+	}
+
+	cb := b.collider.GetBody()
+	if dynamic {
+		cb.SetMass(m.Real(rb.Mass))
+		cb.SetInertiaTensor(&inertia)
+		cb.LinearDamping = damping(rb.LinearDamping)
+		cb.AngularDamping = damping(rb.AngularDamping)
+		cb.CanSleep = true
+		cb.SetAwake(true)
+	} else {
+		cb.SetInfiniteMass()
+	}
+	return b, nil
+}
+
+func damping(d float32) m.Real {
+	if d == 0 {
+		return 1
+	}
+	return m.Real(d)
+}
+
+// readFrom copies the entity's pose and RigidBody into the cubez body if
+// they were changed by someone other than us. A RigidBody's pose is its
+// Transform's Location and LocalRotation; anything else goes by its WorldPose.
+func (me *body) readFrom(w *ecs.World, e ecs.Entity, gravity mgl.Vec3) {
+	if me.kind == ecs.ColliderPlane {
+		// a plane moves and turns with its Transform
+		if world := w.WorldMatrix(e); !me.synced || world != me.world {
+			normal, offset := w.WorldPlane(e)
+			plane := me.collider.(*cubez.CollisionPlane)
+			plane.Normal = vector3(normal)
+			plane.Offset = m.Real(offset)
+			me.world = world
+		}
+		me.synced = true
+		return
+	}
+	cb := me.collider.GetBody()
+	changed := false
+	location, rotation := mgl.Vec3{}, mgl.QuatIdent()
+	if me.dynamic {
+		if t, ok := w.Transforms[e]; ok {
+			location, rotation = t.Location, t.LocalRotation
+		}
+	} else {
+		location, rotation, _ = w.WorldPose(e)
+	}
+	if !me.synced || location != me.location || rotation != me.rotation {
+		cb.Position = vector3(location)
+		cb.Orientation = quat(rotation)
+		me.location, me.rotation = location, rotation
+		changed = true
+	}
+	if rb, ok := w.Bodies[e]; ok {
+		cb.Acceleration = vector3(gravity)
+		if !me.synced || rb.Velocity != me.velocity || rb.AngularVelocity != me.angularVelocity {
+			cb.Velocity = vector3(rb.Velocity)
+			cb.Rotation = vector3(rb.AngularVelocity)
+			me.velocity, me.angularVelocity = rb.Velocity, rb.AngularVelocity
+			changed = true
+		}
+	}
+	me.synced = true
+	if changed {
+		cb.SetAwake(true)
+		cb.CalculateDerivedData()
+		me.collider.CalculateDerivedData()
+	}
+}
+
+// motion is where the simulated body has got to, for the game to write into
+// the entity's Transform and RigidBody.
+func (me *body) motion(e ecs.Entity) game.BodyMotion {
+	cb := me.collider.GetBody()
+	bm := game.BodyMotion{Entity: e}
+	helpers.SetGlVector3(&bm.Location, &cb.Position)
+	helpers.SetGlQuat(&bm.Rotation, &cb.Orientation)
+	bm.Rotation = bm.Rotation.Normalize()
+	helpers.SetGlVector3(&bm.Velocity, &cb.Velocity)
+	helpers.SetGlVector3(&bm.AngularVelocity, &cb.Rotation)
+	me.location, me.rotation = bm.Location, bm.Rotation
+	me.velocity, me.angularVelocity = bm.Velocity, bm.AngularVelocity
+	return bm
+}
+
+func vector3(v mgl.Vec3) m.Vector3 {
+	return m.Vector3{m.Real(v[0]), m.Real(v[1]), m.Real(v[2])}
+}
+
+func quat(q mgl.Quat) m.Quat {
+	return m.Quat{m.Real(q.W), m.Real(q.V[0]), m.Real(q.V[1]), m.Real(q.V[2])}
+}
+
+// sortContacts orders contacts by entity so that replays see the same actions
+func sortContacts(contacts []game.ContactAction) {
+	sort.Slice(contacts, func(i, j int) bool {
+		if contacts[i].A != contacts[j].A {
+			return contacts[i].A < contacts[j].A
+		}
+		return contacts[i].B < contacts[j].B
+	})
+}
@@ -0,0 +1,216 @@
+package physics
+
+import (
+	"testing"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+const dt = 1.0 / 60
+
+func floorSpec(y float32) ecs.Spec {
+	return ecs.Spec{
+		Transform: &ecs.Transform{Location: mgl.Vec3{0, y, 0}},
+		Collider:  &ecs.Collider{Kind: ecs.ColliderPlane, Normal: mgl.Vec3{0, 1, 0}},
+	}
+}
+
+func ballSpec(x, y float32) ecs.Spec {
+	return ecs.Spec{
+		Transform: &ecs.Transform{Location: mgl.Vec3{x, y, 0}},
+		Collider:  &ecs.Collider{Kind: ecs.ColliderSphere, Radius: 0.5},
+		RigidBody: &ecs.RigidBody{Mass: 1},
+	}
+}
+
+func newWorld() *World {
+	pw := NewWorld()
+	pw.Restitution = 0
+	return pw
+}
+
+// step runs a physics step and applies it the way the harness does
+func step(pw *World, w *ecs.World) *game.PhysicsAction {
+	action := pw.Step(w, dt)
+	game.Update(&game.State{World: w}, action)
+	return action.Physics
+}
+
+// stepUntilContact steps until something begins touching, failing after
+// two simulated seconds.
+func stepUntilContact(t *testing.T, pw *World, w *ecs.World) *game.PhysicsAction {
+	for i := 0; i < 120; i++ {
+		p := step(pw, w)
+		if len(p.Ended) > 0 {
+			t.Fatalf("step %d: contacts ended before any began: %v", i, p.Ended)
+		}
+		if len(p.Began) > 0 {
+			return p
+		}
+	}
+	t.Fatal("nothing touched")
+	return nil
+}
+
+func TestFallOntoPlane(t *testing.T) {
+	w := ecs.NewWorld()
+	floor := w.Spawn(floorSpec(0))
+	ball := w.Spawn(ballSpec(0, 2))
+	pw := newWorld()
+
+	p := stepUntilContact(t, pw, w)
+	if len(p.Began) != 1 {
+		t.Fatalf("got %d contacts beginning, want 1: %v", len(p.Began), p.Began)
+	}
+	if c := p.Began[0]; c.A != ball || c.B != floor {
+		t.Errorf("contact between %d and %d, want ball %d and floor %d", c.A, c.B, ball, floor)
+	}
+	if got := w.Transforms[ball].Location[1]; got > 0.6 || got < 0.3 {
+		t.Errorf("ball landed at y=%.3f, want about 0.5", got)
+	}
+	if len(p.Bodies) != 1 || p.Bodies[0].Entity != ball {
+		t.Errorf("moved %v, want just the ball", p.Bodies)
+	}
+
+	// the game lifts the ball off the floor
+	w.Transforms[ball].Location = mgl.Vec3{0, 5, 0}
+	p = step(pw, w)
+	if len(p.Ended) != 1 || p.Ended[0].A != ball || p.Ended[0].B != floor {
+		t.Errorf("got contacts ending %v, want the ball leaving the floor", p.Ended)
+	}
+	if len(p.Began) != 0 {
+		t.Errorf("got contacts beginning %v, want none", p.Began)
+	}
+}
+
+func TestStaticColliderKeepsPosition(t *testing.T) {
+	w := ecs.NewWorld()
+	block := w.Spawn(ecs.Spec{
+		Transform: &ecs.Transform{Location: mgl.Vec3{0, -1, 0}},
+		Collider:  &ecs.Collider{Kind: ecs.ColliderBox, HalfSize: mgl.Vec3{1, 1, 1}},
+	})
+	ball := w.Spawn(ballSpec(0, 2))
+	pw := newWorld()
+
+	for i := 0; i < 120; i++ {
+		p := step(pw, w)
+		for _, bm := range p.Bodies {
+			if bm.Entity == block {
+				t.Fatalf("step %d moved the block to %v", i, bm.Location)
+			}
+		}
+	}
+	if got, want := w.Transforms[block].Location, (mgl.Vec3{0, -1, 0}); got != want {
+		t.Errorf("block at %v, want %v", got, want)
+	}
+	if got := w.Transforms[ball].Location[1]; got < 0.3 {
+		t.Errorf("ball fell to y=%.3f, want it resting on the block", got)
+	}
+}
+
+func TestPlaneFollowsTransform(t *testing.T) {
+	w := ecs.NewWorld()
+	floor := w.Spawn(floorSpec(0))
+	ball := w.Spawn(ballSpec(0, 3))
+	pw := newWorld()
+	step(pw, w)
+
+	// raise the floor under the falling ball
+	w.Transforms[floor].Location = mgl.Vec3{0, 2, 0}
+	stepUntilContact(t, pw, w)
+	if got := w.Transforms[ball].Location[1]; got < 2.3 {
+		t.Errorf("ball landed at y=%.3f, want it on the raised floor at about 2.5", got)
+	}
+}
+
+func TestColliderFollowsWorldPose(t *testing.T) {
+	w := ecs.NewWorld()
+	// a box orbited a quarter turn from z=-2 round to x=-2, under a parent
+	// at y=1, and scaled so it's 3 wide
+	w.Spawn(ecs.Spec{
+		Transform: &ecs.Transform{Location: mgl.Vec3{0, 1, 0}},
+		Children: []ecs.Spec{{
+			Transform: &ecs.Transform{
+				Location: mgl.Vec3{0, 0, -2},
+				Rotation: mgl.QuatRotate(mgl.DegToRad(90), mgl.Vec3{0, 1, 0}),
+				Scale:    mgl.Vec3{3, 1, 3},
+			},
+			Collider: &ecs.Collider{Kind: ecs.ColliderBox, HalfSize: mgl.Vec3{0.5, 0.5, 0.5}},
+		}},
+	})
+	ball := w.Spawn(ballSpec(-3.2, 4))
+	pw := newWorld()
+
+	stepUntilContact(t, pw, w)
+	if got := w.Transforms[ball].Location; got[1] < 1.8 || got[1] > 2.1 || got[0] != -3.2 {
+		t.Errorf("ball landed at %v, want it on top of the box at about y=2", got)
+	}
+}
+
+func TestPlaneFollowsParent(t *testing.T) {
+	w := ecs.NewWorld()
+	stage := w.Spawn(ecs.Spec{
+		Transform: &ecs.Transform{Location: mgl.Vec3{0, 1, 0}},
+		Children:  []ecs.Spec{floorSpec(0.5)},
+	})
+	ball := w.Spawn(ballSpec(0, 4))
+	pw := newWorld()
+
+	stepUntilContact(t, pw, w)
+	if got := w.Transforms[ball].Location[1]; got < 1.8 || got > 2.1 {
+		t.Errorf("ball landed at y=%.3f, want it on the floor at about 2", got)
+	}
+
+	// lowering the parent lowers the floor
+	w.Transforms[stage].Location = mgl.Vec3{0, -1, 0}
+	for i := 0; i < 120; i++ {
+		step(pw, w)
+	}
+	if got := w.Transforms[ball].Location[1]; got > 0.1 || got < -0.2 {
+		t.Errorf("ball at y=%.3f, want it on the lowered floor at about 0", got)
+	}
+}
+
+func TestRigidBodyWithParentLeftOut(t *testing.T) {
+	w := ecs.NewWorld()
+	parent := w.Spawn(ecs.Spec{Transform: &ecs.Transform{}})
+	ball := w.Spawn(ballSpec(0, 2))
+	w.Transforms[ball].Parent = parent
+	spun := w.Spawn(ballSpec(2, 2))
+	w.Transforms[spun].Rotation = mgl.QuatRotate(1, mgl.Vec3{0, 1, 0})
+	pw := newWorld()
+
+	for i := 0; i < 10; i++ {
+		if p := step(pw, w); len(p.Bodies) != 0 {
+			t.Fatalf("step %d moved %v, want nothing", i, p.Bodies)
+		}
+	}
+
+	// once it's unparented the physics takes it
+	w.Transforms[ball].Parent = 0
+	if p := step(pw, w); len(p.Bodies) != 1 || p.Bodies[0].Entity != ball {
+		t.Errorf("moved %v, want just the ball", p.Bodies)
+	}
+}
+
+func TestContactsSorted(t *testing.T) {
+	w := ecs.NewWorld()
+	floor := w.Spawn(floorSpec(0))
+	var balls []ecs.Entity
+	for i := 0; i < 4; i++ {
+		balls = append(balls, w.Spawn(ballSpec(float32(i)*2, 2)))
+	}
+	pw := newWorld()
+
+	p := stepUntilContact(t, pw, w)
+	if len(p.Began) != len(balls) {
+		t.Fatalf("got %d contacts beginning, want %d", len(p.Began), len(balls))
+	}
+	for i, c := range p.Began {
+		if c.A != balls[i] || c.B != floor {
+			t.Errorf("contact %d between %d and %d, want %d and %d", i, c.A, c.B, balls[i], floor)
+		}
+	}
+}
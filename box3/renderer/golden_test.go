@@ -0,0 +1,127 @@
+//go:build golden
+// +build golden
+
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/assets"
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	"github.com/dcrosby42/go-game-sandbox/box3/harness/sideeffect"
+	"github.com/dcrosby42/go-game-sandbox/golden"
+	"github.com/faiface/mainthread"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// testFont stands in for the scene's default font, which only exists on a Mac
+const testFont = "../glfont/testdata/Go-Regular.ttf"
+
+func TestGoldenScene(t *testing.T) {
+	const w, h = 500, 500
+	golden.Context(t, w, h)
+
+	// the scene's asset paths are relative to box3
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, sideEffects := game.Init(&game.State{Width: w, Height: h})
+	s.FontFile = testFont
+
+	mgr := assets.NewManager(2)
+	mgr.ScreenWidth, mgr.ScreenHeight = w, h
+
+	var img *image.NRGBA
+	// the test goroutine holds the GL context, so it serves as the main thread
+	mainthread.Run(func() {
+		for _, e := range sideEffects {
+			if load, ok := e.(*sideeffect.LoadAsset); ok {
+				path := load.Path
+				if load.Kind == sideeffect.AssetFont {
+					path = s.FontFile
+				}
+				mgr.Request(assets.Request{Kind: load.Kind, Path: path, Size: load.Size})
+			}
+		}
+		err = waitForScene(mgr, s, 10*time.Second)
+		if err != nil {
+			return
+		}
+		mainthread.Call(func() {
+			gl.ClearColor(0, 0, 0, 0)
+			r := New(mgr)
+			err = r.Init(s)
+			if err != nil {
+				return
+			}
+			r.Draw(s, 1)
+			img = r.Screenshot()
+		})
+	})
+
+	os.Chdir(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "scene", img, golden.DefaultTolerance)
+}
+
+// waitForScene waits until every entity's material and model, and the font,
+// have loaded, or any asset fails.
+func waitForScene(mgr *assets.Manager, s *game.State, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, res := range mgr.Completed() {
+			if res.Err != nil {
+				return res.Err
+			}
+		}
+		ready := true
+		if _, ok := mgr.Font(s.FontFile, s.FontSize); !ok {
+			ready = false
+		}
+		for _, mat := range s.World.Materials {
+			if !materialLoaded(mgr, mat.Path) {
+				ready = false
+			}
+		}
+		for _, m := range s.World.Models {
+			_, modelOK := mgr.Model(m.Path)
+			_, shaderOK := mgr.Shader(m.Shader)
+			if !modelOK || !shaderOK {
+				ready = false
+			}
+		}
+		if ready {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("assets still loading after %s", timeout)
+}
+
+func materialLoaded(mgr *assets.Manager, path string) bool {
+	def, ok := mgr.Material(path)
+	if !ok {
+		return false
+	}
+	if _, ok := mgr.Shader(def.Shader); !ok {
+		return false
+	}
+	for _, tex := range def.TexturePaths() {
+		if _, ok := mgr.Texture(tex); !ok {
+			return false
+		}
+	}
+	return true
+}
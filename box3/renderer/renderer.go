@@ -0,0 +1,548 @@
+// Package renderer maps the simulation data in game.State onto GPU resources and draws it.
+package renderer
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/dcrosby42/go-game-sandbox/box3/assets"
+	"github.com/dcrosby42/go-game-sandbox/box3/ecs"
+	"github.com/dcrosby42/go-game-sandbox/box3/game"
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+	"github.com/dcrosby42/go-game-sandbox/lighting"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+type Renderer struct {
+	// Assets supplies shaders, textures and fonts once they've loaded
+	Assets *assets.Manager
+
+	// scene holds a Node for every entity with a Transform, arranged by
+	// Transform.Parent
+	scene     *helpers.Node
+	nodes     map[ecs.Entity]*helpers.Node
+	materials map[string]*material
+	lights    *helpers.UniformBuffer
+
+	// assetsVersion counts the calls to AssetsChanged; materials resolved
+	// before the latest one are looked up again
+	assetsVersion int
+
+	// batches draw the entities with Instanced Meshes, one per distinct Mesh
+	// and Material, in the order they were first seen. batchOf is the batch
+	// each such entity is in.
+	batches    map[batchKey]*batch
+	batchOrder []batchKey
+	batchOf    map[ecs.Entity]batchKey
+
+	// models are the loaded glTF scenes of the entities with Models
+	models map[ecs.Entity]*model
+
+	// Target is the offscreen framebuffer each frame is drawn into, before
+	// being copied to the window by Present
+	Target *helpers.RenderTarget
+
+	// Stats counts the meshes (or instances) drawn and culled in the last frame
+	Stats helpers.DrawStats
+}
+
+type batchKey struct {
+	mesh     ecs.Mesh
+	material string
+}
+
+// batch is the entities drawn by one InstancedMesh
+type batch struct {
+	mesh      *helpers.InstancedMesh // nil if it couldn't be built
+	members   []ecs.Entity
+	instances []helpers.Instance // reused from frame to frame
+}
+
+// model is an entity's glTF scene, kept beneath its Node, and the Animator
+// playing its Clips.
+type model struct {
+	path     string
+	source   *helpers.GLTFScene // as loaded by the Assets
+	scene    *helpers.GLTFScene // the entity's Instance of source
+	animator *helpers.Animator
+	clip     string
+	time     float32 // the Model's Time as of the last Tick of the animator
+	version  int     // the assetsVersion its materials were made at
+}
+
+// modelFade is how long a Model takes to cross-fade from one Clip to the next
+const modelFade = 0.5
+
+// lightsBinding is the uniform buffer binding point for the lighting.BlockName block
+const lightsBinding = 0
+
+// material is a Material built from a material file, along with the
+// resources it was built from, so it can be rebuilt when they're reloaded.
+type material struct {
+	m        *helpers.Material
+	def      *helpers.MaterialDef
+	shader   *helpers.ShaderProgram
+	textures map[string]uint32
+	version  int // the assetsVersion it was resolved at
+}
+
+func New(assets *assets.Manager) *Renderer {
+	return &Renderer{
+		Assets:    assets,
+		scene:     helpers.NewNode("scene"),
+		nodes:     make(map[ecs.Entity]*helpers.Node),
+		materials: make(map[string]*material),
+		batches:   make(map[batchKey]*batch),
+		batchOf:   make(map[ecs.Entity]batchKey),
+		models:    make(map[ecs.Entity]*model),
+	}
+}
+
+// Init builds meshes for everything currently in the State.
+// Requires a current GL context.
+func (me *Renderer) Init(s *game.State) error {
+	var err error
+	me.Target, err = helpers.NewRenderTarget(s.Width, s.Height)
+	if err != nil {
+		return err
+	}
+	me.lights = helpers.NewUniformBuffer(lightsBinding, lighting.BlockSize)
+	for _, e := range s.World.Query(ecs.ComponentTransform) {
+		_, err := me.nodeFor(s.World, e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssetsChanged tells the Renderer that an asset has finished loading, or
+// been reloaded, so materials have to be looked up again.
+func (me *Renderer) AssetsChanged() {
+	me.assetsVersion++
+}
+
+// Resize matches the render target to the window's framebuffer size.
+func (me *Renderer) Resize(width, height int) error {
+	if width == me.Target.Width && height == me.Target.Height {
+		return nil
+	}
+	return me.Target.Resize(width, height)
+}
+
+// Draw renders the State into Target. alpha (0..1) is how far along we are between the
+// previous simulation step and the current one; transforms are interpolated
+// accordingly. Objects whose shader or texture is still loading are skipped.
+func (me *Renderer) Draw(s *game.State, alpha float64) {
+	me.Target.Bind()
+	defer me.Target.Unbind()
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	a := float32(alpha)
+	eye := lerpVec3(s.PrevCameraPos, s.Camera.Position, a)
+	cameraView := s.Camera.ViewFrom(eye)
+
+	me.lights.Update(lighting.Pack(s.Ambient, eye, s.Lights))
+
+	me.sync(s.World, s.Selected.Entity, a)
+
+	me.scene.Update()
+	me.Stats = me.scene.Draw(s.Projection, cameraView)
+	me.Stats.Add(me.drawBatches(s.World, s.Selected.Entity, s.Projection, cameraView))
+
+	me.drawText(s, s.Projection, cameraView)
+}
+
+// Present copies the last frame drawn onto the window's framebuffer.
+func (me *Renderer) Present(fbWidth, fbHeight int) {
+	me.Target.BlitToScreen(fbWidth, fbHeight)
+}
+
+// Screenshot reads back the last frame drawn.
+func (me *Renderer) Screenshot() *image.NRGBA {
+	return me.Target.ReadPixels()
+}
+
+// sync brings the scene Nodes up to date with the World: Nodes are made for
+// new entities and dropped for removed ones, and every Node gets its entity's
+// transform, interpolated alpha of the way from the previous one.
+func (me *Renderer) sync(w *ecs.World, selected ecs.Entity, alpha float32) {
+	for e, node := range me.nodes {
+		if !w.Has(e, ecs.ComponentTransform) {
+			node.Detach()
+			delete(me.nodes, e)
+		}
+	}
+	for e, key := range me.batchOf {
+		if want, ok := batchKeyOf(w, e); !ok || want != key {
+			me.leaveBatch(e)
+		}
+	}
+	for e, mod := range me.models {
+		if !w.Has(e, ecs.ComponentTransform|ecs.ComponentModel) {
+			mod.scene.Root.Detach()
+			delete(me.models, e)
+		}
+	}
+	for _, e := range w.Query(ecs.ComponentTransform) {
+		node, err := me.nodeFor(w, e)
+		if err != nil {
+			fmt.Printf("!! ERROR Renderer.Draw() err=%s\n", err)
+			continue
+		}
+		if r := node.Renderable; r != nil {
+			r.Material = nil
+			if mat, ok := w.Materials[e]; ok {
+				r.Material = me.material(mat.Path)
+				r.Color = tint(mat.Color, e == selected)
+			}
+		}
+		syncNode(node, w.Transforms[e], alpha)
+		if m, ok := w.Models[e]; ok {
+			me.syncModel(e, node, m)
+		}
+		if _, ok := me.batchOf[e]; !ok {
+			if key, ok := batchKeyOf(w, e); ok {
+				me.joinBatch(e, key)
+			}
+		}
+	}
+}
+
+// syncModel puts an Instance of the Model's scene beneath the entity's node
+// once the Assets have loaded it, and plays its Clip up to its Time. A
+// reloaded scene replaces the Instance.
+func (me *Renderer) syncModel(e ecs.Entity, node *helpers.Node, m *ecs.Model) {
+	source, loaded := me.Assets.Model(m.Path)
+	mod, ok := me.models[e]
+	if ok && (mod.path != m.Path || mod.source != source) {
+		mod.scene.Root.Detach()
+		delete(me.models, e)
+		ok = false
+	}
+	if !ok {
+		if !loaded {
+			// still loading; the game hears if it fails
+			return
+		}
+		mod = &model{path: m.Path, source: source, scene: source.Instance(), animator: helpers.NewAnimator(), version: -1}
+		node.AddChild(mod.scene.Root)
+		me.models[e] = mod
+	}
+	if mod.version != me.assetsVersion {
+		me.shadeModel(mod, m.Shader)
+	}
+
+	if m.Clip != mod.clip {
+		mod.clip = m.Clip
+		mod.time = 0
+		clip := mod.scene.Clip(m.Clip)
+		if clip == nil && m.Clip != "" {
+			fmt.Printf("!! ERROR Renderer.syncModel() %q has no animation %q\n", m.Path, m.Clip)
+		}
+		mod.animator.Play(clip, modelFade)
+	}
+	if m.Time < mod.time {
+		// started over
+		mod.time = 0
+	}
+	mod.animator.Tick(m.Time - mod.time)
+	mod.time = m.Time
+}
+
+// defaultGLTFMaterial is used for glTF primitives without a material
+var defaultGLTFMaterial = helpers.GLTFMaterial{BaseColor: mgl.Vec4{1, 1, 1, 1}, Roughness: 1}
+
+// shadeModel gives the model's Renderables Materials made with the named
+// shader, once it's loaded.
+func (me *Renderer) shadeModel(mod *model, shaderName string) {
+	shader, ok := me.Assets.Shader(shaderName)
+	if !ok {
+		return
+	}
+	materials := make(map[*helpers.GLTFMaterial]*helpers.Material)
+	mod.scene.Root.Walk(func(n *helpers.Node) bool {
+		r := n.Renderable
+		if r == nil {
+			return true
+		}
+		gm := mod.scene.Materials[r]
+		if gm == nil {
+			gm = &defaultGLTFMaterial
+		}
+		if materials[gm] == nil {
+			materials[gm] = gm.NewMaterial(shader)
+		}
+		r.Material = materials[gm]
+		return true
+	})
+	mod.version = me.assetsVersion
+}
+
+// nodeFor returns the scene Node backing the given entity, creating it, and
+// its mesh if it has one, on first use. It's kept beneath its Parent's Node.
+func (me *Renderer) nodeFor(w *ecs.World, e ecs.Entity) (*helpers.Node, error) {
+	node, ok := me.nodes[e]
+	if !ok {
+		node = helpers.NewNode(fmt.Sprintf("entity %d", e))
+		if mesh, ok := w.Meshes[e]; ok && !mesh.Instanced {
+			r, err := buildRenderable(mesh)
+			if err != nil {
+				return nil, err
+			}
+			node.Renderable = r
+		}
+		syncNode(node, w.Transforms[e], 1)
+		me.nodes[e] = node
+	}
+
+	parent := me.scene
+	if p := w.Transforms[e].Parent; w.Has(p, ecs.ComponentTransform) {
+		var err error
+		parent, err = me.nodeFor(w, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if node.Parent() != parent {
+		parent.AddChild(node)
+	}
+	return node, nil
+}
+
+// batchKeyOf is the batch the entity belongs in, if it has an Instanced Mesh
+// and a Material.
+func batchKeyOf(w *ecs.World, e ecs.Entity) (batchKey, bool) {
+	mesh, ok := w.Meshes[e]
+	if !ok || !mesh.Instanced || !w.Has(e, ecs.ComponentTransform|ecs.ComponentMaterial) {
+		return batchKey{}, false
+	}
+	return batchKey{mesh: *mesh, material: w.Materials[e].Path}, true
+}
+
+// joinBatch adds the entity to a batch, building the batch if it's new.
+func (me *Renderer) joinBatch(e ecs.Entity, key batchKey) {
+	b, ok := me.batches[key]
+	if !ok {
+		b = &batch{}
+		mesh := key.mesh
+		r, err := buildRenderable(&mesh)
+		if err != nil {
+			// leave it nil, so we don't keep trying
+			fmt.Printf("!! ERROR Renderer.joinBatch() err=%s\n", err)
+		} else {
+			b.mesh = helpers.NewInstancedMesh(r)
+		}
+		me.batches[key] = b
+		me.batchOrder = append(me.batchOrder, key)
+	}
+	b.members = append(b.members, e)
+	me.batchOf[e] = key
+}
+
+// leaveBatch takes the entity out of its batch, deleting the batch once it's
+// empty.
+func (me *Renderer) leaveBatch(e ecs.Entity) {
+	key := me.batchOf[e]
+	delete(me.batchOf, e)
+	b := me.batches[key]
+	for i, member := range b.members {
+		if member == e {
+			b.members = append(b.members[:i], b.members[i+1:]...)
+			break
+		}
+	}
+	if len(b.members) > 0 {
+		return
+	}
+	if b.mesh != nil {
+		b.mesh.Delete()
+	}
+	delete(me.batches, key)
+	for i, k := range me.batchOrder {
+		if k == key {
+			me.batchOrder = append(me.batchOrder[:i], me.batchOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// drawBatches draws the entities with Instanced Meshes, at their Nodes' world
+// transforms, which must be up to date.
+func (me *Renderer) drawBatches(w *ecs.World, selected ecs.Entity, perspective, view mgl.Mat4) helpers.DrawStats {
+	var stats helpers.DrawStats
+	for _, key := range me.batchOrder {
+		b := me.batches[key]
+		if b.mesh == nil {
+			continue
+		}
+		b.instances = b.instances[:0]
+		for _, e := range b.members {
+			b.instances = append(b.instances, helpers.Instance{
+				Model: me.nodes[e].WorldTransform(),
+				Color: tint(w.Materials[e].Color, e == selected),
+			})
+		}
+		b.mesh.Material = me.material(key.material)
+		b.mesh.SetInstances(b.instances)
+		stats.Add(b.mesh.Draw(perspective, view))
+	}
+	return stats
+}
+
+// selectedTint is multiplied into the color of the selected entity
+var selectedTint = mgl.Vec4{1.6, 1.4, 0.5, 1}
+
+// tint highlights color if it belongs to the selected entity.
+func tint(color mgl.Vec4, selected bool) mgl.Vec4 {
+	if !selected {
+		return color
+	}
+	for i := range color {
+		color[i] *= selectedTint[i]
+	}
+	return color
+}
+
+func buildRenderable(mesh *ecs.Mesh) (*helpers.Renderable, error) {
+	var r *helpers.Renderable
+	switch mesh.Kind {
+	case ecs.MeshCube:
+		min, max := mesh.Min, mesh.Max
+		r = helpers.CreateCube(min[0], min[1], min[2], max[0], max[1], max[2])
+	case ecs.MeshSphere:
+		r = helpers.CreateSphere(mesh.Radius, mesh.Rings, mesh.Sectors)
+	case ecs.MeshPlaneXZ:
+		min, max := mesh.Min, mesh.Max
+		r = helpers.CreatePlaneXZ(min[0], min[2], max[0], max[2], 1)
+	}
+	if r == nil {
+		return nil, fmt.Errorf("Renderer: can't build mesh %#v", *mesh)
+	}
+	return r, nil
+}
+
+// material returns the Material for the given material file, shared by every
+// Object that uses it, or nil if it or its shader or textures are still
+// loading. It's only looked up in the asset Manager again after
+// AssetsChanged, which picks up hot-reloaded assets.
+func (me *Renderer) material(path string) *helpers.Material {
+	mat := me.materials[path]
+	if mat == nil {
+		mat = &material{version: -1}
+		me.materials[path] = mat
+	}
+	if mat.version == me.assetsVersion {
+		return mat.m
+	}
+	mat.version = me.assetsVersion
+
+	def, ok := me.Assets.Material(path)
+	if !ok {
+		return mat.m
+	}
+	shader, ok := me.Assets.Shader(def.Shader)
+	if !ok {
+		return mat.m
+	}
+	textures := make(map[string]uint32, len(def.Textures))
+	for _, texPath := range def.TexturePaths() {
+		tex, ok := me.Assets.Texture(texPath)
+		if !ok {
+			return mat.m
+		}
+		textures[texPath] = tex
+	}
+
+	if mat.m == nil {
+		mat.m = def.Build(shader, textures)
+	} else if mat.def != def || mat.shader != shader || !sameTextures(mat.textures, textures) {
+		def.Apply(mat.m, shader, textures)
+	}
+	if mat.shader != shader {
+		shader.BindUniformBlock(lighting.BlockName, lightsBinding)
+	}
+	mat.def, mat.shader, mat.textures = def, shader, textures
+	return mat.m
+}
+
+func sameTextures(a, b map[string]uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, tex := range a {
+		if b[path] != tex {
+			return false
+		}
+	}
+	return true
+}
+
+// syncNode copies the entity's simulated transform onto its Node, interpolating
+// alpha of the way from its previous transform. The Node is only marked dirty if it moved.
+func syncNode(node *helpers.Node, t *ecs.Transform, alpha float32) {
+	setTransform(node, helpers.Positioner{
+		Scale:         t.Scale,
+		Location:      lerpVec3(t.PrevLocation, t.Location, alpha),
+		Rotation:      nlerpQuat(t.PrevRotation, t.Rotation, alpha),
+		LocalRotation: nlerpQuat(t.PrevLocalRotation, t.LocalRotation, alpha),
+	})
+}
+
+func setTransform(node *helpers.Node, p helpers.Positioner) {
+	if node.Location == p.Location && node.Scale == p.Scale &&
+		node.Rotation == p.Rotation && node.LocalRotation == p.LocalRotation {
+		return
+	}
+	node.Location = p.Location
+	node.Scale = p.Scale
+	node.Rotation = p.Rotation
+	node.LocalRotation = p.LocalRotation
+	node.SetDirty()
+}
+
+// nlerpQuat blends along the shortest arc (q and -q are the same rotation)
+func nlerpQuat(from, to mgl.Quat, alpha float32) mgl.Quat {
+	if from.Dot(to) < 0 {
+		from = from.Scale(-1)
+	}
+	return mgl.QuatNlerp(from, to, alpha)
+}
+
+func lerpVec3(from, to mgl.Vec3, alpha float32) mgl.Vec3 {
+	return from.Add(to.Sub(from).Mul(alpha))
+}
+
+func (me *Renderer) drawText(s *game.State, perspective, view mgl.Mat4) {
+	labels := s.World.Query(ecs.ComponentTransform | ecs.ComponentLabel)
+	if len(labels) == 0 {
+		return
+	}
+	font, ok := me.Assets.Font(s.FontFile, s.FontSize)
+	if !ok {
+		return
+	}
+	gl.Disable(gl.CULL_FACE) // glfont seems to do backward triangles?
+
+	x := float32(0)
+	y := float32(45)
+	scale := float32(1)
+	//set color and draw text
+	font.SetColor(1.0, 1.0, 1.0, 1.0) //r,g,b,a font color
+
+	for _, e := range labels {
+		node, ok := me.nodes[e]
+		if !ok {
+			// not synced yet, or its node couldn't be built
+			continue
+		}
+		transmat := perspective.Mul4(view).Mul4(node.WorldTransform())
+		// transmat = mgl.Ident4()
+
+		font.Tprintf(x, y, scale, transmat, "%s", s.World.Labels[e].Text) //x,y,scale,string,printf args
+	}
+	// font.Printf(x, y, scale, "Hello World") //x,y,scale,string,printf args
+
+	gl.Enable(gl.CULL_FACE)
+}
@@ -1,30 +1,87 @@
 #version 330
 
 precision highp float;
-uniform sampler2D MATERIAL_TEX_0;
 
-uniform mat4 MV_MATRIX;
-uniform mat4 MVP_MATRIX;
+// See package lighting for the layout
+#define MAX_LIGHTS 8
+#define POINT_LIGHT 0
+#define DIRECTIONAL_LIGHT 1
+#define SPOT_LIGHT 2
+
+struct Light {
+  vec4 position;    // xyz, w = type
+  vec4 direction;
+  vec4 color;
+  vec4 attenuation; // constant, linear, quadratic
+  vec4 cone;        // cos(inner), cos(outer)
+};
+
+layout(std140) uniform Lights {
+  vec4 AMBIENT;
+  vec4 EYE_POSITION;
+  ivec4 LIGHT_COUNT;
+  Light LIGHTS[MAX_LIGHTS];
+};
+
+uniform sampler2D MATERIAL_TEX_0;
 uniform vec4 MATERIAL_DIFFUSE;
+uniform vec3 MATERIAL_SPECULAR;
+uniform float MATERIAL_SHININESS;
 
-in vec3 vs_position;
-in vec3 vs_eye_normal;
+in vec3 vs_world_position;
+in vec3 vs_world_normal;
 in vec2 vs_uv_0;
+in vec4 vs_color;
 
 out vec4 frag_color;
 
-void main()
+// blinnPhong returns the diffuse and specular light reaching this fragment from one light
+void blinnPhong(Light light, vec3 normal, vec3 toEye, out vec3 diffuse, out vec3 specular)
 {
-  vec4 LIGHT_POSITION = vec4(-100.0, 100.0, 100.0, 1.0);
-  vec4 LIGHT_DIFFUSE = vec4(1.0);
+  diffuse = vec3(0.0);
+  specular = vec3(0.0);
 
-  vec4 eye_vertex = MV_MATRIX * vec4(vs_position, 1.0);
-  vec3 s = normalize(vec3(LIGHT_POSITION-eye_vertex));
-  float diffuseIntensity = max(dot(s,vs_eye_normal), 0.0);
+  int kind = int(light.position.w);
+  vec3 toLight;
+  float falloff = 1.0;
+  if (kind == DIRECTIONAL_LIGHT) {
+    toLight = -normalize(light.direction.xyz);
+  } else {
+    vec3 offset = light.position.xyz - vs_world_position;
+    float dist = length(offset);
+    toLight = offset / dist;
+    falloff = 1.0 / (light.attenuation.x + light.attenuation.y * dist + light.attenuation.z * dist * dist);
+    if (kind == SPOT_LIGHT) {
+      float theta = dot(-toLight, normalize(light.direction.xyz));
+      falloff *= smoothstep(light.cone.y, light.cone.x, theta);
+    }
+  }
 
-  vec4 ambient = vec4(0.1,0.1,0.1,0.1);
+  float lambert = max(dot(normal, toLight), 0.0);
+  if (lambert <= 0.0) {
+    return;
+  }
+  diffuse = light.color.rgb * lambert * falloff;
+
+  vec3 halfway = normalize(toLight + toEye);
+  float spec = pow(max(dot(normal, halfway), 0.0), max(MATERIAL_SHININESS, 1.0));
+  specular = light.color.rgb * spec * falloff;
+}
+
+void main()
+{
+  vec3 normal = normalize(vs_world_normal);
+  vec3 toEye = normalize(EYE_POSITION.xyz - vs_world_position);
 
+  vec3 diffuse = vec3(0.0);
+  vec3 specular = vec3(0.0);
+  for (int i = 0; i < LIGHT_COUNT.x && i < MAX_LIGHTS; i++) {
+    vec3 d, s;
+    blinnPhong(LIGHTS[i], normal, toEye, d, s);
+    diffuse += d;
+    specular += s;
+  }
 
-  frag_color = MATERIAL_DIFFUSE * texture(MATERIAL_TEX_0, vs_uv_0) * diffuseIntensity;
-  frag_color = frag_color + ambient;
+  vec4 base = MATERIAL_DIFFUSE * vs_color * texture(MATERIAL_TEX_0, vs_uv_0);
+  frag_color = vec4(base.rgb * (AMBIENT.rgb + diffuse) + MATERIAL_SPECULAR * specular, base.a);
 }
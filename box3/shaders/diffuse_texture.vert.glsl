@@ -2,19 +2,54 @@
 precision highp float;
 
 uniform mat4 MVP_MATRIX;
-uniform mat4 MV_MATRIX;
+uniform mat4 MODEL_MATRIX;
+uniform mat3 NORMAL_MATRIX;
 in vec3 VERTEX_POSITION;
 in vec3 VERTEX_NORMAL;
 in vec2 VERTEX_UV_0;
 
-out vec3 vs_position;
-out vec3 vs_eye_normal;
+// when INSTANCED, the model matrix and a color come per instance instead
+uniform bool INSTANCED;
+uniform mat4 VP_MATRIX;
+in mat4 INSTANCE_MODEL;
+in vec4 INSTANCE_COLOR;
+
+// when SKINNED, each vertex is moved by up to four joints of the palette
+const int MAX_JOINTS = 64;
+uniform bool SKINNED;
+uniform mat4 JOINT_MATRICES[MAX_JOINTS];
+in vec4 VERTEX_JOINTS;
+in vec4 VERTEX_WEIGHTS;
+
+out vec3 vs_world_position;
+out vec3 vs_world_normal;
 out vec2 vs_uv_0;
+out vec4 vs_color;
 
 void main()
 {
-  vs_position = VERTEX_POSITION;
   vs_uv_0 = VERTEX_UV_0;
-  vs_eye_normal = normalize(mat3(MV_MATRIX) * VERTEX_NORMAL);
-  gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
+  if (INSTANCED) {
+    vec4 world = INSTANCE_MODEL * vec4(VERTEX_POSITION, 1.0);
+    vs_world_position = vec3(world);
+    vs_world_normal = normalize(transpose(inverse(mat3(INSTANCE_MODEL))) * VERTEX_NORMAL);
+    vs_color = INSTANCE_COLOR;
+    gl_Position = VP_MATRIX * world;
+  } else {
+    vec4 position = vec4(VERTEX_POSITION, 1.0);
+    vec3 normal = VERTEX_NORMAL;
+    if (SKINNED) {
+      mat4 skin =
+        VERTEX_WEIGHTS.x * JOINT_MATRICES[int(VERTEX_JOINTS.x)] +
+        VERTEX_WEIGHTS.y * JOINT_MATRICES[int(VERTEX_JOINTS.y)] +
+        VERTEX_WEIGHTS.z * JOINT_MATRICES[int(VERTEX_JOINTS.z)] +
+        VERTEX_WEIGHTS.w * JOINT_MATRICES[int(VERTEX_JOINTS.w)];
+      position = skin * position;
+      normal = mat3(skin) * normal;
+    }
+    vs_world_position = vec3(MODEL_MATRIX * position);
+    vs_world_normal = normalize(NORMAL_MATRIX * normal);
+    vs_color = vec4(1.0);
+    gl_Position = MVP_MATRIX * position;
+  }
 }
@@ -0,0 +1,43 @@
+//go:build golden
+// +build golden
+
+package main
+
+import (
+	"testing"
+
+	"github.com/dcrosby42/go-game-sandbox/golden"
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+)
+
+func TestGoldenGrid(t *testing.T) {
+	golden.Context(t, width, height)
+	grid := newGrid(initOpenGL())
+
+	// a fixed starting pattern instead of makeCells' random one
+	cells := makeCells()
+	for x := range cells {
+		for y, c := range cells[x] {
+			c.alive = (x*7+y*13)%5 == 0 || (x/10+y/10)%3 == 0
+			c.aliveNext = c.alive
+		}
+	}
+	for gen := 0; gen < 10; gen++ {
+		for r := range cells {
+			for _, c := range cells[r] {
+				c.checkState(cells)
+			}
+		}
+	}
+
+	target, err := helpers.NewRenderTarget(width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	target.Bind()
+	drawCells(cells, grid)
+	target.Unbind()
+
+	golden.Check(t, "grid", target.ReadPixels(), golden.DefaultTolerance)
+}
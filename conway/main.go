@@ -10,9 +10,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dcrosby42/go-game-sandbox/helpers"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	_ "github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
+	mgl "github.com/go-gl/mathgl/mgl32"
 )
 
 const (
@@ -26,18 +28,6 @@ const (
 	threshold = 0.45
 )
 
-var (
-	square = []float32{
-		-0.5, 0.5, 0, // top-left
-		-0.5, -0.5, 0, // bot-left
-		0.5, -0.5, 0, // bot-right
-
-		-0.5, 0.5, 0, // top-left
-		0.5, -0.5, 0, // bot-right
-		0.5, 0.5, 0, // top-right
-	}
-)
-
 func main() {
 	runtime.LockOSThread()
 
@@ -45,8 +35,8 @@ func main() {
 	defer glfw.Terminate()
 
 	program := initOpenGL()
+	grid := newGrid(program)
 
-	// vao := makeVao(square)
 	cells := makeCells()
 
 	for !window.ShouldClose() {
@@ -58,7 +48,7 @@ func main() {
 			}
 		}
 
-		draw(cells, window, program)
+		draw(cells, window, grid)
 
 		time.Sleep(time.Second/time.Duration(fps) - time.Since(t))
 	}
@@ -109,47 +99,56 @@ func initOpenGL() uint32 {
 	return prog
 }
 
-func draw(cells [][]*Cell, window *glfw.Window, program uint32) {
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-	gl.UseProgram(program)
-
-	for x := range cells {
-		for _, c := range cells[x] {
-			c.Draw()
-		}
-	}
+func draw(cells [][]*Cell, window *glfw.Window, grid *helpers.InstancedMesh) {
+	drawCells(cells, grid)
 
 	glfw.PollEvents()
 	window.SwapBuffers()
 }
 
-func makeVao(points []float32) uint32 {
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, 4*len(points), gl.Ptr(points), gl.STATIC_DRAW)
+// newGrid makes the mesh the live cells are drawn with: one unit square,
+// instanced at each live cell.
+func newGrid(program uint32) *helpers.InstancedMesh {
+	material := helpers.NewMaterial(helpers.NewShaderProgram(program))
+	material.CullFace = 0
+	material.DepthTest = false
 
-	var vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
-	gl.EnableVertexAttribArray(0)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 0, nil)
+	grid := helpers.NewInstancedMesh(helpers.CreatePlaneXY(0, 0, 1, 1, 1))
+	grid.Material = material
+	return grid
+}
 
-	return vao
+// drawCells renders the grid into the current framebuffer, with a single draw call.
+func drawCells(cells [][]*Cell, grid *helpers.InstancedMesh) {
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	var live []helpers.Instance
+	for x := range cells {
+		for _, c := range cells[x] {
+			if c.alive {
+				live = append(live, helpers.Instance{Model: c.model, Color: mgl.Vec4{1, 1, 1, 1}})
+			}
+		}
+	}
+	grid.SetInstances(live)
+
+	// cells are placed directly in clip space
+	grid.Draw(mgl.Ident4(), mgl.Ident4())
 }
 
 const (
 	vertexShaderSource = `
-    #version 410
-    in vec3 vp;
+    #version 330 core
+    uniform mat4 VP_MATRIX;
+    in vec3 VERTEX_POSITION;
+    in mat4 INSTANCE_MODEL;
     void main() {
-        gl_Position = vec4(vp, 1.0);
+        gl_Position = VP_MATRIX * INSTANCE_MODEL * vec4(VERTEX_POSITION, 1.0);
     }
 ` + "\x00"
 
 	fragmentShaderSource = `
-    #version 410
+    #version 330 core
     out vec4 frag_colour;
     void main() {
         frag_colour = vec4(1, 1, 1, 1);
@@ -181,20 +180,11 @@ func compileShader(source string, shaderType uint32) (uint32, error) {
 }
 
 type Cell struct {
-	drawable         uint32
-	numPts           int32
+	model            mgl.Mat4 // places the unit square over the cell, in clip space
 	x, y             int
 	alive, aliveNext bool
 }
 
-func (me Cell) Draw() {
-	if !me.alive {
-		return
-	}
-	gl.BindVertexArray(me.drawable)
-	gl.DrawArrays(gl.TRIANGLES, 0, me.numPts) //XXX int32(len(square)/3))
-}
-
 func makeCells() [][]*Cell {
 	cells := make([][]*Cell, rows, rows)
 	for x := 0; x < rows; x++ {
@@ -210,35 +200,12 @@ func makeCells() [][]*Cell {
 }
 
 func newCell(x, y int) *Cell {
-	points := make([]float32, len(square), len(square))
-	copy(points, square)
-
-	for i := 0; i < len(points); i++ {
-		var position float32
-		var size float32
-		switch i % 3 {
-		case 0:
-			size = 1.0 / float32(columns)
-			position = float32(x) * size
-		case 1:
-			size = 1.0 / float32(rows)
-			position = float32(y) * size
-		default:
-			continue
-		}
-
-		if points[i] < 0 {
-			points[i] = (position * 2) - 1
-		} else {
-			points[i] = ((position + size) * 2) - 1
-		}
-	}
-
+	w := 2 / float32(columns)
+	h := 2 / float32(rows)
 	return &Cell{
-		drawable: makeVao(points),
-		numPts:   int32(len(square) / 3),
-		x:        x,
-		y:        y,
+		model: mgl.Translate3D(float32(x)*w-1, float32(y)*h-1, 0).Mul4(mgl.Scale3D(w, h, 1)),
+		x:     x,
+		y:     y,
 	}
 }
 
@@ -0,0 +1,121 @@
+// Package geom has the bounding volumes and intersection tests shared by the
+// game logic and the renderer. It makes no GL calls.
+package geom
+
+import mgl "github.com/go-gl/mathgl/mgl32"
+
+// epsilon keeps boxes that merely touch from counting as overlapping
+const epsilon = 1e-4
+
+// AABB is an axis-aligned bounding box.
+type AABB struct {
+	Min, Max mgl.Vec3
+}
+
+// BoxAround makes the AABB centered on center reaching half out along each axis.
+func BoxAround(center, half mgl.Vec3) AABB {
+	return AABB{Min: center.Sub(half), Max: center.Add(half)}
+}
+
+func (me AABB) Center() mgl.Vec3 {
+	return me.Min.Add(me.Max).Mul(0.5)
+}
+
+func (me AABB) Size() mgl.Vec3 {
+	return me.Max.Sub(me.Min)
+}
+
+func (me AABB) Translate(v mgl.Vec3) AABB {
+	return AABB{Min: me.Min.Add(v), Max: me.Max.Add(v)}
+}
+
+// Union is the smallest AABB containing both.
+func (me AABB) Union(other AABB) AABB {
+	var u AABB
+	for i := 0; i < 3; i++ {
+		u.Min[i] = min32(me.Min[i], other.Min[i])
+		u.Max[i] = max32(me.Max[i], other.Max[i])
+	}
+	return u
+}
+
+func (me AABB) Contains(p mgl.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		if p[i] < me.Min[i] || p[i] > me.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects reports whether the boxes overlap by more than a hair; boxes
+// that only touch don't intersect.
+func (me AABB) Intersects(other AABB) bool {
+	for i := 0; i < 3; i++ {
+		if !me.overlaps(other, i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (me AABB) overlaps(other AABB, axis int) bool {
+	return me.Min[axis] < other.Max[axis]-epsilon && me.Max[axis] > other.Min[axis]+epsilon
+}
+
+// Transform returns the AABB enclosing this one after it's been transformed by m.
+func (me AABB) Transform(m mgl.Mat4) AABB {
+	center := m.Mul4x1(me.Center().Vec4(1)).Vec3()
+	half := me.Size().Mul(0.5)
+	var extent mgl.Vec3
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			extent[row] += abs32(m.At(row, col)) * half[col]
+		}
+	}
+	return BoxAround(center, extent)
+}
+
+// Sweep moves the box by d along one axis (0, 1, 2 for X, Y, Z) and returns
+// how far it can actually go before running into any of the solids. A box
+// already overlapping a solid isn't stopped by it, so it can get back out.
+// The result is never in the opposite direction to d; a box sunk a hair into
+// a solid stays put rather than being pushed back out.
+func (me AABB) Sweep(axis int, d float32, solids []AABB) float32 {
+	if d == 0 {
+		return 0
+	}
+	a1, a2 := (axis+1)%3, (axis+2)%3
+	for _, s := range solids {
+		if !me.overlaps(s, a1) || !me.overlaps(s, a2) {
+			continue
+		}
+		if d > 0 && me.Max[axis] <= s.Min[axis]+epsilon {
+			d = min32(d, max32(0, s.Min[axis]-me.Max[axis]))
+		} else if d < 0 && me.Min[axis] >= s.Max[axis]-epsilon {
+			d = max32(d, min32(0, s.Max[axis]-me.Min[axis]))
+		}
+	}
+	return d
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func abs32(a float32) float32 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,70 @@
+package geom
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSweep(t *testing.T) {
+	unit := AABB{Min: mgl.Vec3{0, 0, 0}, Max: mgl.Vec3{1, 1, 1}}
+	wall := AABB{Min: mgl.Vec3{3, 0, 0}, Max: mgl.Vec3{4, 1, 1}}
+	floor := AABB{Min: mgl.Vec3{-10, -1, -10}, Max: mgl.Vec3{10, 0, 10}}
+
+	for _, tc := range []struct {
+		name   string
+		box    AABB
+		axis   int
+		d      float32
+		solids []AABB
+		want   float32
+	}{
+		{"nothing in the way", unit, 0, 5, nil, 5},
+		{"no move", unit, 0, 0, []AABB{wall}, 0},
+		{"short of the wall", unit, 0, 1.5, []AABB{wall}, 1.5},
+		{"blocked by the wall", unit, 0, 5, []AABB{wall}, 2},
+		{"moving away from the wall", unit, 0, -5, []AABB{wall}, -5},
+		{"nearest of two", unit, 0, 5, []AABB{wall.Translate(mgl.Vec3{-1, 0, 0}), wall}, 1},
+		{"wall off to the side", unit.Translate(mgl.Vec3{0, 2, 0}), 0, 5, []AABB{wall}, 5},
+		{"wall only touching the side", unit.Translate(mgl.Vec3{0, 1, 0}), 0, 5, []AABB{wall}, 5},
+		{"touching the wall", unit.Translate(mgl.Vec3{2, 0, 0}), 0, 1, []AABB{wall}, 0},
+		{"a hair into the wall", unit.Translate(mgl.Vec3{2 + epsilon/2, 0, 0}), 0, 1, []AABB{wall}, 0},
+		{"a hair into the wall, backing off", unit.Translate(mgl.Vec3{2 + epsilon/2, 0, 0}), 0, -1, []AABB{wall}, -1},
+		{"overlapping the wall", unit.Translate(mgl.Vec3{2.5, 0, 0}), 0, 1, []AABB{wall}, 1},
+		{"inside the wall", unit.Translate(mgl.Vec3{3, 0, 0}), 0, -1, []AABB{wall}, -1},
+		{"falling onto the floor", unit.Translate(mgl.Vec3{0, 2, 0}), 1, -5, []AABB{floor}, -2},
+		{"standing on the floor", unit, 1, -1, []AABB{floor}, 0},
+		{"a hair into the floor", unit.Translate(mgl.Vec3{0, -epsilon / 2, 0}), 1, -1, []AABB{floor}, 0},
+		{"jumping off the floor", unit, 1, 1, []AABB{floor}, 1},
+	} {
+		got := tc.box.Sweep(tc.axis, tc.d, tc.solids)
+		if got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		if got*tc.d < 0 {
+			t.Errorf("%s: moved %v, against the direction %v", tc.name, got, tc.d)
+		}
+	}
+}
+
+func TestIntersects(t *testing.T) {
+	a := AABB{Min: mgl.Vec3{0, 0, 0}, Max: mgl.Vec3{1, 1, 1}}
+	for _, tc := range []struct {
+		name  string
+		other AABB
+		want  bool
+	}{
+		{"itself", a, true},
+		{"overlapping", a.Translate(mgl.Vec3{0.5, 0.5, 0.5}), true},
+		{"inside", AABB{Min: mgl.Vec3{0.25, 0.25, 0.25}, Max: mgl.Vec3{0.75, 0.75, 0.75}}, true},
+		{"touching", a.Translate(mgl.Vec3{1, 0, 0}), false},
+		{"apart", a.Translate(mgl.Vec3{0, 0, 2}), false},
+	} {
+		if got := a.Intersects(tc.other); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		if got := tc.other.Intersects(a); got != tc.want {
+			t.Errorf("%s, the other way: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package geom
+
+import mgl "github.com/go-gl/mathgl/mgl32"
+
+// Sphere is a bounding sphere.
+type Sphere struct {
+	Center mgl.Vec3
+	Radius float32
+}
+
+// SphereAround is the sphere through the corners of the box.
+func SphereAround(box AABB) Sphere {
+	return Sphere{Center: box.Center(), Radius: box.Size().Len() / 2}
+}
+
+// Transform returns the sphere enclosing this one after it's been transformed
+// by m, which is assumed to be affine.
+func (me Sphere) Transform(m mgl.Mat4) Sphere {
+	scale := float32(0)
+	for col := 0; col < 3; col++ {
+		if s := m.Col(col).Vec3().Len(); s > scale {
+			scale = s
+		}
+	}
+	return Sphere{
+		Center: m.Mul4x1(me.Center.Vec4(1)).Vec3(),
+		Radius: me.Radius * scale,
+	}
+}
+
+// Plane is the points p where Normal.Dot(p) + D == 0. Normal points to the
+// inside (positive) side.
+type Plane struct {
+	Normal mgl.Vec3
+	D      float32
+}
+
+func (me Plane) Distance(p mgl.Vec3) float32 {
+	return me.Normal.Dot(p) + me.D
+}
+
+// Frustum is the volume a camera can see, as six planes facing inward: left,
+// right, bottom, top, near, far.
+type Frustum [6]Plane
+
+// FrustumOf extracts the frustum from a projection * view matrix (the
+// Gribb/Hartmann method), in world space.
+func FrustumOf(m mgl.Mat4) Frustum {
+	r0, r1, r2, r3 := m.Row(0), m.Row(1), m.Row(2), m.Row(3)
+	rows := [6]mgl.Vec4{
+		r3.Add(r0), r3.Sub(r0),
+		r3.Add(r1), r3.Sub(r1),
+		r3.Add(r2), r3.Sub(r2),
+	}
+	var f Frustum
+	for i, row := range rows {
+		n := row.Vec3()
+		l := n.Len()
+		f[i] = Plane{Normal: n.Mul(1 / l), D: row[3] / l}
+	}
+	return f
+}
+
+// IntersectsSphere reports whether any of the sphere might be visible.
+func (me *Frustum) IntersectsSphere(s Sphere) bool {
+	for _, p := range me {
+		if p.Distance(s.Center) < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsAABB reports whether any of the box might be visible. It can give
+// false positives for big boxes near the frustum's corners, which is fine for
+// culling.
+func (me *Frustum) IntersectsAABB(box AABB) bool {
+	for _, p := range me {
+		// the corner furthest along the plane's normal
+		var v mgl.Vec3
+		for i := 0; i < 3; i++ {
+			if p.Normal[i] >= 0 {
+				v[i] = box.Max[i]
+			} else {
+				v[i] = box.Min[i]
+			}
+		}
+		if p.Distance(v) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package geom
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// testFrustum is a camera at (0,0,5) looking toward the origin with a 90
+// degree field of view, seeing from 1 to 20 away.
+func testFrustum() Frustum {
+	projection := mgl.Perspective(mgl.DegToRad(90), 1, 1, 20)
+	view := mgl.LookAtV(mgl.Vec3{0, 0, 5}, mgl.Vec3{0, 0, 0}, mgl.Vec3{0, 1, 0})
+	return FrustumOf(projection.Mul4(view))
+}
+
+func TestFrustumOf(t *testing.T) {
+	f := testFrustum()
+	for i, p := range f {
+		if l := p.Normal.Len(); abs32(l-1) > 1e-5 {
+			t.Errorf("plane %d: normal %v isn't unit length", i, p.Normal)
+		}
+	}
+	// the near and far planes are 1 and 20 in front of the eye
+	if d := f[4].Distance(mgl.Vec3{0, 0, 4}); abs32(d) > 1e-4 {
+		t.Errorf("near plane is %v from z=4, want 0", d)
+	}
+	if d := f[5].Distance(mgl.Vec3{0, 0, -15}); abs32(d) > 1e-3 {
+		t.Errorf("far plane is %v from z=-15, want 0", d)
+	}
+	// every plane faces the middle of the view
+	for i, p := range f {
+		if d := p.Distance(mgl.Vec3{0, 0, -5}); d <= 0 {
+			t.Errorf("plane %d: the middle of the view is at %v, want it inside", i, d)
+		}
+	}
+}
+
+func TestFrustumIntersectsSphere(t *testing.T) {
+	f := testFrustum()
+	for _, tc := range []struct {
+		name   string
+		sphere Sphere
+		want   bool
+	}{
+		{"in front", Sphere{mgl.Vec3{0, 0, 0}, 0.5}, true},
+		{"off to the side in front", Sphere{mgl.Vec3{3, -3, 0}, 0.5}, true},
+		{"behind", Sphere{mgl.Vec3{0, 0, 8}, 0.5}, false},
+		{"behind, reaching past the near plane", Sphere{mgl.Vec3{0, 0, 6}, 2.5}, true},
+		{"between the eye and the near plane", Sphere{mgl.Vec3{0, 0, 4.5}, 0.1}, false},
+		{"beyond the far plane", Sphere{mgl.Vec3{0, 0, -20}, 1}, false},
+		{"out to the left", Sphere{mgl.Vec3{-10, 0, 0}, 1}, false},
+		{"straddling the left edge", Sphere{mgl.Vec3{-5.5, 0, 0}, 1}, true},
+		{"above", Sphere{mgl.Vec3{0, 10, 0}, 1}, false},
+		{"around the eye", Sphere{mgl.Vec3{0, 0, 5}, 100}, true},
+	} {
+		if got := f.IntersectsSphere(tc.sphere); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFrustumIntersectsAABB(t *testing.T) {
+	f := testFrustum()
+	unit := mgl.Vec3{0.5, 0.5, 0.5}
+	for _, tc := range []struct {
+		name string
+		box  AABB
+		want bool
+	}{
+		{"in front", BoxAround(mgl.Vec3{0, 0, 0}, unit), true},
+		{"behind", BoxAround(mgl.Vec3{0, 0, 8}, unit), false},
+		{"beyond the far plane", BoxAround(mgl.Vec3{0, 0, -20}, unit), false},
+		{"below", BoxAround(mgl.Vec3{0, -10, 0}, unit), false},
+		{"out to the right", BoxAround(mgl.Vec3{10, 0, 0}, unit), false},
+		{"straddling the right edge", BoxAround(mgl.Vec3{5.2, 0, 0}, unit), true},
+		{"a floor under everything", AABB{Min: mgl.Vec3{-50, -1, -50}, Max: mgl.Vec3{50, 0, 50}}, true},
+		{"containing the frustum", BoxAround(mgl.Vec3{}, mgl.Vec3{100, 100, 100}), true},
+	} {
+		if got := f.IntersectsAABB(tc.box); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSphereTransform(t *testing.T) {
+	s := SphereAround(AABB{Min: mgl.Vec3{-1, -1, -1}, Max: mgl.Vec3{1, 1, 1}})
+	if abs32(s.Radius*s.Radius-3) > 1e-5 {
+		t.Errorf("got radius %v, want sqrt(3)", s.Radius)
+	}
+	moved := s.Transform(mgl.Translate3D(1, 2, 3).Mul4(mgl.Scale3D(1, 3, 2)))
+	if moved.Center != (mgl.Vec3{1, 2, 3}) {
+		t.Errorf("got center %v, want {1,2,3}", moved.Center)
+	}
+	if abs32(moved.Radius-3*s.Radius) > 1e-5 {
+		t.Errorf("got radius %v, want it scaled by the largest scale, 3", moved.Radius)
+	}
+}
@@ -0,0 +1,145 @@
+package geom
+
+import (
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Ray starts at Origin and heads off along Dir. Dir needn't be unit length;
+// distances along the ray are measured in multiples of it.
+type Ray struct {
+	Origin, Dir mgl.Vec3
+}
+
+// Hit is where a ray struck something.
+type Hit struct {
+	Distance float32
+	Point    mgl.Vec3
+	Normal   mgl.Vec3
+}
+
+func (me Ray) At(t float32) mgl.Vec3 {
+	return me.Origin.Add(me.Dir.Mul(t))
+}
+
+// Unproject makes the ray from the eye through the point (x, y) on screen, in
+// normalized device coordinates where (-1,-1) is the lower-left corner and
+// (1,1) the upper-right. The ray starts on the near plane and its Dir is unit
+// length.
+func Unproject(x, y float32, projection, view mgl.Mat4) Ray {
+	inv := projection.Mul4(view).Inv()
+	near := inv.Mul4x1(mgl.Vec4{x, y, -1, 1})
+	far := inv.Mul4x1(mgl.Vec4{x, y, 1, 1})
+	origin := near.Vec3().Mul(1 / near[3])
+	dir := far.Vec3().Mul(1 / far[3]).Sub(origin).Normalize()
+	return Ray{Origin: origin, Dir: dir}
+}
+
+// Transform moves the ray by m, eg into an object's model space. Dir is not
+// renormalized, so distances along the transformed ray match the original.
+func (me Ray) Transform(m mgl.Mat4) Ray {
+	return Ray{
+		Origin: m.Mul4x1(me.Origin.Vec4(1)).Vec3(),
+		Dir:    m.Mul4x1(me.Dir.Vec4(0)).Vec3(),
+	}
+}
+
+// IntersectAABB finds where the ray enters the box. A ray starting inside the
+// box hits it at distance 0.
+func (me Ray) IntersectAABB(box AABB) (Hit, bool) {
+	tmin := float32(0)
+	tmax := float32(math.MaxFloat32)
+	axis, sign := -1, float32(0)
+	for i := 0; i < 3; i++ {
+		if me.Dir[i] == 0 {
+			if me.Origin[i] < box.Min[i] || me.Origin[i] > box.Max[i] {
+				return Hit{}, false
+			}
+			continue
+		}
+		t1 := (box.Min[i] - me.Origin[i]) / me.Dir[i]
+		t2 := (box.Max[i] - me.Origin[i]) / me.Dir[i]
+		s := float32(-1) // entering through the Min face
+		if t1 > t2 {
+			t1, t2 = t2, t1
+			s = 1
+		}
+		if t1 > tmin {
+			tmin, axis, sign = t1, i, s
+		}
+		if t2 < tmax {
+			tmax = t2
+		}
+		if tmin > tmax {
+			return Hit{}, false
+		}
+	}
+	hit := Hit{Distance: tmin, Point: me.At(tmin)}
+	if axis >= 0 {
+		hit.Normal[axis] = sign
+	} else {
+		hit.Normal = me.Dir.Normalize().Mul(-1)
+	}
+	return hit, true
+}
+
+// IntersectSphere finds where the ray enters the sphere.
+func (me Ray) IntersectSphere(center mgl.Vec3, radius float32) (Hit, bool) {
+	oc := me.Origin.Sub(center)
+	a := me.Dir.Dot(me.Dir)
+	b := oc.Dot(me.Dir)
+	c := oc.Dot(oc) - radius*radius
+	disc := b*b - a*c
+	if a == 0 || disc < 0 {
+		return Hit{}, false
+	}
+	sq := float32(math.Sqrt(float64(disc)))
+	t := (-b - sq) / a
+	if t < 0 {
+		if c > 0 {
+			return Hit{}, false // sphere is behind us
+		}
+		t = 0 // we're inside it
+	}
+	hit := Hit{Distance: t, Point: me.At(t)}
+	hit.Normal = hit.Point.Sub(center)
+	if hit.Normal.Len() == 0 {
+		hit.Normal = me.Dir.Mul(-1)
+	}
+	hit.Normal = hit.Normal.Normalize()
+	return hit, true
+}
+
+// IntersectTriangle finds where the ray crosses the triangle abc, from either
+// side (Möller-Trumbore). The Normal faces back toward the ray.
+func (me Ray) IntersectTriangle(a, b, c mgl.Vec3) (Hit, bool) {
+	const eps = 1e-7
+	e1 := b.Sub(a)
+	e2 := c.Sub(a)
+	p := me.Dir.Cross(e2)
+	det := e1.Dot(p)
+	if det > -eps && det < eps {
+		return Hit{}, false // parallel
+	}
+	inv := 1 / det
+	s := me.Origin.Sub(a)
+	u := s.Dot(p) * inv
+	if u < 0 || u > 1 {
+		return Hit{}, false
+	}
+	q := s.Cross(e1)
+	v := me.Dir.Dot(q) * inv
+	if v < 0 || u+v > 1 {
+		return Hit{}, false
+	}
+	t := e2.Dot(q) * inv
+	if t < 0 {
+		return Hit{}, false
+	}
+	n := e1.Cross(e2).Normalize()
+	if n.Dot(me.Dir) > 0 {
+		n = n.Mul(-1)
+	}
+	return Hit{Distance: t, Point: me.At(t), Normal: n}, true
+}
@@ -0,0 +1,167 @@
+package geom
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func near(a, b mgl.Vec3) bool {
+	return a.Sub(b).Len() < 1e-4
+}
+
+func TestIntersectAABB(t *testing.T) {
+	box := AABB{Min: mgl.Vec3{-1, -1, -1}, Max: mgl.Vec3{1, 1, 1}}
+	for _, tc := range []struct {
+		name   string
+		ray    Ray
+		hit    bool
+		dist   float32
+		normal mgl.Vec3
+	}{
+		{"head on", Ray{mgl.Vec3{0, 0, 5}, mgl.Vec3{0, 0, -1}}, true, 4, mgl.Vec3{0, 0, 1}},
+		{"from below", Ray{mgl.Vec3{0.5, -3, 0.5}, mgl.Vec3{0, 1, 0}}, true, 2, mgl.Vec3{0, -1, 0}},
+		{"long Dir", Ray{mgl.Vec3{-5, 0, 0}, mgl.Vec3{2, 0, 0}}, true, 2, mgl.Vec3{-1, 0, 0}},
+		{"diagonal", Ray{mgl.Vec3{3, 2, 0}, mgl.Vec3{-1, -1, 0}}, true, 2, mgl.Vec3{1, 0, 0}},
+		{"pointing away", Ray{mgl.Vec3{0, 0, 5}, mgl.Vec3{0, 0, 1}}, false, 0, mgl.Vec3{}},
+		{"passing by", Ray{mgl.Vec3{0, 2, 5}, mgl.Vec3{0, 0, -1}}, false, 0, mgl.Vec3{}},
+		{"passing by diagonally", Ray{mgl.Vec3{3, 0, 0}, mgl.Vec3{-1, 0, 5}}, false, 0, mgl.Vec3{}},
+		{"parallel, outside the slab", Ray{mgl.Vec3{-5, 1.5, 0}, mgl.Vec3{1, 0, 0}}, false, 0, mgl.Vec3{}},
+		{"parallel, along a face", Ray{mgl.Vec3{-5, 1, 0}, mgl.Vec3{1, 0, 0}}, true, 4, mgl.Vec3{-1, 0, 0}},
+		{"inside", Ray{mgl.Vec3{0.5, 0, 0}, mgl.Vec3{0, 0, 1}}, true, 0, mgl.Vec3{0, 0, -1}},
+	} {
+		hit, ok := tc.ray.IntersectAABB(box)
+		if ok != tc.hit {
+			t.Errorf("%s: got hit %v, want %v", tc.name, ok, tc.hit)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if hit.Distance != tc.dist {
+			t.Errorf("%s: got distance %v, want %v", tc.name, hit.Distance, tc.dist)
+		}
+		if !near(hit.Point, tc.ray.At(tc.dist)) {
+			t.Errorf("%s: got point %v, want %v", tc.name, hit.Point, tc.ray.At(tc.dist))
+		}
+		if hit.Normal != tc.normal {
+			t.Errorf("%s: got normal %v, want %v", tc.name, hit.Normal, tc.normal)
+		}
+	}
+}
+
+func TestIntersectSphere(t *testing.T) {
+	center := mgl.Vec3{0, 0, -5}
+	for _, tc := range []struct {
+		name   string
+		ray    Ray
+		hit    bool
+		dist   float32
+		normal mgl.Vec3
+	}{
+		{"head on", Ray{mgl.Vec3{}, mgl.Vec3{0, 0, -1}}, true, 3, mgl.Vec3{0, 0, 1}},
+		{"long Dir", Ray{mgl.Vec3{}, mgl.Vec3{0, 0, -3}}, true, 1, mgl.Vec3{0, 0, 1}},
+		{"grazing", Ray{mgl.Vec3{0, 2, 0}, mgl.Vec3{0, 0, -1}}, true, 5, mgl.Vec3{0, 1, 0}},
+		{"passing by", Ray{mgl.Vec3{0, 2.1, 0}, mgl.Vec3{0, 0, -1}}, false, 0, mgl.Vec3{}},
+		{"behind", Ray{mgl.Vec3{}, mgl.Vec3{0, 0, 1}}, false, 0, mgl.Vec3{}},
+		{"along an axis", Ray{mgl.Vec3{-9, 0, -5}, mgl.Vec3{1, 0, 0}}, true, 7, mgl.Vec3{-1, 0, 0}},
+		{"inside", Ray{mgl.Vec3{0, 1, -5}, mgl.Vec3{1, 0, 0}}, true, 0, mgl.Vec3{0, 1, 0}},
+		{"at the center", Ray{center, mgl.Vec3{1, 0, 0}}, true, 0, mgl.Vec3{-1, 0, 0}},
+		{"no Dir", Ray{mgl.Vec3{}, mgl.Vec3{}}, false, 0, mgl.Vec3{}},
+	} {
+		hit, ok := tc.ray.IntersectSphere(center, 2)
+		if ok != tc.hit {
+			t.Errorf("%s: got hit %v, want %v", tc.name, ok, tc.hit)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if abs32(hit.Distance-tc.dist) > 1e-4 {
+			t.Errorf("%s: got distance %v, want %v", tc.name, hit.Distance, tc.dist)
+		}
+		if !near(hit.Normal, tc.normal) {
+			t.Errorf("%s: got normal %v, want %v", tc.name, hit.Normal, tc.normal)
+		}
+	}
+}
+
+func TestIntersectTriangle(t *testing.T) {
+	// in the z=-2 plane, facing +z
+	a, b, c := mgl.Vec3{-1, -1, -2}, mgl.Vec3{1, -1, -2}, mgl.Vec3{0, 1, -2}
+	for _, tc := range []struct {
+		name   string
+		ray    Ray
+		hit    bool
+		dist   float32
+		normal mgl.Vec3
+	}{
+		{"front", Ray{mgl.Vec3{}, mgl.Vec3{0, 0, -1}}, true, 2, mgl.Vec3{0, 0, 1}},
+		{"back", Ray{mgl.Vec3{0, 0, -5}, mgl.Vec3{0, 0, 1}}, true, 3, mgl.Vec3{0, 0, -1}},
+		{"slanted", Ray{mgl.Vec3{2, 0, 0}, mgl.Vec3{-1, 0, -1}}, true, 2, mgl.Vec3{0, 0, 1}},
+		{"on a corner", Ray{mgl.Vec3{1, -1, 0}, mgl.Vec3{0, 0, -1}}, true, 2, mgl.Vec3{0, 0, 1}},
+		{"outside an edge", Ray{mgl.Vec3{0.9, 0.9, 0}, mgl.Vec3{0, 0, -1}}, false, 0, mgl.Vec3{}},
+		{"below", Ray{mgl.Vec3{0, -1.5, 0}, mgl.Vec3{0, 0, -1}}, false, 0, mgl.Vec3{}},
+		{"behind", Ray{mgl.Vec3{}, mgl.Vec3{0, 0, 1}}, false, 0, mgl.Vec3{}},
+		{"parallel", Ray{mgl.Vec3{-5, 0, -2}, mgl.Vec3{1, 0, 0}}, false, 0, mgl.Vec3{}},
+	} {
+		hit, ok := tc.ray.IntersectTriangle(a, b, c)
+		if ok != tc.hit {
+			t.Errorf("%s: got hit %v, want %v", tc.name, ok, tc.hit)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if abs32(hit.Distance-tc.dist) > 1e-5 {
+			t.Errorf("%s: got distance %v, want %v", tc.name, hit.Distance, tc.dist)
+		}
+		if !near(hit.Point, tc.ray.At(tc.dist)) {
+			t.Errorf("%s: got point %v, want %v", tc.name, hit.Point, tc.ray.At(tc.dist))
+		}
+		if !near(hit.Normal, tc.normal) {
+			t.Errorf("%s: got normal %v, want %v", tc.name, hit.Normal, tc.normal)
+		}
+	}
+}
+
+func TestUnproject(t *testing.T) {
+	projection := mgl.Perspective(mgl.DegToRad(90), 1, 0.5, 100)
+
+	// straight ahead, down -z from the near plane
+	ray := Unproject(0, 0, projection, mgl.Ident4())
+	if !near(ray.Origin, mgl.Vec3{0, 0, -0.5}) {
+		t.Errorf("got origin %v, want {0,0,-0.5}", ray.Origin)
+	}
+	if !near(ray.Dir, mgl.Vec3{0, 0, -1}) {
+		t.Errorf("got dir %v, want {0,0,-1}", ray.Dir)
+	}
+
+	// with a 90 degree field of view, the right edge is at 45 degrees
+	ray = Unproject(1, 0, projection, mgl.Ident4())
+	if want := (mgl.Vec3{1, 0, -1}).Normalize(); !near(ray.Dir, want) {
+		t.Errorf("got dir %v, want %v", ray.Dir, want)
+	}
+
+	// a camera at (0,0,5) looking along +x
+	view := mgl.LookAtV(mgl.Vec3{0, 0, 5}, mgl.Vec3{1, 0, 5}, mgl.Vec3{0, 1, 0})
+	ray = Unproject(0, 0, projection, view)
+	if !near(ray.Origin, mgl.Vec3{0.5, 0, 5}) {
+		t.Errorf("got origin %v, want {0.5,0,5}", ray.Origin)
+	}
+	if !near(ray.Dir, mgl.Vec3{1, 0, 0}) {
+		t.Errorf("got dir %v, want {1,0,0}", ray.Dir)
+	}
+}
+
+func TestRayTransform(t *testing.T) {
+	ray := Ray{Origin: mgl.Vec3{1, 2, 3}, Dir: mgl.Vec3{0, 0, -1}}
+	moved := ray.Transform(mgl.Translate3D(1, 0, 0).Mul4(mgl.Scale3D(2, 2, 2)))
+	if !near(moved.Origin, mgl.Vec3{3, 4, 6}) {
+		t.Errorf("got origin %v, want {3,4,6}", moved.Origin)
+	}
+	// not renormalized, and not moved by the translation
+	if !near(moved.Dir, mgl.Vec3{0, 0, -2}) {
+		t.Errorf("got dir %v, want {0,0,-2}", moved.Dir)
+	}
+}
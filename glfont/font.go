@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/dcrosby42/go-game-sandbox/helpers"
 	"github.com/go-gl/gl/v3.3-core/gl"
+	mgl "github.com/go-gl/mathgl/mgl32"
 )
 
 // Direction represents the direction in which strings should be rendered.
@@ -22,7 +24,7 @@ type Font struct {
 	fontChar []*character
 	vao      uint32
 	vbo      uint32
-	program  uint32
+	program  *helpers.ShaderProgram
 	texture  uint32 // Holds the glyph texture id.
 	color    color
 }
@@ -48,19 +50,37 @@ func LoadFont(file string, scale int32, windowWidth int, windowHeight int, shade
 	if shaderCompiler == nil {
 		shaderCompiler = newProgram
 	}
-	program, err := shaderCompiler(vertexFontShader, fragmentFontShader)
+	handle, err := shaderCompiler(vertexFontShader, fragmentFontShader)
 	if err != nil {
 		panic(err)
 	}
+	program := helpers.NewShaderProgram(handle)
 
 	// Activate corresponding render state
-	gl.UseProgram(program)
+	program.Use()
 
 	//set screen resolution
-	resUniform := gl.GetUniformLocation(program, gl.Str("resolution\x00"))
-	gl.Uniform2f(resUniform, float32(windowWidth), float32(windowHeight))
+	program.SetVec2("resolution", mgl.Vec2{float32(windowWidth), float32(windowHeight)})
+	// the shader takes a transform, as Font2's does; Printf draws flat on the screen
+	program.SetMat4("transmat", mgl.Ident4())
 
-	return LoadTrueTypeFont(program, fd, scale, 32, 127, LeftToRight)
+	ttf, err := ParseTrueType(fd)
+	if err != nil {
+		return nil, err
+	}
+	return buildTrueTypeFont(program, ttf, scale, 32, 127, LeftToRight)
+}
+
+//Delete frees the font's glyph textures, buffers and shader program. The
+//font can't be drawn with afterwards.
+func (f *Font) Delete() {
+	for _, ch := range f.fontChar {
+		gl.DeleteTextures(1, &ch.textureID)
+	}
+	f.fontChar = nil
+	gl.DeleteVertexArrays(1, &f.vao)
+	gl.DeleteBuffers(1, &f.vbo)
+	f.program.Delete()
 }
 
 //SetColor allows you to set the text color to be used when you draw the text
@@ -87,9 +107,9 @@ func (f *Font) Printf(x, y float32, scale float32, fs string, argv ...interface{
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
 	// Activate corresponding render state
-	gl.UseProgram(f.program)
+	f.program.Use()
 	//set text color
-	gl.Uniform4f(gl.GetUniformLocation(f.program, gl.Str("textColor\x00")), f.color.r, f.color.g, f.color.b, f.color.a)
+	f.program.SetVec4("textColor", mgl.Vec4{f.color.r, f.color.g, f.color.b, f.color.a})
 	//set screen resolution
 	//resUniform := gl.GetUniformLocation(f.program, gl.Str("resolution\x00"))
 	//gl.Uniform2f(resUniform, float32(2560), float32(1440))
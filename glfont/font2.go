@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/dcrosby42/go-game-sandbox/helpers"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	mgl "github.com/go-gl/mathgl/mgl32"
+	"github.com/golang/freetype/truetype"
 )
 
 // A Font allows rendering of text to an OpenGL context.
@@ -21,23 +23,32 @@ func LoadFont2(file string, scale int32, windowWidth int, windowHeight int, shad
 	}
 	defer fd.Close()
 
+	ttf, err := ParseTrueType(fd)
+	if err != nil {
+		return nil, err
+	}
+	return NewFont2(ttf, scale, windowWidth, windowHeight, shaderCompiler)
+}
+
+//NewFont2 builds the glyph textures for an already-parsed font at the given scale.
+func NewFont2(ttf *truetype.Font, scale int32, windowWidth int, windowHeight int, shaderCompiler ShaderCompilerFunc) (*Font2, error) {
 	// Configure the default font vertex and fragment shaders
 	if shaderCompiler == nil {
 		shaderCompiler = newProgram
 	}
-	program, err := shaderCompiler(vertexFont2Shader, fragmentFontShader)
+	handle, err := shaderCompiler(vertexFont2Shader, fragmentFontShader)
 	if err != nil {
 		panic(err)
 	}
+	program := helpers.NewShaderProgram(handle)
 
 	// Activate corresponding render state
-	gl.UseProgram(program)
+	program.Use()
 
 	//set screen resolution
-	resUniform := gl.GetUniformLocation(program, gl.Str("resolution\x00"))
-	gl.Uniform2f(resUniform, float32(windowWidth), float32(windowHeight))
+	program.SetVec2("resolution", mgl.Vec2{float32(windowWidth), float32(windowHeight)})
 
-	font, err := LoadTrueTypeFont(program, fd, scale, 32, 127, LeftToRight)
+	font, err := buildTrueTypeFont(program, ttf, scale, 32, 127, LeftToRight)
 	if err != nil {
 		return nil, err
 	}
@@ -61,15 +72,15 @@ func (f *Font2) Tprintf(x, y float32, scale float32, transmat mgl.Mat4, fs strin
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
 	// Activate corresponding render state
-	gl.UseProgram(f.program)
+	f.program.Use()
 	//set text color
-	gl.Uniform4f(gl.GetUniformLocation(f.program, gl.Str("textColor\x00")), f.color.r, f.color.g, f.color.b, f.color.a)
+	f.program.SetVec4("textColor", mgl.Vec4{f.color.r, f.color.g, f.color.b, f.color.a})
 	//set screen resolution
 	//resUniform := gl.GetUniformLocation(f.program, gl.Str("resolution\x00"))
 	//gl.Uniform2f(resUniform, float32(2560), float32(1440))
 
 	// transform matrix
-	gl.UniformMatrix4fv(gl.GetUniformLocation(f.program, gl.Str("transmat\x00")), 1, false, &transmat[0])
+	f.program.SetMat4("transmat", transmat)
 
 	gl.ActiveTexture(gl.TEXTURE0)
 	gl.BindVertexArray(f.vao)
@@ -0,0 +1,44 @@
+//go:build golden
+// +build golden
+
+package glfont
+
+import (
+	"testing"
+
+	"github.com/dcrosby42/go-game-sandbox/golden"
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// testFont is the Go font (BSD licensed, see testdata/LICENSE-GoFont)
+const testFont = "testdata/Go-Regular.ttf"
+
+func TestGoldenText(t *testing.T) {
+	const w, h = 320, 120
+	golden.Context(t, w, h)
+
+	font, err := LoadFont(testFont, 32, w, h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := helpers.NewRenderTarget(w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Delete()
+	target.Bind()
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	gl.Disable(gl.CULL_FACE)
+	gl.Disable(gl.DEPTH_TEST)
+
+	font.SetColor(1, 1, 1, 1)
+	font.Printf(10, 45, 1, "Hello World")
+	font.SetColor(1, 0.5, 0.2, 1)
+	font.Printf(10, 95, 0.75, "glfont %d", 123)
+	target.Unbind()
+
+	golden.Check(t, "text", target.ReadPixels(), golden.DefaultTolerance)
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 
+	"github.com/dcrosby42/go-game-sandbox/helpers"
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
@@ -26,17 +27,27 @@ type character struct {
 
 //LoadTrueTypeFont builds a set of textures based on a ttf files gylphs
 func LoadTrueTypeFont(program uint32, r io.Reader, scale int32, low, high rune, dir Direction) (*Font, error) {
-	data, err := ioutil.ReadAll(r)
+	ttf, err := ParseTrueType(r)
 	if err != nil {
 		return nil, err
 	}
+	return buildTrueTypeFont(helpers.NewShaderProgram(program), ttf, scale, low, high, dir)
+}
 
-	// Read the truetype font.
-	ttf, err := truetype.Parse(data)
+//ParseTrueType reads a ttf file. It makes no GL calls, so it can be done off the main thread.
+func ParseTrueType(r io.Reader) (*truetype.Font, error) {
+	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
 
+	// Read the truetype font.
+	return truetype.Parse(data)
+}
+
+func buildTrueTypeFont(program *helpers.ShaderProgram, ttf *truetype.Font, scale int32, low, high rune, dir Direction) (*Font, error) {
+	var err error
+
 	//make Font stuct type
 	f := new(Font)
 	f.fontChar = make([]*character, 0, high-low+1)
@@ -142,12 +153,12 @@ func LoadTrueTypeFont(program uint32, r io.Reader, scale int32, low, high rune,
 
 	gl.BufferData(gl.ARRAY_BUFFER, 6*4*4, nil, gl.STATIC_DRAW)
 
-	vertAttrib := uint32(gl.GetAttribLocation(f.program, gl.Str("vert\x00")))
+	vertAttrib := uint32(f.program.AttribLocation("vert"))
 	gl.EnableVertexAttribArray(vertAttrib)
 	gl.VertexAttribPointer(vertAttrib, 2, gl.FLOAT, false, 4*4, gl.PtrOffset(0))
 	defer gl.DisableVertexAttribArray(vertAttrib)
 
-	texCoordAttrib := uint32(gl.GetAttribLocation(f.program, gl.Str("vertTexCoord\x00")))
+	texCoordAttrib := uint32(f.program.AttribLocation("vertTexCoord"))
 	gl.EnableVertexAttribArray(texCoordAttrib)
 	gl.VertexAttribPointer(texCoordAttrib, 2, gl.FLOAT, false, 4*4, gl.PtrOffset(2*4))
 	defer gl.DisableVertexAttribArray(texCoordAttrib)
@@ -0,0 +1,147 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+var typeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+var componentSizes = map[int]int{
+	Byte:          1,
+	UnsignedByte:  1,
+	Short:         2,
+	UnsignedShort: 2,
+	UnsignedInt:   4,
+	Float:         4,
+}
+
+// ReadFloats decodes an accessor into floats, Components per element.
+// Integer components are converted, and scaled to 0..1 (or -1..1) if the
+// accessor is Normalized.
+func (me *Document) ReadFloats(i int) (values []float32, components int, err error) {
+	a, raw, stride, err := me.accessor(i)
+	if err != nil {
+		return nil, 0, err
+	}
+	components = typeComponents[a.Type]
+	size := componentSizes[a.ComponentType]
+	values = make([]float32, 0, a.Count*components)
+	for e := 0; e < a.Count; e++ {
+		elem := raw[e*stride:]
+		for c := 0; c < components; c++ {
+			values = append(values, readComponent(elem[c*size:], a.ComponentType, a.Normalized))
+		}
+	}
+	return values, components, nil
+}
+
+// ReadIndices decodes a SCALAR accessor of unsigned integers, as used for
+// primitive indices.
+func (me *Document) ReadIndices(i int) ([]uint32, error) {
+	a, raw, stride, err := me.accessor(i)
+	if err != nil {
+		return nil, err
+	}
+	if a.Type != "SCALAR" {
+		return nil, fmt.Errorf("gltf: accessor %d: indices must be SCALAR, not %s", i, a.Type)
+	}
+	indices := make([]uint32, a.Count)
+	for e := range indices {
+		elem := raw[e*stride:]
+		switch a.ComponentType {
+		case UnsignedByte:
+			indices[e] = uint32(elem[0])
+		case UnsignedShort:
+			indices[e] = uint32(binary.LittleEndian.Uint16(elem))
+		case UnsignedInt:
+			indices[e] = binary.LittleEndian.Uint32(elem)
+		default:
+			return nil, fmt.Errorf("gltf: accessor %d: indices can't have component type %d", i, a.ComponentType)
+		}
+	}
+	return indices, nil
+}
+
+// accessor checks an accessor and returns its bytes, starting at its first
+// element, and the distance between elements.
+func (me *Document) accessor(i int) (a Accessor, raw []byte, stride int, err error) {
+	if i < 0 || i >= len(me.Accessors) {
+		return a, nil, 0, fmt.Errorf("gltf: no accessor %d", i)
+	}
+	a = me.Accessors[i]
+	if len(a.Sparse) > 0 {
+		return a, nil, 0, fmt.Errorf("gltf: accessor %d: sparse accessors aren't supported", i)
+	}
+	components, ok := typeComponents[a.Type]
+	if !ok {
+		return a, nil, 0, fmt.Errorf("gltf: accessor %d: unknown type %q", i, a.Type)
+	}
+	size, ok := componentSizes[a.ComponentType]
+	if !ok {
+		return a, nil, 0, fmt.Errorf("gltf: accessor %d: unknown component type %d", i, a.ComponentType)
+	}
+	if a.Count < 0 || a.ByteOffset < 0 {
+		return a, nil, 0, fmt.Errorf("gltf: accessor %d: negative count %d or byteOffset %d", i, a.Count, a.ByteOffset)
+	}
+	elemSize := components * size
+	if a.BufferView == nil {
+		// no data means all zeros
+		return a, make([]byte, a.Count*elemSize), elemSize, nil
+	}
+	view, err := me.bufferView(*a.BufferView)
+	if err != nil {
+		return a, nil, 0, err
+	}
+	stride = me.BufferViews[*a.BufferView].ByteStride
+	if stride == 0 {
+		stride = elemSize
+	} else if stride < elemSize {
+		return a, nil, 0, fmt.Errorf("gltf: accessor %d: byteStride %d is less than its %d byte elements", i, stride, elemSize)
+	}
+	if a.ByteOffset > len(view) || (a.Count > 0 && a.ByteOffset+(a.Count-1)*stride+elemSize > len(view)) {
+		return a, nil, 0, fmt.Errorf("gltf: accessor %d runs past the end of bufferView %d", i, *a.BufferView)
+	}
+	return a, view[a.ByteOffset:], stride, nil
+}
+
+func readComponent(b []byte, componentType int, normalized bool) float32 {
+	switch componentType {
+	case Float:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	case UnsignedByte:
+		if normalized {
+			return float32(b[0]) / 255
+		}
+		return float32(b[0])
+	case Byte:
+		if normalized {
+			return float32(math.Max(float64(int8(b[0]))/127, -1))
+		}
+		return float32(int8(b[0]))
+	case UnsignedShort:
+		v := binary.LittleEndian.Uint16(b)
+		if normalized {
+			return float32(v) / 65535
+		}
+		return float32(v)
+	case Short:
+		v := int16(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return float32(math.Max(float64(v)/32767, -1))
+		}
+		return float32(v)
+	case UnsignedInt:
+		return float32(binary.LittleEndian.Uint32(b))
+	}
+	return 0
+}
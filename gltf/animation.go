@@ -0,0 +1,54 @@
+package gltf
+
+// Skin binds a mesh's vertices to joints, which are nodes. Vertex attribute
+// JOINTS_0 indexes into Joints. InverseBindMatrices, a MAT4 accessor, takes
+// each joint from world space to its bind pose space; without it they're
+// the identity.
+type Skin struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Joints              []int  `json:"joints"`
+	Skeleton            *int   `json:"skeleton"`
+}
+
+// Animation is a set of Channels played together.
+type Animation struct {
+	Name     string             `json:"name"`
+	Channels []Channel          `json:"channels"`
+	Samplers []AnimationSampler `json:"samplers"`
+}
+
+// Channel animates one property of a node with one of the Animation's Samplers.
+type Channel struct {
+	Sampler int           `json:"sampler"`
+	Target  ChannelTarget `json:"target"`
+}
+
+// Channel target paths
+const (
+	PathTranslation = "translation"
+	PathRotation    = "rotation"
+	PathScale       = "scale"
+	PathWeights     = "weights" // morph target weights, not supported
+)
+
+type ChannelTarget struct {
+	Node *int   `json:"node"`
+	Path string `json:"path"`
+}
+
+// Sampler interpolations
+const (
+	InterpolationLinear      = "LINEAR" // the default
+	InterpolationStep        = "STEP"
+	InterpolationCubicSpline = "CUBICSPLINE"
+)
+
+// AnimationSampler has keyframe times in seconds (Input, a SCALAR accessor)
+// and values (Output). A CUBICSPLINE sampler has three values per keyframe:
+// in-tangent, value, out-tangent.
+type AnimationSampler struct {
+	Input         int    `json:"input"`
+	Output        int    `json:"output"`
+	Interpolation string `json:"interpolation"`
+}
@@ -0,0 +1,360 @@
+// Package gltf reads glTF 2.0 files, both .gltf (JSON, with external or
+// embedded data: URI buffers) and .glb (binary), and decodes their accessors
+// into plain vertex and index slices. Building GPU resources from them is left
+// to helpers.LoadGLTF.
+//
+// Sparse accessors, morph targets and extensions are not supported.
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Document is the top level of a glTF file. Indices between its parts are
+// into these slices.
+type Document struct {
+	Asset       Asset        `json:"asset"`
+	Scene       *int         `json:"scene"`
+	Scenes      []Scene      `json:"scenes"`
+	Nodes       []Node       `json:"nodes"`
+	Meshes      []Mesh       `json:"meshes"`
+	Materials   []Material   `json:"materials"`
+	Skins       []Skin       `json:"skins"`
+	Animations  []Animation  `json:"animations"`
+	Textures    []Texture    `json:"textures"`
+	Images      []Image      `json:"images"`
+	Accessors   []Accessor   `json:"accessors"`
+	BufferViews []BufferView `json:"bufferViews"`
+	Buffers     []Buffer     `json:"buffers"`
+
+	// dir is where relative URIs are found
+	dir string
+	// bin is the binary chunk of a .glb
+	bin []byte
+	// data holds each Buffer's bytes once loaded
+	data [][]byte
+}
+
+type Asset struct {
+	Version string `json:"version"`
+}
+
+type Scene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+// Node places a Mesh, and its children, with either a Matrix or
+// Translation/Rotation/Scale. Rotation is a quaternion as x, y, z, w.
+type Node struct {
+	Name        string       `json:"name"`
+	Children    []int        `json:"children"`
+	Mesh        *int         `json:"mesh"`
+	Skin        *int         `json:"skin"`
+	Matrix      *[16]float32 `json:"matrix"`
+	Translation *[3]float32  `json:"translation"`
+	Rotation    *[4]float32  `json:"rotation"`
+	Scale       *[3]float32  `json:"scale"`
+}
+
+type Mesh struct {
+	Name       string      `json:"name"`
+	Primitives []Primitive `json:"primitives"`
+}
+
+// Primitive modes; only ModeTriangles is drawn by helpers.LoadGLTF
+const (
+	ModePoints    = 0
+	ModeTriangles = 4
+)
+
+// Primitive is a set of vertices drawn with one material. Attributes maps
+// names such as "POSITION", "NORMAL" and "TEXCOORD_0" to accessors; skinned
+// primitives also have "JOINTS_0" and "WEIGHTS_0".
+type Primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+// TriangleMode reports whether the primitive is a plain triangle list.
+func (me *Primitive) TriangleMode() bool {
+	return me.Mode == nil || *me.Mode == ModeTriangles
+}
+
+type Material struct {
+	Name                 string                `json:"name"`
+	PbrMetallicRoughness *PbrMetallicRoughness `json:"pbrMetallicRoughness"`
+	AlphaMode            string                `json:"alphaMode"` // OPAQUE (default), MASK or BLEND
+	AlphaCutoff          *float32              `json:"alphaCutoff"`
+	DoubleSided          bool                  `json:"doubleSided"`
+}
+
+// PbrMetallicRoughness holds the material's parameters; use the getters for
+// the spec's defaults when they're absent.
+type PbrMetallicRoughness struct {
+	BaseColorFactor          *[4]float32  `json:"baseColorFactor"`
+	BaseColorTexture         *TextureInfo `json:"baseColorTexture"`
+	MetallicFactor           *float32     `json:"metallicFactor"`
+	RoughnessFactor          *float32     `json:"roughnessFactor"`
+	MetallicRoughnessTexture *TextureInfo `json:"metallicRoughnessTexture"`
+}
+
+func (me *PbrMetallicRoughness) BaseColor() [4]float32 {
+	if me == nil || me.BaseColorFactor == nil {
+		return [4]float32{1, 1, 1, 1}
+	}
+	return *me.BaseColorFactor
+}
+
+func (me *PbrMetallicRoughness) Metallic() float32 {
+	if me == nil || me.MetallicFactor == nil {
+		return 1
+	}
+	return *me.MetallicFactor
+}
+
+func (me *PbrMetallicRoughness) Roughness() float32 {
+	if me == nil || me.RoughnessFactor == nil {
+		return 1
+	}
+	return *me.RoughnessFactor
+}
+
+type TextureInfo struct {
+	Index    int `json:"index"`
+	TexCoord int `json:"texCoord"`
+}
+
+type Texture struct {
+	Source *int `json:"source"`
+}
+
+// Image is either a URI (a file or a data: URI) or a BufferView with a MimeType.
+type Image struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+// Accessor component types
+const (
+	Byte          = 5120
+	UnsignedByte  = 5121
+	Short         = 5122
+	UnsignedShort = 5123
+	UnsignedInt   = 5125
+	Float         = 5126
+)
+
+type Accessor struct {
+	BufferView    *int            `json:"bufferView"`
+	ByteOffset    int             `json:"byteOffset"`
+	ComponentType int             `json:"componentType"`
+	Normalized    bool            `json:"normalized"`
+	Count         int             `json:"count"`
+	Type          string          `json:"type"` // SCALAR, VEC2, VEC3, VEC4, MAT4...
+	Sparse        json.RawMessage `json:"sparse"`
+}
+
+type BufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+// Buffer is binary data from a URI, or with no URI in a .glb, its binary chunk.
+type Buffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// Load reads a .gltf or .glb file, and the buffers it refers to.
+func Load(path string) (*Document, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Parse(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return doc, nil
+}
+
+// Parse decodes a .gltf or .glb file's contents and loads its buffers. dir is
+// where relative URIs are looked for.
+func Parse(data []byte, dir string) (*Document, error) {
+	doc := &Document{dir: dir}
+	jsonChunk := data
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		jsonChunk, doc.bin, err = splitGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(jsonChunk, doc); err != nil {
+		return nil, fmt.Errorf("gltf: %s", err)
+	}
+	if !strings.HasPrefix(doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("gltf: version %q, only 2.x is supported", doc.Asset.Version)
+	}
+	if err := doc.loadBuffers(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// splitGLB returns the JSON and BIN chunks of a .glb.
+func splitGLB(data []byte) (jsonChunk, bin []byte, err error) {
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("gltf: glb version %d, only 2 is supported", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, fmt.Errorf("gltf: glb is %d bytes, header says %d", len(data), length)
+	}
+	for pos := 12; pos+8 <= length; {
+		chunkLen := int(binary.LittleEndian.Uint32(data[pos:]))
+		chunkType := binary.LittleEndian.Uint32(data[pos+4:])
+		start := pos + 8
+		if start+chunkLen > length {
+			return nil, nil, fmt.Errorf("gltf: glb chunk at %d runs past the end", pos)
+		}
+		switch chunkType {
+		case glbChunkJSON:
+			jsonChunk = data[start : start+chunkLen]
+		case glbChunkBIN:
+			bin = data[start : start+chunkLen]
+		}
+		pos = start + chunkLen
+	}
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("gltf: glb has no JSON chunk")
+	}
+	return jsonChunk, bin, nil
+}
+
+func (me *Document) loadBuffers() error {
+	me.data = make([][]byte, len(me.Buffers))
+	for i, b := range me.Buffers {
+		var data []byte
+		var err error
+		if b.URI == "" {
+			if me.bin == nil {
+				return fmt.Errorf("gltf: buffer %d has no uri and there's no glb binary chunk", i)
+			}
+			data = me.bin
+		} else {
+			data, err = me.readURI(b.URI)
+			if err != nil {
+				return fmt.Errorf("gltf: buffer %d: %s", i, err)
+			}
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("gltf: buffer %d is %d bytes, expected %d", i, len(data), b.ByteLength)
+		}
+		me.data[i] = data
+	}
+	return nil
+}
+
+// readURI reads a data: URI or a file relative to the document.
+func (me *Document) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("only base64 data URIs are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+	return ioutil.ReadFile(me.Path(uri))
+}
+
+// Path resolves a relative URI against the document's directory.
+func (me *Document) Path(uri string) string {
+	if filepath.IsAbs(uri) {
+		return uri
+	}
+	return filepath.Join(me.dir, filepath.FromSlash(uri))
+}
+
+// ImageSource says where to get an image: from the file at Path, or else
+// from Data, which is the encoded (eg PNG) image.
+func (me *Document) ImageSource(i int) (path string, data []byte, err error) {
+	if i < 0 || i >= len(me.Images) {
+		return "", nil, fmt.Errorf("gltf: no image %d", i)
+	}
+	img := me.Images[i]
+	switch {
+	case img.BufferView != nil:
+		data, err = me.bufferView(*img.BufferView)
+		return "", data, err
+	case strings.HasPrefix(img.URI, "data:"):
+		data, err = me.readURI(img.URI)
+		return "", data, err
+	case img.URI != "":
+		return me.Path(img.URI), nil, nil
+	}
+	return "", nil, fmt.Errorf("gltf: image %d has neither uri nor bufferView", i)
+}
+
+func (me *Document) bufferView(i int) ([]byte, error) {
+	if i < 0 || i >= len(me.BufferViews) {
+		return nil, fmt.Errorf("gltf: no bufferView %d", i)
+	}
+	bv := me.BufferViews[i]
+	if bv.Buffer < 0 || bv.Buffer >= len(me.data) {
+		return nil, fmt.Errorf("gltf: bufferView %d: no buffer %d", i, bv.Buffer)
+	}
+	buf := me.data[bv.Buffer]
+	end := bv.ByteOffset + bv.ByteLength
+	if bv.ByteOffset < 0 || bv.ByteLength < 0 || end > len(buf) {
+		return nil, fmt.Errorf("gltf: bufferView %d runs past the end of buffer %d", i, bv.Buffer)
+	}
+	return buf[bv.ByteOffset:end], nil
+}
+
+// SceneRoots lists the root nodes of the default scene, or of the first
+// scene if there's no default, or if there are no scenes, every node that
+// isn't a child of another.
+func (me *Document) SceneRoots() []int {
+	if len(me.Scenes) > 0 {
+		i := 0
+		if me.Scene != nil && *me.Scene >= 0 && *me.Scene < len(me.Scenes) {
+			i = *me.Scene
+		}
+		return me.Scenes[i].Nodes
+	}
+	isChild := make([]bool, len(me.Nodes))
+	for _, n := range me.Nodes {
+		for _, c := range n.Children {
+			if c >= 0 && c < len(isChild) {
+				isChild[c] = true
+			}
+		}
+	}
+	var roots []int
+	for i := range me.Nodes {
+		if !isChild[i] {
+			roots = append(roots, i)
+		}
+	}
+	return roots
+}
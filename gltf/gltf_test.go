@@ -0,0 +1,173 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// testdata/triangle.gltf and triangle.glb hold the same document, one with
+// its buffer as a base64 data: URI and the other in the binary chunk.
+var testFiles = []string{"testdata/triangle.gltf", "testdata/triangle.glb"}
+
+func loadTest(t *testing.T, path string) *Document {
+	t.Helper()
+	doc, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestReadFloats(t *testing.T) {
+	for _, path := range testFiles {
+		doc := loadTest(t, path)
+		for _, tc := range []struct {
+			accessor   int
+			values     []float32
+			components int
+		}{
+			{0, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, 3},
+			{2, []float32{1, 0, 0, 1, 0, 1, 0, 128.0 / 255, 0, 0, 1, 0}, 4}, // normalized bytes
+			{3, []float32{0.5, 1.5, 2.5}, 1},                                // every other float
+			{5, []float32{0, 0, 0, 0}, 2},                                   // no bufferView
+			{9, []float32{0, 0, 1, 0, 0, 0}, 3},                             // byteOffset
+		} {
+			values, components, err := doc.ReadFloats(tc.accessor)
+			if err != nil {
+				t.Errorf("%s: accessor %d: %s", path, tc.accessor, err)
+				continue
+			}
+			if components != tc.components {
+				t.Errorf("%s: accessor %d: got %d components, want %d", path, tc.accessor, components, tc.components)
+			}
+			if !reflect.DeepEqual(values, tc.values) {
+				t.Errorf("%s: accessor %d: got %v, want %v", path, tc.accessor, values, tc.values)
+			}
+		}
+	}
+}
+
+func TestReadIndices(t *testing.T) {
+	for _, path := range testFiles {
+		doc := loadTest(t, path)
+		indices, err := doc.ReadIndices(1)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		if want := []uint32{0, 1, 2}; !reflect.DeepEqual(indices, want) {
+			t.Errorf("%s: got %v, want %v", path, indices, want)
+		}
+		if _, err := doc.ReadIndices(3); err == nil {
+			t.Errorf("%s: read float indices without an error", path)
+		}
+		if _, err := doc.ReadIndices(0); err == nil {
+			t.Errorf("%s: read VEC3 indices without an error", path)
+		}
+	}
+}
+
+func TestBadAccessors(t *testing.T) {
+	doc := loadTest(t, testFiles[0])
+	for _, tc := range []struct {
+		accessor int
+		err      string
+	}{
+		{4, "runs past the end"},
+		{6, "runs past the end"},
+		{7, "negative count"},
+		{8, "negative count"},
+		{10, "no accessor"},
+		{-1, "no accessor"},
+	} {
+		_, _, err := doc.ReadFloats(tc.accessor)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("accessor %d: got error %v, want %q", tc.accessor, err, tc.err)
+		}
+		if _, err := doc.ReadIndices(tc.accessor); err == nil {
+			t.Errorf("accessor %d: read indices without an error", tc.accessor)
+		}
+	}
+
+	doc.BufferViews[3].ByteStride = 2
+	if _, _, err := doc.ReadFloats(3); err == nil {
+		t.Error("read with a byteStride shorter than an element")
+	}
+}
+
+func TestSceneRoots(t *testing.T) {
+	doc := loadTest(t, testFiles[0])
+	if got, want := doc.SceneRoots(), []int{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("default scene: got %v, want %v", got, want)
+	}
+	doc.Scene = nil
+	if got, want := doc.SceneRoots(), []int{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first scene: got %v, want %v", got, want)
+	}
+	doc.Scenes = nil
+	if got, want := doc.SceneRoots(), []int{0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("no scenes: got %v, want %v", got, want)
+	}
+}
+
+func TestNodeTRS(t *testing.T) {
+	doc := loadTest(t, testFiles[0])
+	for _, tc := range []struct {
+		node        int
+		translation mgl.Vec3
+		rotation    mgl.Quat
+		scale       mgl.Vec3
+	}{
+		{0, mgl.Vec3{1, 2, 3}, mgl.QuatRotate(mgl.DegToRad(90), mgl.Vec3{0, 1, 0}), mgl.Vec3{2, 2, 2}},
+		{1, mgl.Vec3{4, 5, 6}, mgl.QuatRotate(mgl.DegToRad(90), mgl.Vec3{1, 0, 0}), mgl.Vec3{1, 2, 3}},
+		{2, mgl.Vec3{}, mgl.QuatIdent(), mgl.Vec3{-2, 1, 1}},
+	} {
+		translation, rotation, scale := doc.Nodes[tc.node].TRS()
+		if !translation.ApproxEqual(tc.translation) {
+			t.Errorf("node %d: got translation %v, want %v", tc.node, translation, tc.translation)
+		}
+		if !rotation.OrientationEqualThreshold(tc.rotation, 1e-5) {
+			t.Errorf("node %d: got rotation %v, want %v", tc.node, rotation, tc.rotation)
+		}
+		if !scale.ApproxEqualThreshold(tc.scale, 1e-5) {
+			t.Errorf("node %d: got scale %v, want %v", tc.node, scale, tc.scale)
+		}
+	}
+
+	var empty Node
+	translation, rotation, scale := empty.TRS()
+	if translation != (mgl.Vec3{}) || rotation != mgl.QuatIdent() || scale != (mgl.Vec3{1, 1, 1}) {
+		t.Errorf("empty node: got %v %v %v, want the identity", translation, rotation, scale)
+	}
+}
+
+func TestBadGLB(t *testing.T) {
+	glb, err := ioutil.ReadFile(testFiles[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	longChunk := append([]byte{}, glb...)
+	binary.LittleEndian.PutUint32(longChunk[12:], uint32(len(glb)))
+	noBin := append([]byte{}, glb[:20+binary.LittleEndian.Uint32(glb[12:])]...)
+	binary.LittleEndian.PutUint32(noBin[8:], uint32(len(noBin)))
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"truncated", glb[:len(glb)-10], "header says"},
+		{"header only", glb[:12], "header says"},
+		{"chunk past the end", longChunk, "runs past the end"},
+		{"no binary chunk", noBin, "no glb binary chunk"},
+	} {
+		_, err := Parse(tc.data, "testdata")
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package gltf
+
+import mgl "github.com/go-gl/mathgl/mgl32"
+
+// TRS is the node's local transform as translation, rotation and scale,
+// applied scale first. A Matrix is decomposed, losing any shear.
+func (me *Node) TRS() (translation mgl.Vec3, rotation mgl.Quat, scale mgl.Vec3) {
+	translation = mgl.Vec3{}
+	rotation = mgl.QuatIdent()
+	scale = mgl.Vec3{1, 1, 1}
+
+	if me.Matrix != nil {
+		m := mgl.Mat4(*me.Matrix) // column major, like glTF
+		translation = m.Col(3).Vec3()
+		var rot mgl.Mat4
+		for c := 0; c < 3; c++ {
+			col := m.Col(c).Vec3()
+			scale[c] = col.Len()
+			if scale[c] != 0 {
+				col = col.Mul(1 / scale[c])
+			}
+			rot.SetCol(c, col.Vec4(0))
+		}
+		rot.SetCol(3, mgl.Vec4{0, 0, 0, 1})
+		if rot.Det() < 0 {
+			// mirrored; put the flip in the scale so the rotation is proper
+			scale[0] = -scale[0]
+			rot.SetCol(0, rot.Col(0).Mul(-1))
+		}
+		rotation = mgl.Mat4ToQuat(rot).Normalize()
+		return translation, rotation, scale
+	}
+
+	if me.Translation != nil {
+		translation = mgl.Vec3(*me.Translation)
+	}
+	if me.Rotation != nil {
+		r := *me.Rotation
+		rotation = mgl.Quat{W: r[3], V: mgl.Vec3{r[0], r[1], r[2]}}.Normalize()
+	}
+	if me.Scale != nil {
+		scale = mgl.Vec3(*me.Scale)
+	}
+	return translation, rotation, scale
+}
@@ -0,0 +1,45 @@
+package golden
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/dcrosby42/go-game-sandbox/window"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// Context opens a hidden width x height window with a software-rendered
+// OpenGL 3.3 context (Mesa llvmpipe) and makes it current on the test's
+// goroutine, which is locked to its OS thread until the test ends. The test
+// is skipped if no such context can be made, eg there's no display; under CI
+// run the tests inside xvfb-run. Set GOLDEN_EGL=1 to create the context
+// through EGL instead.
+//
+// Draw into a helpers.RenderTarget rather than the window, so the result
+// doesn't depend on how the window system treats hidden windows.
+func Context(t testing.TB, width, height int) *glfw.Window {
+	t.Helper()
+	runtime.LockOSThread()
+
+	win, err := window.New(window.Options{
+		Title:      t.Name(),
+		Width:      width,
+		Height:     height,
+		Hidden:     true,
+		SoftwareGL: true,
+		EGL:        os.Getenv("GOLDEN_EGL") != "",
+	})
+	if err != nil {
+		glfw.Terminate()
+		runtime.UnlockOSThread()
+		t.Skipf("golden: no software GL context: %s", err)
+	}
+
+	t.Cleanup(func() {
+		win.Destroy()
+		glfw.Terminate()
+		runtime.UnlockOSThread()
+	})
+	return win
+}
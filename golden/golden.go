@@ -0,0 +1,146 @@
+// Package golden compares rendered images against checked-in "golden" PNGs.
+//
+// Golden images live in testdata/golden/<name>.png next to the test. Run the
+// tests with -update to (re)write them from the current output:
+//
+//	go test -tags golden ./... -args -update
+//
+// When an image doesn't match, the rendered image and a diff image are written
+// alongside the golden one as <name>.got.png and <name>.diff.png.
+package golden
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dcrosby42/go-game-sandbox/helpers"
+)
+
+var update = flag.Bool("update", false, "write golden images instead of comparing against them")
+
+// Dir is where golden images are kept, relative to the test's package directory
+const Dir = "testdata/golden"
+
+// Tolerance says how close an image must be to its golden to match.
+type Tolerance struct {
+	// Channel is the largest difference allowed in any of a pixel's R, G, B
+	// or A values (0-255) before the pixel counts as mismatched
+	Channel uint8
+	// Pixels is how many mismatched pixels are allowed
+	Pixels int
+}
+
+// DefaultTolerance absorbs rounding differences between software rasterizer versions.
+var DefaultTolerance = Tolerance{Channel: 2, Pixels: 0}
+
+// Result is the outcome of Compare.
+type Result struct {
+	Mismatched int   // pixels that differ by more than the Channel tolerance
+	MaxDelta   uint8 // largest channel difference seen
+
+	// Diff shows the want image dimmed to gray, with mismatched pixels in red
+	Diff *image.NRGBA
+}
+
+// Compare checks got against want pixel by pixel. The images must be the same size.
+func Compare(got, want image.Image, tol Tolerance) Result {
+	bounds := want.Bounds()
+	res := Result{Diff: image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))}
+	gotMin := got.Bounds().Min
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			g := color.NRGBAModel.Convert(got.At(gotMin.X+x, gotMin.Y+y)).(color.NRGBA)
+			w := color.NRGBAModel.Convert(want.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+
+			delta := maxDelta(g, w)
+			if delta > res.MaxDelta {
+				res.MaxDelta = delta
+			}
+			if delta > tol.Channel {
+				res.Mismatched++
+				res.Diff.SetNRGBA(x, y, color.NRGBA{255, 0, 0, 255})
+			} else {
+				gray := uint8((int(w.R) + int(w.G) + int(w.B)) / 3 / 4)
+				res.Diff.SetNRGBA(x, y, color.NRGBA{gray, gray, gray, 255})
+			}
+		}
+	}
+	return res
+}
+
+func maxDelta(a, b color.NRGBA) uint8 {
+	d := absDiff(a.R, b.R)
+	if v := absDiff(a.G, b.G); v > d {
+		d = v
+	}
+	if v := absDiff(a.B, b.B); v > d {
+		d = v
+	}
+	if v := absDiff(a.A, b.A); v > d {
+		d = v
+	}
+	return d
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// Check compares got against the golden image called name, failing t if
+// they don't match within tol. With -update it writes got as the new golden instead.
+func Check(t testing.TB, name string, got image.Image, tol Tolerance) {
+	t.Helper()
+	path := filepath.Join(Dir, name+".png")
+
+	if *update {
+		err := os.MkdirAll(Dir, 0755)
+		if err == nil {
+			err = helpers.SavePNG(path, got)
+		}
+		if err != nil {
+			t.Fatalf("golden: writing %s: %s", path, err)
+		}
+		t.Logf("golden: wrote %s", path)
+		return
+	}
+
+	want, err := readPNG(path)
+	if err != nil {
+		t.Fatalf("golden: no golden image for %q (%s); run with -update to create it", name, err)
+	}
+
+	gotPath := filepath.Join(Dir, name+".got.png")
+	diffPath := filepath.Join(Dir, name+".diff.png")
+	if got.Bounds().Size() != want.Bounds().Size() {
+		helpers.SavePNG(gotPath, got)
+		t.Fatalf("golden: %s is %v, golden is %v; wrote %s", name, got.Bounds().Size(), want.Bounds().Size(), gotPath)
+	}
+
+	res := Compare(got, want, tol)
+	if res.Mismatched <= tol.Pixels {
+		os.Remove(gotPath)
+		os.Remove(diffPath)
+		return
+	}
+	helpers.SavePNG(gotPath, got)
+	helpers.SavePNG(diffPath, res.Diff)
+	t.Errorf("golden: %s has %d mismatched pixels (max channel delta %d, tolerance %+v); wrote %s and %s",
+		name, res.Mismatched, res.MaxDelta, tol, gotPath, diffPath)
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
@@ -0,0 +1,339 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Interpolation is how a Channel gets from one keyframe to the next.
+type Interpolation int
+
+const (
+	InterpLinear Interpolation = iota // rotations are slerped
+	InterpStep
+	InterpCubic // Hermite spline, with in and out tangents stored around each value
+)
+
+// AnimPath is the part of a Node's transform that a Channel animates.
+type AnimPath int
+
+const (
+	AnimTranslation AnimPath = iota // the Node's Location
+	AnimRotation                    // its LocalRotation
+	AnimScale
+)
+
+// Channel animates one part of one Node's transform. Values holds 3 floats
+// (4 for a rotation quaternion as x, y, z, w) per keyframe, or for InterpCubic,
+// three of those per keyframe: in-tangent, value, out-tangent.
+type Channel struct {
+	Target        *Node
+	Path          AnimPath
+	Interpolation Interpolation
+	Times         []float32 // seconds, ascending
+	Values        []float32
+}
+
+// Clip is a named set of Channels played together, such as "walk".
+type Clip struct {
+	Name     string
+	Channels []Channel
+
+	// Duration is the time of the last keyframe
+	Duration float32
+}
+
+// NodePose is a Node's local transform as translation, rotation and scale.
+type NodePose struct {
+	Translation mgl.Vec3
+	Rotation    mgl.Quat
+	Scale       mgl.Vec3
+}
+
+// Pose holds the transforms of the Nodes an animation moves.
+type Pose map[*Node]NodePose
+
+// PoseOf reads a Node's current transform.
+func PoseOf(n *Node) NodePose {
+	return NodePose{Translation: n.Location, Rotation: n.LocalRotation, Scale: n.Scale}
+}
+
+// Apply moves each Node to its pose.
+func (p Pose) Apply() {
+	for n, np := range p {
+		if n.Location == np.Translation && n.LocalRotation == np.Rotation && n.Scale == np.Scale {
+			continue
+		}
+		n.Location = np.Translation
+		n.LocalRotation = np.Rotation
+		n.Scale = np.Scale
+		n.SetDirty()
+	}
+}
+
+// Blend mixes two poses, weight of the way from a to b. A Node in only one
+// of them keeps that transform.
+func Blend(a, b Pose, weight float32) Pose {
+	out := make(Pose, len(a))
+	for n, pa := range a {
+		pb, ok := b[n]
+		if !ok {
+			out[n] = pa
+			continue
+		}
+		out[n] = NodePose{
+			Translation: lerpVec3(pa.Translation, pb.Translation, weight),
+			Rotation:    slerp(pa.Rotation, pb.Rotation, weight),
+			Scale:       lerpVec3(pa.Scale, pb.Scale, weight),
+		}
+	}
+	for n, pb := range b {
+		if _, ok := a[n]; !ok {
+			out[n] = pb
+		}
+	}
+	return out
+}
+
+// Targets lists the Nodes the Clip animates.
+func (c *Clip) Targets() []*Node {
+	seen := make(map[*Node]bool)
+	var nodes []*Node
+	for i := range c.Channels {
+		if n := c.Channels[i].Target; !seen[n] {
+			seen[n] = true
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// Sample sets the poses of the Clip's targets at time t (clamped to the
+// Clip), over whatever pose already holds for them. Parts of a Node's
+// transform that no Channel animates are left as they were.
+func (c *Clip) Sample(t float32, pose Pose) {
+	for i := range c.Channels {
+		ch := &c.Channels[i]
+		np := pose[ch.Target]
+		switch ch.Path {
+		case AnimTranslation:
+			np.Translation = ch.vec3(t)
+		case AnimRotation:
+			np.Rotation = ch.quat(t)
+		case AnimScale:
+			np.Scale = ch.vec3(t)
+		}
+		pose[ch.Target] = np
+	}
+}
+
+func (ch *Channel) width() int {
+	if ch.Path == AnimRotation {
+		return 4
+	}
+	return 3
+}
+
+// value is keyframe k's value; for cubic channels, part 0 is the in-tangent,
+// 1 the value and 2 the out-tangent.
+func (ch *Channel) value(k, part int) []float32 {
+	w := ch.width()
+	if ch.Interpolation == InterpCubic {
+		k = k*3 + part
+	}
+	return ch.Values[k*w : k*w+w]
+}
+
+// sample interpolates into out, which is ch.width() floats.
+func (ch *Channel) sample(t float32, out []float32) {
+	n := len(ch.Times)
+	if n == 0 {
+		return
+	}
+	// k is the last keyframe at or before t
+	k := sort.Search(n, func(i int) bool { return ch.Times[i] > t }) - 1
+	if k < 0 || k >= n-1 || ch.Interpolation == InterpStep {
+		if k < 0 {
+			k = 0
+		}
+		copy(out, ch.value(k, 1))
+		return
+	}
+	dt := ch.Times[k+1] - ch.Times[k]
+	s := (t - ch.Times[k]) / dt
+	switch ch.Interpolation {
+	case InterpCubic:
+		s2, s3 := s*s, s*s*s
+		v0, b0 := ch.value(k, 1), ch.value(k, 2)
+		v1, a1 := ch.value(k+1, 1), ch.value(k+1, 0)
+		for i := range out {
+			out[i] = (2*s3-3*s2+1)*v0[i] + (s3-2*s2+s)*dt*b0[i] +
+				(-2*s3+3*s2)*v1[i] + (s3-s2)*dt*a1[i]
+		}
+	default:
+		v0, v1 := ch.value(k, 1), ch.value(k+1, 1)
+		if ch.Path == AnimRotation {
+			q := slerp(quatOf(v0), quatOf(v1), s)
+			out[0], out[1], out[2], out[3] = q.V[0], q.V[1], q.V[2], q.W
+			return
+		}
+		for i := range out {
+			out[i] = v0[i] + (v1[i]-v0[i])*s
+		}
+	}
+}
+
+func (ch *Channel) vec3(t float32) mgl.Vec3 {
+	var v mgl.Vec3
+	ch.sample(t, v[:])
+	return v
+}
+
+func (ch *Channel) quat(t float32) mgl.Quat {
+	var v [4]float32
+	ch.sample(t, v[:])
+	q := quatOf(v[:])
+	if q.Len() == 0 {
+		return mgl.QuatIdent()
+	}
+	return q.Normalize()
+}
+
+// Validate checks the Channel's Values fit its Times.
+func (ch *Channel) Validate() error {
+	per := ch.width()
+	if ch.Interpolation == InterpCubic {
+		per *= 3
+	}
+	if len(ch.Values) != len(ch.Times)*per {
+		return fmt.Errorf("channel has %d values for %d keyframes, expected %d each", len(ch.Values), len(ch.Times), per)
+	}
+	for i := 1; i < len(ch.Times); i++ {
+		if ch.Times[i] <= ch.Times[i-1] {
+			return fmt.Errorf("channel keyframe times aren't increasing at %d", i)
+		}
+	}
+	return nil
+}
+
+func quatOf(xyzw []float32) mgl.Quat {
+	return mgl.Quat{W: xyzw[3], V: mgl.Vec3{xyzw[0], xyzw[1], xyzw[2]}}
+}
+
+// slerp takes the shorter way round (q and -q are the same rotation)
+func slerp(from, to mgl.Quat, t float32) mgl.Quat {
+	if from.Dot(to) < 0 {
+		to = to.Scale(-1)
+	}
+	return mgl.QuatSlerp(from, to, t)
+}
+
+func lerpVec3(from, to mgl.Vec3, t float32) mgl.Vec3 {
+	return from.Add(to.Sub(from).Mul(t))
+}
+
+// Animator plays Clips on a Node tree, cross-fading from one to the next.
+// Call Tick with each simulation step's dt.
+type Animator struct {
+	// Loop wraps the current Clip's time around; otherwise it holds the last frame
+	Loop bool
+	// Speed scales dt; 1 is normal speed
+	Speed float32
+
+	current, previous playback
+	fade, fadeTime    float32
+
+	// rest is each animated Node's transform from before it was first
+	// animated, the base that Clips are sampled over
+	rest Pose
+}
+
+type playback struct {
+	clip *Clip
+	time float32
+}
+
+func NewAnimator() *Animator {
+	return &Animator{Loop: true, Speed: 1, rest: make(Pose)}
+}
+
+// Current is the Clip playing, or fading in, or nil.
+func (me *Animator) Current() *Clip {
+	return me.current.clip
+}
+
+// Play starts clip from the beginning, blending over from whatever was
+// playing during fade seconds. Playing the current Clip again does nothing.
+func (me *Animator) Play(clip *Clip, fade float32) {
+	if clip == me.current.clip {
+		return
+	}
+	me.previous = me.current
+	me.current = playback{clip: clip}
+	me.fade, me.fadeTime = 0, fade
+	if me.previous.clip == nil || fade <= 0 {
+		me.previous = playback{}
+		me.fadeTime = 0
+	}
+	for _, c := range []*Clip{me.current.clip, me.previous.clip} {
+		if c == nil {
+			continue
+		}
+		for _, n := range c.Targets() {
+			if _, ok := me.rest[n]; !ok {
+				me.rest[n] = PoseOf(n)
+			}
+		}
+	}
+}
+
+// Tick advances the Clips by dt seconds and poses their Nodes.
+func (me *Animator) Tick(dt float32) {
+	if me.current.clip == nil {
+		return
+	}
+	dt *= me.Speed
+	me.advance(&me.current, dt)
+	if me.previous.clip == nil {
+		me.sample(me.current, me.current.clip.Targets()).Apply()
+		return
+	}
+
+	me.advance(&me.previous, dt)
+	me.fade += dt
+	if me.fade >= me.fadeTime {
+		me.previous = playback{}
+		me.sample(me.current, me.current.clip.Targets()).Apply()
+		return
+	}
+	// both poses cover the Nodes of both Clips, so a Node only one of them
+	// animates blends to or from its rest pose
+	targets := append(me.current.clip.Targets(), me.previous.clip.Targets()...)
+	from := me.sample(me.previous, targets)
+	to := me.sample(me.current, targets)
+	Blend(from, to, me.fade/me.fadeTime).Apply()
+}
+
+func (me *Animator) advance(p *playback, dt float32) {
+	p.time += dt
+	if d := p.clip.Duration; p.time > d {
+		if me.Loop && d > 0 {
+			p.time = float32(math.Mod(float64(p.time), float64(d)))
+		} else {
+			p.time = d
+		}
+	}
+}
+
+// sample poses the Clip's targets, over the rest poses of targets.
+func (me *Animator) sample(p playback, targets []*Node) Pose {
+	pose := make(Pose, len(targets))
+	for _, n := range targets {
+		pose[n] = me.rest[n]
+	}
+	p.clip.Sample(p.time, pose)
+	return pose
+}
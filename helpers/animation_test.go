@@ -0,0 +1,309 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func near32(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func nearVec3(a, b mgl.Vec3) bool {
+	return a.Sub(b).Len() < 1e-5
+}
+
+func rotY(degrees float32) mgl.Quat {
+	return mgl.QuatRotate(mgl.DegToRad(degrees), mgl.Vec3{0, 1, 0})
+}
+
+func quatValues(qs ...mgl.Quat) []float32 {
+	var values []float32
+	for _, q := range qs {
+		values = append(values, q.V[0], q.V[1], q.V[2], q.W)
+	}
+	return values
+}
+
+func TestChannelSample(t *testing.T) {
+	linear := Channel{
+		Path:   AnimTranslation,
+		Times:  []float32{1, 2, 4},
+		Values: []float32{0, 0, 0, 10, 0, 0, 10, 20, 0},
+	}
+	step := linear
+	step.Interpolation = InterpStep
+	// each keyframe is in-tangent, value, out-tangent
+	cubic := Channel{
+		Path:          AnimTranslation,
+		Interpolation: InterpCubic,
+		Times:         []float32{0, 2},
+		Values: []float32{
+			0, 0, 0, 0, 0, 0, 1, 0, 0,
+			0, 0, 0, 1, 0, 0, 0, 0, 0,
+		},
+	}
+	flat := cubic
+	flat.Values = []float32{
+		0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 1, 0, 0, 0, 0, 0,
+	}
+
+	for _, tc := range []struct {
+		name string
+		ch   *Channel
+		t    float32
+		want mgl.Vec3
+	}{
+		{"linear before the start", &linear, 0, mgl.Vec3{0, 0, 0}},
+		{"linear at a keyframe", &linear, 2, mgl.Vec3{10, 0, 0}},
+		{"linear between", &linear, 1.5, mgl.Vec3{5, 0, 0}},
+		{"linear in a longer gap", &linear, 3, mgl.Vec3{10, 10, 0}},
+		{"linear at the end", &linear, 4, mgl.Vec3{10, 20, 0}},
+		{"linear past the end", &linear, 9, mgl.Vec3{10, 20, 0}},
+		{"step between", &step, 1.9, mgl.Vec3{0, 0, 0}},
+		{"step at a keyframe", &step, 2, mgl.Vec3{10, 0, 0}},
+		{"step in a longer gap", &step, 3.9, mgl.Vec3{10, 0, 0}},
+		{"cubic at the start", &cubic, 0, mgl.Vec3{0, 0, 0}},
+		// h00 v0 + h10 dt b0 + h01 v1 + h11 dt a1 = 0 + 0.125*2*1 + 0.5*1 + 0
+		{"cubic halfway", &cubic, 1, mgl.Vec3{0.75, 0, 0}},
+		{"cubic at the end", &cubic, 2, mgl.Vec3{1, 0, 0}},
+		{"cubic with flat tangents, halfway", &flat, 1, mgl.Vec3{0.5, 0, 0}},
+		{"cubic with flat tangents, eased in", &flat, 0.5, mgl.Vec3{0.15625, 0, 0}},
+	} {
+		if got := tc.ch.vec3(tc.t); !nearVec3(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestChannelSampleRotation(t *testing.T) {
+	ch := Channel{
+		Path:   AnimRotation,
+		Times:  []float32{0, 1},
+		Values: quatValues(rotY(0), rotY(90)),
+	}
+	if got := ch.quat(0.5); !got.OrientationEqualThreshold(rotY(45), 1e-5) {
+		t.Errorf("got %v, want 45 degrees about y", got)
+	}
+	if got := ch.quat(0.5); !near32(got.Len(), 1) {
+		t.Errorf("got %v, want a unit quaternion", got)
+	}
+
+	// the same end rotation, the other way up, still goes the short way
+	ch.Values = quatValues(rotY(0), rotY(90).Scale(-1))
+	if got := ch.quat(0.5); !got.OrientationEqualThreshold(rotY(45), 1e-5) {
+		t.Errorf("negated end: got %v, want 45 degrees about y", got)
+	}
+
+	ch.Interpolation = InterpStep
+	if got := ch.quat(0.99); !got.OrientationEqualThreshold(rotY(0), 1e-5) {
+		t.Errorf("step: got %v, want no rotation", got)
+	}
+}
+
+func TestSlerpShortestPath(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		from, to mgl.Quat
+		want     mgl.Quat
+	}{
+		{"quarter turn", rotY(0), rotY(90), rotY(45)},
+		{"negated end", rotY(0), rotY(90).Scale(-1), rotY(45)},
+		{"negated start", rotY(0).Scale(-1), rotY(90), rotY(45)},
+		// 350 degrees round is 10 degrees back, so halfway is at -5
+		{"most of the way round", rotY(0), rotY(350), rotY(-5)},
+	} {
+		got := slerp(tc.from, tc.to, 0.5)
+		if !got.OrientationEqualThreshold(tc.want, 1e-5) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBlend(t *testing.T) {
+	shared, onlyA, onlyB := NewNode("shared"), NewNode("a"), NewNode("b")
+	a := Pose{
+		shared: {Translation: mgl.Vec3{0, 0, 0}, Rotation: rotY(0), Scale: mgl.Vec3{1, 1, 1}},
+		onlyA:  {Translation: mgl.Vec3{1, 2, 3}, Rotation: rotY(10), Scale: mgl.Vec3{1, 1, 1}},
+	}
+	b := Pose{
+		shared: {Translation: mgl.Vec3{4, 0, -8}, Rotation: rotY(-90), Scale: mgl.Vec3{3, 1, 1}},
+		onlyB:  {Translation: mgl.Vec3{5, 6, 7}, Rotation: rotY(20), Scale: mgl.Vec3{2, 2, 2}},
+	}
+	blended := Blend(a, b, 0.25)
+	if len(blended) != 3 {
+		t.Errorf("got %d nodes, want 3", len(blended))
+	}
+	got := blended[shared]
+	if !nearVec3(got.Translation, mgl.Vec3{1, 0, -2}) {
+		t.Errorf("got translation %v, want {1,0,-2}", got.Translation)
+	}
+	if !got.Rotation.OrientationEqualThreshold(rotY(-22.5), 1e-5) {
+		t.Errorf("got rotation %v, want -22.5 degrees about y", got.Rotation)
+	}
+	if !nearVec3(got.Scale, mgl.Vec3{1.5, 1, 1}) {
+		t.Errorf("got scale %v, want {1.5,1,1}", got.Scale)
+	}
+	if blended[onlyA] != a[onlyA] || blended[onlyB] != b[onlyB] {
+		t.Errorf("a node in only one pose didn't keep its transform")
+	}
+
+	for _, weight := range []float32{0, 1} {
+		want := a[shared]
+		if weight == 1 {
+			want = b[shared]
+		}
+		got := Blend(a, b, weight)[shared]
+		if !nearVec3(got.Translation, want.Translation) || !got.Rotation.OrientationEqualThreshold(want.Rotation, 1e-5) {
+			t.Errorf("weight %v: got %v, want %v", weight, got, want)
+		}
+	}
+}
+
+// slideClip moves n along x from 0 at time 0 to length at time 1 second,
+// lasting duration.
+func slideClip(name string, n *Node, length, duration float32) *Clip {
+	return &Clip{
+		Name: name,
+		Channels: []Channel{{
+			Target: n,
+			Path:   AnimTranslation,
+			Times:  []float32{0, 1, duration},
+			Values: []float32{0, 0, 0, length, 0, 0, length, 0, 0},
+		}},
+		Duration: duration,
+	}
+}
+
+func TestAnimatorPlay(t *testing.T) {
+	n := NewNode("n")
+	n.Scale = mgl.Vec3{2, 2, 2}
+	clip := slideClip("slide", n, 10, 2)
+
+	a := NewAnimator()
+	a.Play(clip, 1)
+	if a.Current() != clip {
+		t.Errorf("current is %v, want the clip", a.Current())
+	}
+	a.Tick(0.25)
+	if !nearVec3(n.Location, mgl.Vec3{2.5, 0, 0}) {
+		t.Errorf("at 0.25s: got %v, want {2.5,0,0}", n.Location)
+	}
+	if n.Scale != (mgl.Vec3{2, 2, 2}) {
+		t.Errorf("got scale %v; an unanimated part should keep its rest pose", n.Scale)
+	}
+	// the Node's world transform follows
+	if got := n.WorldTransform().Col(3).Vec3(); !nearVec3(got, mgl.Vec3{2.5, 0, 0}) {
+		t.Errorf("world transform at %v, want {2.5,0,0}", got)
+	}
+
+	a.Speed = 2
+	a.Tick(0.25)
+	if !nearVec3(n.Location, mgl.Vec3{7.5, 0, 0}) {
+		t.Errorf("at double speed: got %v, want {7.5,0,0}", n.Location)
+	}
+}
+
+func TestAnimatorLoop(t *testing.T) {
+	n := NewNode("n")
+	clip := slideClip("slide", n, 10, 2)
+	for _, tc := range []struct {
+		name string
+		loop bool
+		dt   float32
+		want float32
+	}{
+		{"looping, once round", true, 2.5, 5},
+		{"looping, many times round", true, 1000.5, 5},
+		{"looping, exactly at the end", true, 2, 10},
+		{"held", false, 2.5, 10},
+		{"held, long after", false, 1000.5, 10},
+	} {
+		a := NewAnimator()
+		a.Loop = tc.loop
+		a.Play(clip, 0)
+		a.Tick(tc.dt)
+		if !near32(n.Location[0], tc.want) {
+			t.Errorf("%s: got x=%v, want %v", tc.name, n.Location[0], tc.want)
+		}
+	}
+}
+
+func TestAnimatorCrossFade(t *testing.T) {
+	n, other := NewNode("n"), NewNode("other")
+	other.Location = mgl.Vec3{0, 1, 0}
+	from := slideClip("from", n, -10, 1)
+	to := &Clip{
+		Name: "to",
+		Channels: []Channel{
+			{Target: n, Path: AnimTranslation, Times: []float32{0}, Values: []float32{10, 0, 0}},
+			{Target: other, Path: AnimTranslation, Times: []float32{0}, Values: []float32{0, 5, 0}},
+		},
+	}
+
+	a := NewAnimator()
+	a.Loop = false
+	a.Play(from, 0)
+	a.Tick(1)
+	if !nearVec3(n.Location, mgl.Vec3{-10, 0, 0}) {
+		t.Fatalf("before the fade: got %v, want {-10,0,0}", n.Location)
+	}
+
+	a.Play(to, 2)
+	if a.Current() != to {
+		t.Errorf("current is %v, want the clip fading in", a.Current())
+	}
+	a.Tick(0.5)
+	// a quarter of the way from -10 to 10; other, animated by only the new
+	// clip, is a quarter of the way from its rest pose
+	if !nearVec3(n.Location, mgl.Vec3{-5, 0, 0}) {
+		t.Errorf("a quarter through the fade: got %v, want {-5,0,0}", n.Location)
+	}
+	if !nearVec3(other.Location, mgl.Vec3{0, 2, 0}) {
+		t.Errorf("a quarter through the fade: other at %v, want {0,2,0}", other.Location)
+	}
+
+	a.Tick(1)
+	if !nearVec3(n.Location, mgl.Vec3{5, 0, 0}) {
+		t.Errorf("three quarters through the fade: got %v, want {5,0,0}", n.Location)
+	}
+
+	// finishing exactly at fadeTime
+	a.Tick(0.5)
+	if !nearVec3(n.Location, mgl.Vec3{10, 0, 0}) || !nearVec3(other.Location, mgl.Vec3{0, 5, 0}) {
+		t.Errorf("after the fade: got %v and %v, want {10,0,0} and {0,5,0}", n.Location, other.Location)
+	}
+	if a.previous.clip != nil {
+		t.Errorf("the faded out clip is still playing")
+	}
+
+	// playing the current clip again changes nothing
+	a.Play(to, 2)
+	a.Tick(0.1)
+	if !nearVec3(n.Location, mgl.Vec3{10, 0, 0}) {
+		t.Errorf("replaying: got %v, want {10,0,0}", n.Location)
+	}
+}
+
+func TestChannelValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ch   Channel
+		ok   bool
+	}{
+		{"translation", Channel{Path: AnimTranslation, Times: []float32{0, 1}, Values: make([]float32, 6)}, true},
+		{"rotation", Channel{Path: AnimRotation, Times: []float32{0, 1}, Values: make([]float32, 8)}, true},
+		{"cubic", Channel{Path: AnimScale, Interpolation: InterpCubic, Times: []float32{0, 1}, Values: make([]float32, 18)}, true},
+		{"too few values", Channel{Path: AnimRotation, Times: []float32{0, 1}, Values: make([]float32, 6)}, false},
+		{"cubic without tangents", Channel{Path: AnimScale, Interpolation: InterpCubic, Times: []float32{0, 1}, Values: make([]float32, 6)}, false},
+		{"times going backwards", Channel{Path: AnimTranslation, Times: []float32{1, 0}, Values: make([]float32, 6)}, false},
+		{"repeated time", Channel{Path: AnimTranslation, Times: []float32{1, 1}, Values: make([]float32, 6)}, false},
+	} {
+		if err := tc.ch.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s: got error %v, want ok %v", tc.name, err, tc.ok)
+		}
+	}
+}
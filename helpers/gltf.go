@@ -0,0 +1,601 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/dcrosby42/go-game-sandbox/gltf"
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// GLTFMaterial is a glTF material's metallic-roughness parameters, and its
+// base color texture.
+type GLTFMaterial struct {
+	Name      string
+	BaseColor mgl.Vec4
+	// BaseColorTexture is the GL texture, or 0 if none or not yet uploaded
+	BaseColorTexture uint32
+	Metallic         float32
+	Roughness        float32
+	DoubleSided      bool
+	Blend            bool // alphaMode BLEND
+}
+
+// GLTFScene is a glTF file's default scene as a Node tree. Each glTF node is a
+// Node with its translation, rotation and scale in Location, LocalRotation and
+// Scale. A mesh of one primitive is the Node's Renderable; with more, each
+// primitive gets a child Node. Skinned meshes get a Skin of joint Nodes.
+type GLTFScene struct {
+	Root *Node
+
+	// Materials has the material of each Renderable in the tree
+	Materials map[*Renderable]*GLTFMaterial
+
+	// Clips are the file's animations, to play with an Animator
+	Clips []*Clip
+
+	// uploads are the GL work left by ReadGLTF for Upload
+	uploads []func()
+}
+
+// Clip finds an animation by name, or returns nil.
+func (s *GLTFScene) Clip(name string) *Clip {
+	for _, c := range s.Clips {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// LoadGLTF loads a .gltf or .glb file's default scene and its animations. The
+// Renderables' Colors are their materials' base color factors. Requires a
+// current GL context.
+func LoadGLTF(path string) (*GLTFScene, error) {
+	scene, err := ReadGLTF(path)
+	if err != nil {
+		return nil, err
+	}
+	scene.Upload()
+	return scene, nil
+}
+
+// ReadGLTF is the part of LoadGLTF that reads and decodes the file. It makes
+// no GL calls, so it's safe to run off the main thread; the scene can't be
+// drawn until it's been through Upload.
+func ReadGLTF(path string) (*GLTFScene, error) {
+	doc, err := gltf.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &gltfLoader{
+		doc:       doc,
+		path:      path,
+		scene:     &GLTFScene{Root: NewNode(path), Materials: make(map[*Renderable]*GLTFMaterial)},
+		nodes:     make(map[int]*Node),
+		meshes:    make(map[meshKey][]*Renderable),
+		materials: make(map[int]*GLTFMaterial),
+		textures:  make(map[int]*uint32),
+		skins:     make(map[int]*Skin),
+	}
+	for _, i := range doc.SceneRoots() {
+		if err := l.node(l.scene.Root, i, 0); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	// joints can be anywhere in the tree, so skins wait until it's built
+	for _, use := range l.skinned {
+		skin, err := l.skin(use.skin)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		use.r.Skin = skin
+	}
+	for i := range doc.Animations {
+		clip, err := l.animation(i)
+		if err != nil {
+			return nil, fmt.Errorf("%s: animation %d: %s", path, i, err)
+		}
+		l.scene.Clips = append(l.scene.Clips, clip)
+	}
+	return l.scene, nil
+}
+
+// Upload sends the meshes and textures read by ReadGLTF to GL. Requires a
+// current GL context.
+func (s *GLTFScene) Upload() {
+	for _, upload := range s.uploads {
+		upload()
+	}
+	s.uploads = nil
+}
+
+// Instance copies the scene's Nodes, Skins and Clips so the copy can be placed
+// and animated on its own. The copy shares the Renderables' GL buffers, and
+// the Materials, with the original.
+func (s *GLTFScene) Instance() *GLTFScene {
+	in := &GLTFScene{Materials: make(map[*Renderable]*GLTFMaterial)}
+	nodes := make(map[*Node]*Node)
+	var copyNode func(n *Node) *Node
+	copyNode = func(n *Node) *Node {
+		c := NewNode(n.Name)
+		c.Positioner = n.Positioner
+		nodes[n] = c
+		for _, child := range n.children {
+			c.AddChild(copyNode(child))
+		}
+		return c
+	}
+	in.Root = copyNode(s.Root)
+
+	// Renderables once the tree's built, as joints can be anywhere in it
+	renderables := make(map[*Renderable]*Renderable)
+	skins := make(map[*Skin]*Skin)
+	s.Root.Walk(func(n *Node) bool {
+		r := n.Renderable
+		if r == nil {
+			return true
+		}
+		c, ok := renderables[r]
+		if !ok {
+			copied := *r
+			c = &copied
+			if r.Skin != nil {
+				if c.Skin = skins[r.Skin]; c.Skin == nil {
+					joints := make([]*Node, len(r.Skin.Joints))
+					for i, j := range r.Skin.Joints {
+						joints[i] = nodes[j]
+					}
+					c.Skin = NewSkin(joints, r.Skin.InverseBind)
+					skins[r.Skin] = c.Skin
+				}
+			}
+			if m, ok := s.Materials[r]; ok {
+				in.Materials[c] = m
+			}
+			renderables[r] = c
+		}
+		nodes[n].Renderable = c
+		return true
+	})
+
+	for _, clip := range s.Clips {
+		c := *clip
+		c.Channels = make([]Channel, len(clip.Channels))
+		for i, ch := range clip.Channels {
+			ch.Target = nodes[ch.Target]
+			c.Channels[i] = ch
+		}
+		in.Clips = append(in.Clips, &c)
+	}
+	return in
+}
+
+// Delete frees the GL buffers and textures of the scene, and of its Instances.
+func (s *GLTFScene) Delete() {
+	deleted := make(map[*Renderable]bool)
+	s.Root.Walk(func(n *Node) bool {
+		if r := n.Renderable; r != nil && !deleted[r] {
+			r.Delete()
+			deleted[r] = true
+		}
+		return true
+	})
+	textures := make(map[uint32]bool)
+	for _, m := range s.Materials {
+		if tex := m.BaseColorTexture; tex != 0 && !textures[tex] {
+			gl.DeleteTextures(1, &tex)
+			textures[tex] = true
+		}
+	}
+}
+
+type gltfLoader struct {
+	doc   *gltf.Document
+	path  string
+	scene *GLTFScene
+
+	// nodes are the Nodes built, by glTF index
+	nodes map[int]*Node
+
+	// caches, by glTF index, so shared meshes and textures are built once
+	meshes    map[meshKey][]*Renderable
+	materials map[int]*GLTFMaterial
+	textures  map[int]*uint32 // filled in by Upload
+	skins     map[int]*Skin
+
+	// skinned are the Renderables waiting for their Skins
+	skinned []skinUse
+}
+
+// meshKey is a mesh, and the skin it's drawn with or -1; a mesh is built
+// again for each skin
+type meshKey struct {
+	mesh, skin int
+}
+
+type skinUse struct {
+	r    *Renderable
+	skin int
+}
+
+// maxGLTFDepth guards against node cycles, which glTF forbids
+const maxGLTFDepth = 256
+
+func (l *gltfLoader) node(parent *Node, i int, depth int) error {
+	if i < 0 || i >= len(l.doc.Nodes) {
+		return fmt.Errorf("no node %d", i)
+	}
+	if depth > maxGLTFDepth {
+		return fmt.Errorf("nodes nested too deep at %d, is there a cycle?", i)
+	}
+	gn := &l.doc.Nodes[i]
+	name := gn.Name
+	if name == "" {
+		name = fmt.Sprintf("node %d", i)
+	}
+	n := NewNode(name)
+	t, r, s := gn.TRS()
+	n.Location = t
+	n.Rotation = mgl.QuatIdent()
+	n.LocalRotation = r
+	n.Scale = s
+	parent.AddChild(n)
+	l.nodes[i] = n
+
+	if gn.Mesh != nil {
+		key := meshKey{mesh: *gn.Mesh, skin: -1}
+		if gn.Skin != nil {
+			key.skin = *gn.Skin
+		}
+		renderables, err := l.mesh(key)
+		if err != nil {
+			return err
+		}
+		if len(renderables) == 1 {
+			n.Renderable = renderables[0]
+		} else {
+			for p, r := range renderables {
+				child := NewNode(fmt.Sprintf("%s primitive %d", name, p))
+				child.Renderable = r
+				n.AddChild(child)
+			}
+		}
+	}
+	for _, c := range gn.Children {
+		if err := l.node(n, c, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mesh builds a Renderable for each of the mesh's triangle primitives.
+func (l *gltfLoader) mesh(key meshKey) ([]*Renderable, error) {
+	if rs, ok := l.meshes[key]; ok {
+		return rs, nil
+	}
+	i := key.mesh
+	if i < 0 || i >= len(l.doc.Meshes) {
+		return nil, fmt.Errorf("no mesh %d", i)
+	}
+	var renderables []*Renderable
+	for p, prim := range l.doc.Meshes[i].Primitives {
+		if !prim.TriangleMode() {
+			fmt.Printf("!! ERROR ReadGLTF(%q) mesh %d primitive %d: mode %d isn't supported, skipping\n", l.path, i, p, *prim.Mode)
+			continue
+		}
+		r, err := l.primitive(&prim)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d primitive %d: %s", i, p, err)
+		}
+		if key.skin >= 0 {
+			if err := l.skinWeights(r, &prim); err != nil {
+				return nil, fmt.Errorf("mesh %d primitive %d: %s", i, p, err)
+			}
+			l.skinned = append(l.skinned, skinUse{r: r, skin: key.skin})
+		}
+		renderables = append(renderables, r)
+	}
+	l.meshes[key] = renderables
+	return renderables, nil
+}
+
+func (l *gltfLoader) primitive(prim *gltf.Primitive) (*Renderable, error) {
+	pos, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return nil, fmt.Errorf("no POSITION")
+	}
+	verts, n, err := l.doc.ReadFloats(pos)
+	if err != nil {
+		return nil, err
+	}
+	if n != 3 {
+		return nil, fmt.Errorf("POSITION has %d components", n)
+	}
+	count := len(verts) / 3
+
+	var indexes []uint32
+	if prim.Indices != nil {
+		if indexes, err = l.doc.ReadIndices(*prim.Indices); err != nil {
+			return nil, err
+		}
+		for _, ix := range indexes {
+			if int(ix) >= count {
+				return nil, fmt.Errorf("index %d out of range (have %d vertices)", ix, count)
+			}
+		}
+	} else {
+		indexes = make([]uint32, count)
+		for v := range indexes {
+			indexes[v] = uint32(v)
+		}
+	}
+
+	var normals []float32
+	if a, ok := prim.Attributes["NORMAL"]; ok {
+		if normals, n, err = l.doc.ReadFloats(a); err != nil {
+			return nil, err
+		}
+		if n != 3 || len(normals) != len(verts) {
+			return nil, fmt.Errorf("NORMAL doesn't match POSITION")
+		}
+	} else {
+		normals = smoothNormals(verts, indexes)
+	}
+
+	uvs := make([]float32, count*2)
+	if a, ok := prim.Attributes["TEXCOORD_0"]; ok {
+		tc, n, err := l.doc.ReadFloats(a)
+		if err != nil {
+			return nil, err
+		}
+		if n != 2 || len(tc) != len(uvs) {
+			return nil, fmt.Errorf("TEXCOORD_0 doesn't match POSITION")
+		}
+		// glTF's uv origin is the top left; our textures are flipped for GL's bottom left
+		for v := 0; v < count; v++ {
+			uvs[v*2] = tc[v*2]
+			uvs[v*2+1] = 1 - tc[v*2+1]
+		}
+	}
+
+	r := NewRenderable()
+	l.scene.uploads = append(l.scene.uploads, func() { r.upload(verts, uvs, normals, indexes) })
+	r.Color = mgl.Vec4{1, 1, 1, 1}
+	if prim.Material != nil {
+		m, err := l.material(*prim.Material)
+		if err != nil {
+			return nil, err
+		}
+		r.Color = m.BaseColor
+		l.scene.Materials[r] = m
+	}
+	return r, nil
+}
+
+func (l *gltfLoader) material(i int) (*GLTFMaterial, error) {
+	if m, ok := l.materials[i]; ok {
+		return m, nil
+	}
+	if i < 0 || i >= len(l.doc.Materials) {
+		return nil, fmt.Errorf("no material %d", i)
+	}
+	gm := &l.doc.Materials[i]
+	pbr := gm.PbrMetallicRoughness
+	m := &GLTFMaterial{
+		Name:        gm.Name,
+		BaseColor:   mgl.Vec4(pbr.BaseColor()),
+		Metallic:    pbr.Metallic(),
+		Roughness:   pbr.Roughness(),
+		DoubleSided: gm.DoubleSided,
+		Blend:       gm.AlphaMode == "BLEND",
+	}
+	if pbr != nil && pbr.BaseColorTexture != nil {
+		tex, err := l.texture(pbr.BaseColorTexture.Index)
+		if err != nil {
+			return nil, fmt.Errorf("material %d: %s", i, err)
+		}
+		// (after the texture's own upload)
+		l.scene.uploads = append(l.scene.uploads, func() { m.BaseColorTexture = *tex })
+	}
+	l.materials[i] = m
+	return m, nil
+}
+
+// skinWeights reads a skinned primitive's JOINTS_0 and WEIGHTS_0 for upload.
+func (l *gltfLoader) skinWeights(r *Renderable, prim *gltf.Primitive) error {
+	ja, ok := prim.Attributes["JOINTS_0"]
+	wa, ok2 := prim.Attributes["WEIGHTS_0"]
+	if !ok || !ok2 {
+		return fmt.Errorf("skinned, but missing JOINTS_0 or WEIGHTS_0")
+	}
+	joints, n, err := l.doc.ReadFloats(ja)
+	if err != nil {
+		return err
+	}
+	weights, n2, err := l.doc.ReadFloats(wa)
+	if err != nil {
+		return err
+	}
+	count := l.doc.Accessors[prim.Attributes["POSITION"]].Count
+	if n != 4 || n2 != 4 || len(joints) != count*4 || len(weights) != count*4 {
+		return fmt.Errorf("JOINTS_0 and WEIGHTS_0 must be a VEC4 per vertex")
+	}
+	l.scene.uploads = append(l.scene.uploads, func() { r.SetSkinWeights(joints, weights) })
+	return nil
+}
+
+// skin builds a Skin from the joint Nodes, which must be in the scene.
+func (l *gltfLoader) skin(i int) (*Skin, error) {
+	if s, ok := l.skins[i]; ok {
+		return s, nil
+	}
+	if i < 0 || i >= len(l.doc.Skins) {
+		return nil, fmt.Errorf("no skin %d", i)
+	}
+	gs := &l.doc.Skins[i]
+	joints := make([]*Node, len(gs.Joints))
+	for j, ni := range gs.Joints {
+		if joints[j] = l.nodes[ni]; joints[j] == nil {
+			return nil, fmt.Errorf("skin %d: joint node %d isn't in the scene", i, ni)
+		}
+	}
+	if len(joints) > MaxJoints {
+		return nil, fmt.Errorf("skin %d has %d joints, the shader takes at most %d", i, len(joints), MaxJoints)
+	}
+	var inverseBind []mgl.Mat4
+	if gs.InverseBindMatrices != nil {
+		floats, n, err := l.doc.ReadFloats(*gs.InverseBindMatrices)
+		if err != nil {
+			return nil, err
+		}
+		if n != 16 || len(floats) != len(joints)*16 {
+			return nil, fmt.Errorf("skin %d: inverseBindMatrices must be a MAT4 per joint", i)
+		}
+		inverseBind = make([]mgl.Mat4, len(joints))
+		for j := range inverseBind {
+			copy(inverseBind[j][:], floats[j*16:]) // both column major
+		}
+	}
+	s := NewSkin(joints, inverseBind)
+	l.skins[i] = s
+	return s, nil
+}
+
+var gltfPaths = map[string]AnimPath{
+	gltf.PathTranslation: AnimTranslation,
+	gltf.PathRotation:    AnimRotation,
+	gltf.PathScale:       AnimScale,
+}
+
+var gltfInterpolations = map[string]Interpolation{
+	"":                            InterpLinear,
+	gltf.InterpolationLinear:      InterpLinear,
+	gltf.InterpolationStep:        InterpStep,
+	gltf.InterpolationCubicSpline: InterpCubic,
+}
+
+// animation builds a Clip from an animation's channels that target Nodes
+// in the scene.
+func (l *gltfLoader) animation(i int) (*Clip, error) {
+	ga := &l.doc.Animations[i]
+	clip := &Clip{Name: ga.Name}
+	if clip.Name == "" {
+		clip.Name = fmt.Sprintf("animation %d", i)
+	}
+	for c, gc := range ga.Channels {
+		if gc.Target.Node == nil || l.nodes[*gc.Target.Node] == nil {
+			continue
+		}
+		path, ok := gltfPaths[gc.Target.Path]
+		if !ok {
+			fmt.Printf("!! ERROR ReadGLTF(%q) animation %d channel %d: path %q isn't supported, skipping\n", l.path, i, c, gc.Target.Path)
+			continue
+		}
+		if gc.Sampler < 0 || gc.Sampler >= len(ga.Samplers) {
+			return nil, fmt.Errorf("channel %d: no sampler %d", c, gc.Sampler)
+		}
+		gs := ga.Samplers[gc.Sampler]
+		interp, ok := gltfInterpolations[gs.Interpolation]
+		if !ok {
+			return nil, fmt.Errorf("channel %d: unknown interpolation %q", c, gs.Interpolation)
+		}
+		times, _, err := l.doc.ReadFloats(gs.Input)
+		if err != nil {
+			return nil, err
+		}
+		values, _, err := l.doc.ReadFloats(gs.Output)
+		if err != nil {
+			return nil, err
+		}
+		ch := Channel{
+			Target:        l.nodes[*gc.Target.Node],
+			Path:          path,
+			Interpolation: interp,
+			Times:         times,
+			Values:        values,
+		}
+		if err := ch.Validate(); err != nil {
+			return nil, fmt.Errorf("channel %d: %s", c, err)
+		}
+		if n := len(times); n > 0 && times[n-1] > clip.Duration {
+			clip.Duration = times[n-1]
+		}
+		clip.Channels = append(clip.Channels, ch)
+	}
+	return clip, nil
+}
+
+// texture decodes a texture's image, from its file or from its embedded data.
+// The GL texture is filled in by Upload.
+func (l *gltfLoader) texture(i int) (*uint32, error) {
+	if tex, ok := l.textures[i]; ok {
+		return tex, nil
+	}
+	if i < 0 || i >= len(l.doc.Textures) || l.doc.Textures[i].Source == nil {
+		return nil, fmt.Errorf("no texture %d, or it has no source", i)
+	}
+	path, data, err := l.doc.ImageSource(*l.doc.Textures[i].Source)
+	if err != nil {
+		return nil, err
+	}
+	var img *image.NRGBA
+	if path != "" {
+		img, err = DecodeTextureFile(path)
+	} else {
+		img, err = decodeTexture(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("texture %d: %s", i, err)
+	}
+	tex := new(uint32)
+	l.scene.uploads = append(l.scene.uploads, func() { *tex = UploadTexture(img) })
+	l.textures[i] = tex
+	return tex, nil
+}
+
+// smoothNormals averages the normals of the triangles around each vertex,
+// weighted by area.
+func smoothNormals(verts []float32, indexes []uint32) []float32 {
+	normals := make([]float32, len(verts))
+	at := func(i uint32) mgl.Vec3 {
+		return mgl.Vec3{verts[i*3], verts[i*3+1], verts[i*3+2]}
+	}
+	for t := 0; t+2 < len(indexes); t += 3 {
+		a, b, c := indexes[t], indexes[t+1], indexes[t+2]
+		n := at(b).Sub(at(a)).Cross(at(c).Sub(at(a)))
+		for _, v := range []uint32{a, b, c} {
+			normals[v*3] += n[0]
+			normals[v*3+1] += n[1]
+			normals[v*3+2] += n[2]
+		}
+	}
+	for v := 0; v+2 < len(normals); v += 3 {
+		n := mgl.Vec3{normals[v], normals[v+1], normals[v+2]}
+		if l := n.Len(); l > 0 {
+			n = n.Mul(1 / l)
+		}
+		normals[v], normals[v+1], normals[v+2] = n[0], n[1], n[2]
+	}
+	return normals
+}
+
+// NewMaterial makes a Material for drawing with shader, approximating the
+// metallic-roughness parameters with Blinn-Phong specular, see newDiffuseMaterial.
+func (m *GLTFMaterial) NewMaterial(shader *ShaderProgram) *Material {
+	// rough surfaces have dim, broad highlights; metals reflect more
+	roughness := mgl.Clamp(m.Roughness, 0.05, 1)
+	reflect := (1 - roughness) * (0.04 + 0.96*m.Metallic)
+	shininess := mgl.Clamp(2/float32(math.Pow(float64(roughness), 4))-2, 1, 256)
+
+	mat := newDiffuseMaterial(shader, m.BaseColorTexture, mgl.Vec3{reflect, reflect, reflect}, shininess)
+	mat.Blend = m.Blend
+	if m.DoubleSided {
+		mat.CullFace = 0
+	}
+	return mat
+}
@@ -0,0 +1,100 @@
+package helpers
+
+import (
+	"testing"
+)
+
+const bendyFile = "../box3/models/bendy.gltf"
+
+// findNode looks for a Node by name beneath root
+func findNode(root *Node, name string) *Node {
+	var found *Node
+	root.Walk(func(n *Node) bool {
+		if n.Name == name {
+			found = n
+		}
+		return found == nil
+	})
+	return found
+}
+
+func TestReadGLTF(t *testing.T) {
+	// no GL context here, so this also checks ReadGLTF doesn't need one
+	scene, err := ReadGLTF(bendyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scene.uploads) == 0 {
+		t.Error("nothing waiting for Upload")
+	}
+
+	bendy := findNode(scene.Root, "bendy")
+	if bendy == nil || bendy.Renderable == nil {
+		t.Fatalf("no bendy mesh in the scene")
+	}
+	r := bendy.Renderable
+	if r.Vao != 0 {
+		t.Errorf("Renderable has VAO %d before Upload", r.Vao)
+	}
+	if r.Skin == nil || len(r.Skin.Joints) != 2 || r.Skin.Joints[1] != findNode(scene.Root, "knee") {
+		t.Errorf("Renderable skin is %v, want the hip and knee", r.Skin)
+	}
+	if m := scene.Materials[r]; m == nil || m.Name != "green" {
+		t.Errorf("Renderable material is %v, want green", m)
+	}
+	for _, name := range []string{"bend", "sway"} {
+		if scene.Clip(name) == nil {
+			t.Errorf("no %q clip", name)
+		}
+	}
+}
+
+func TestGLTFInstance(t *testing.T) {
+	scene, err := ReadGLTF(bendyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scene.Root.Walk(func(n *Node) bool {
+		if n.Renderable != nil {
+			n.Renderable.Vao = 7 // as if uploaded
+		}
+		return true
+	})
+	in := scene.Instance()
+
+	knee, inKnee := findNode(scene.Root, "knee"), findNode(in.Root, "knee")
+	if inKnee == nil || inKnee == knee {
+		t.Fatalf("instance's knee is %p, the original's %p", inKnee, knee)
+	}
+	if inKnee.Location != knee.Location {
+		t.Errorf("instance's knee at %v, want %v", inKnee.Location, knee.Location)
+	}
+
+	r, inR := findNode(scene.Root, "bendy").Renderable, findNode(in.Root, "bendy").Renderable
+	if inR == r || inR.Vao != r.Vao {
+		t.Errorf("instance's Renderable should be a copy sharing VAO %d, got VAO %d", r.Vao, inR.Vao)
+	}
+	if inR.Skin == r.Skin || inR.Skin.Joints[1] != inKnee {
+		t.Errorf("instance's skin doesn't use its own joints")
+	}
+	if in.Materials[inR] != scene.Materials[r] {
+		t.Errorf("instance's material %v, want the shared %v", in.Materials[inR], scene.Materials[r])
+	}
+
+	// animating the instance leaves the original be
+	for _, ch := range in.Clip("bend").Channels {
+		if ch.Target != inKnee {
+			t.Errorf("instance's clip targets %q outside the instance", ch.Target.Name)
+		}
+	}
+	before := knee.LocalRotation
+	a := NewAnimator()
+	a.Play(in.Clip("bend"), 0)
+	a.Tick(0.5)
+	if knee.LocalRotation != before {
+		t.Errorf("animating an instance turned the original's knee to %v", knee.LocalRotation)
+	}
+	if inKnee.LocalRotation == before {
+		t.Errorf("instance's knee didn't turn")
+	}
+}
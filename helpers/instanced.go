@@ -0,0 +1,179 @@
+package helpers
+
+import (
+	"github.com/dcrosby42/go-game-sandbox/geom"
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Attributes and uniforms used when drawing an InstancedMesh. A shader can
+// serve both instanced and ordinary drawing by checking UniformInstanced, see
+// box3/shaders/diffuse_texture.vert.glsl.
+const (
+	AttribInstanceModel   = "INSTANCE_MODEL" // mat4, per instance
+	AttribInstanceColor   = "INSTANCE_COLOR" // vec4, per instance
+	UniformViewProjection = "VP_MATRIX"
+	UniformInstanced      = "INSTANCED" // bool, true while drawing instances
+)
+
+// Layout of the per-instance buffer: the model matrix, then the color
+const (
+	instanceFloats           = 16 + 4
+	instanceStride           = instanceFloats * 4
+	instanceColorOffset      = 16 * 4
+	instanceModelAttribSlots = 4 // a mat4 attribute takes four vec4 locations
+)
+
+// Instance is one copy of an InstancedMesh's geometry.
+type Instance struct {
+	Model mgl.Mat4
+	Color mgl.Vec4
+}
+
+// InstancedMesh draws many copies of one Renderable's geometry in a single
+// draw call, each with its own model transform and color, which are kept in a
+// per-instance buffer. The Renderable's own transform, Material and Color are
+// not used.
+type InstancedMesh struct {
+	Mesh     *Renderable
+	Material *Material
+
+	// Color tints every instance, as MATERIAL_DIFFUSE
+	Color mgl.Vec4
+
+	// Vao is separate from the Mesh's, as it also holds the instance attributes
+	Vao         uint32
+	InstanceVBO uint32
+
+	instances []Instance
+	bounds    geom.AABB
+	uploaded  int // instances the buffer has room for
+	dirty     bool
+}
+
+// NewInstancedMesh makes an InstancedMesh, with no instances, from mesh's
+// geometry. Requires a current GL context.
+func NewInstancedMesh(mesh *Renderable) *InstancedMesh {
+	im := &InstancedMesh{Mesh: mesh, Color: mgl.Vec4{1, 1, 1, 1}}
+	gl.GenVertexArrays(1, &im.Vao)
+	gl.GenBuffers(1, &im.InstanceVBO)
+	return im
+}
+
+func (im *InstancedMesh) Instances() []Instance {
+	return im.instances
+}
+
+// SetInstances replaces the instances. They're only uploaded again, at the
+// next Draw, if they've changed.
+func (im *InstancedMesh) SetInstances(instances []Instance) {
+	if len(instances) == len(im.instances) {
+		same := true
+		for i := range instances {
+			if instances[i] != im.instances[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return
+		}
+	}
+	im.instances = append(im.instances[:0], instances...)
+	im.dirty = true
+
+	im.bounds = geom.AABB{}
+	for i, inst := range im.instances {
+		b := im.Mesh.Bounds.Transform(inst.Model)
+		if i == 0 {
+			im.bounds = b
+		} else {
+			im.bounds = im.bounds.Union(b)
+		}
+	}
+}
+
+// upload copies the instances into InstanceVBO, growing it if need be.
+func (im *InstancedMesh) upload() {
+	im.dirty = false
+	if len(im.instances) == 0 {
+		return
+	}
+	data := make([]float32, 0, len(im.instances)*instanceFloats)
+	for _, inst := range im.instances {
+		data = append(data, inst.Model[:]...)
+		data = append(data, inst.Color[:]...)
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, im.InstanceVBO)
+	if len(im.instances) > im.uploaded {
+		gl.BufferData(gl.ARRAY_BUFFER, 4*len(data), gl.Ptr(data), gl.DYNAMIC_DRAW)
+		im.uploaded = len(im.instances)
+	} else {
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, 4*len(data), gl.Ptr(data))
+	}
+}
+
+// Draw draws every instance, unless none of them are in view. The stats count
+// instances.
+func (im *InstancedMesh) Draw(perspective mgl.Mat4, view mgl.Mat4) DrawStats {
+	var stats DrawStats
+	n := len(im.instances)
+	if n == 0 || im.Material == nil {
+		return stats
+	}
+	viewProjection := perspective.Mul4(view)
+	if im.Mesh.BoundingSphere.Radius > 0 {
+		frustum := geom.FrustumOf(viewProjection)
+		if !frustum.IntersectsAABB(im.bounds) {
+			stats.Culled = n
+			return stats
+		}
+	}
+	if im.dirty {
+		im.upload()
+	}
+
+	im.Material.Bind()
+	shader := im.Material.Shader
+	gl.BindVertexArray(im.Vao)
+
+	shader.SetInt(UniformInstanced, 1)
+	shader.SetInt(UniformSkinned, 0)
+	shader.SetMat4(UniformViewProjection, viewProjection)
+	shader.SetVec4(UniformColor, im.Color)
+	shader.SetVec3(UniformCameraPosition, mgl.Vec3{-view[12], -view[13], -view[14]})
+
+	r := im.Mesh
+	shader.EnableAttrib("VERTEX_POSITION", r.VertVBO, 3)
+	shader.EnableAttrib("VERTEX_NORMAL", r.NormsVBO, 3)
+	shader.EnableAttrib("VERTEX_UV_0", r.UvVBO, 2)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, im.InstanceVBO)
+	if loc := shader.AttribLocation(AttribInstanceModel); loc >= 0 {
+		for i := 0; i < instanceModelAttribSlots; i++ {
+			slot := uint32(loc) + uint32(i)
+			gl.EnableVertexAttribArray(slot)
+			gl.VertexAttribPointer(slot, 4, gl.FLOAT, false, instanceStride, gl.PtrOffset(i*16))
+			gl.VertexAttribDivisor(slot, 1)
+		}
+	}
+	if loc := shader.AttribLocation(AttribInstanceColor); loc >= 0 {
+		gl.EnableVertexAttribArray(uint32(loc))
+		gl.VertexAttribPointer(uint32(loc), 4, gl.FLOAT, false, instanceStride, gl.PtrOffset(instanceColorOffset))
+		gl.VertexAttribDivisor(uint32(loc), 1)
+	}
+
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, r.ElementsVBO)
+	gl.DrawElementsInstanced(gl.TRIANGLES, int32(r.FaceCount*3), gl.UNSIGNED_INT, gl.PtrOffset(0), int32(n))
+	gl.BindVertexArray(0)
+
+	stats.Drawn = n
+	return stats
+}
+
+// Delete frees the instance buffer and VAO, but not the Mesh.
+func (im *InstancedMesh) Delete() {
+	gl.DeleteBuffers(1, &im.InstanceVBO)
+	gl.DeleteVertexArrays(1, &im.Vao)
+	im.uploaded = 0
+}
@@ -0,0 +1,270 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Uniforms set per Renderable by Renderable.Draw, rather than by its Material
+const (
+	UniformMVP            = "MVP_MATRIX"
+	UniformMV             = "MV_MATRIX"
+	UniformModel          = "MODEL_MATRIX"
+	UniformNormalMatrix   = "NORMAL_MATRIX" // inverse transpose of the model matrix, for world-space normals
+	UniformColor          = "MATERIAL_DIFFUSE"
+	UniformCameraPosition = "CAMERA_WORLD_POSITION"
+)
+
+// TextureSlot binds a texture to a sampler uniform. Slots are bound to texture
+// units in order, so the first is TEXTURE0.
+type TextureSlot struct {
+	Uniform string
+	Texture uint32
+}
+
+// Material is a shader along with the values for its uniforms and the GL
+// state to draw with. Many Renderables can share one Material.
+type Material struct {
+	Shader *ShaderProgram
+
+	Floats   map[string]float32
+	Vec2s    map[string]mgl.Vec2
+	Vec3s    map[string]mgl.Vec3
+	Vec4s    map[string]mgl.Vec4
+	Mat4s    map[string]mgl.Mat4
+	Textures []TextureSlot
+
+	// Blend enables blending with BlendSrc, BlendDst (eg gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	Blend              bool
+	BlendSrc, BlendDst uint32
+
+	// CullFace is gl.BACK, gl.FRONT, or 0 to draw both sides
+	CullFace uint32
+
+	DepthTest  bool
+	DepthWrite bool
+	DepthFunc  uint32
+}
+
+// NewMaterial makes an opaque, back-face culled, depth tested Material.
+func NewMaterial(shader *ShaderProgram) *Material {
+	return &Material{
+		Shader:     shader,
+		Floats:     make(map[string]float32),
+		Vec2s:      make(map[string]mgl.Vec2),
+		Vec3s:      make(map[string]mgl.Vec3),
+		Vec4s:      make(map[string]mgl.Vec4),
+		Mat4s:      make(map[string]mgl.Mat4),
+		BlendSrc:   gl.SRC_ALPHA,
+		BlendDst:   gl.ONE_MINUS_SRC_ALPHA,
+		CullFace:   gl.BACK,
+		DepthTest:  true,
+		DepthWrite: true,
+		DepthFunc:  gl.LESS,
+	}
+}
+
+// SetTexture puts tex in the slot for the named sampler, adding a slot if needed.
+func (m *Material) SetTexture(uniform string, tex uint32) {
+	for i := range m.Textures {
+		if m.Textures[i].Uniform == uniform {
+			m.Textures[i].Texture = tex
+			return
+		}
+	}
+	m.Textures = append(m.Textures, TextureSlot{Uniform: uniform, Texture: tex})
+}
+
+// Bind uses the Material's shader, applies its GL state and sets its uniforms.
+func (m *Material) Bind() {
+	m.Shader.Use()
+
+	if m.Blend {
+		gl.Enable(gl.BLEND)
+		gl.BlendFunc(m.BlendSrc, m.BlendDst)
+	} else {
+		gl.Disable(gl.BLEND)
+	}
+
+	if m.CullFace != 0 {
+		gl.Enable(gl.CULL_FACE)
+		gl.CullFace(m.CullFace)
+	} else {
+		gl.Disable(gl.CULL_FACE)
+	}
+
+	if m.DepthTest {
+		gl.Enable(gl.DEPTH_TEST)
+		gl.DepthFunc(m.DepthFunc)
+	} else {
+		gl.Disable(gl.DEPTH_TEST)
+	}
+	gl.DepthMask(m.DepthWrite)
+
+	for name, f := range m.Floats {
+		m.Shader.SetFloat(name, f)
+	}
+	for name, v := range m.Vec2s {
+		m.Shader.SetVec2(name, v)
+	}
+	for name, v := range m.Vec3s {
+		m.Shader.SetVec3(name, v)
+	}
+	for name, v := range m.Vec4s {
+		m.Shader.SetVec4(name, v)
+	}
+	for name, mat := range m.Mat4s {
+		m.Shader.SetMat4(name, mat)
+	}
+	for i, slot := range m.Textures {
+		m.Shader.SetTexture(slot.Uniform, uint32(i), slot.Texture)
+	}
+}
+
+// MaterialDef is the declarative form of a Material, as read from a JSON file:
+//
+//	{
+//	  "shader": "diffuse_texture",
+//	  "textures": [{"uniform": "MATERIAL_TEX_0", "path": "assets/crate1_diffuse.png"}],
+//	  "vec4s": {"TINT": [1, 1, 1, 1]},
+//	  "blend": "alpha",
+//	  "cull": "back",
+//	  "depthTest": true,
+//	  "depthWrite": true
+//	}
+//
+// Shader and texture paths are resolved by whoever builds the Material.
+type MaterialDef struct {
+	Shader   string              `json:"shader"`
+	Textures []TextureDef        `json:"textures"`
+	Floats   map[string]float32  `json:"floats"`
+	Vec2s    map[string]mgl.Vec2 `json:"vec2s"`
+	Vec3s    map[string]mgl.Vec3 `json:"vec3s"`
+	Vec4s    map[string]mgl.Vec4 `json:"vec4s"`
+	Mat4s    map[string]mgl.Mat4 `json:"mat4s"`
+
+	// Blend is "" or "none", "alpha" or "additive"
+	Blend string `json:"blend"`
+	// Cull is "" or "back", "front" or "none"
+	Cull string `json:"cull"`
+	// DepthTest and DepthWrite default to true
+	DepthTest  *bool `json:"depthTest"`
+	DepthWrite *bool `json:"depthWrite"`
+}
+
+type TextureDef struct {
+	Uniform string `json:"uniform"`
+	Path    string `json:"path"`
+}
+
+func LoadMaterialDef(path string) (*MaterialDef, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	def, err := ReadMaterialDef(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return def, nil
+}
+
+// ReadMaterialDef parses and validates a MaterialDef. It makes no GL calls.
+func ReadMaterialDef(r io.Reader) (*MaterialDef, error) {
+	def := &MaterialDef{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(def)
+	if err != nil {
+		return nil, err
+	}
+	if def.Shader == "" {
+		return nil, fmt.Errorf("material has no shader")
+	}
+	switch strings.ToLower(def.Blend) {
+	case "", "none", "alpha", "additive":
+	default:
+		return nil, fmt.Errorf("unknown blend mode %q", def.Blend)
+	}
+	switch strings.ToLower(def.Cull) {
+	case "", "back", "front", "none":
+	default:
+		return nil, fmt.Errorf("unknown cull mode %q", def.Cull)
+	}
+	for _, tex := range def.Textures {
+		if tex.Uniform == "" || tex.Path == "" {
+			return nil, fmt.Errorf("texture needs both a uniform and a path: %+v", tex)
+		}
+	}
+	return def, nil
+}
+
+// Build makes a Material from the def, given its loaded shader and textures
+// (keyed by path).
+func (def *MaterialDef) Build(shader *ShaderProgram, textures map[string]uint32) *Material {
+	m := NewMaterial(shader)
+	def.Apply(m, shader, textures)
+	return m
+}
+
+// Apply sets m up according to the def. Used to update a shared Material in
+// place when its shader or textures are reloaded.
+func (def *MaterialDef) Apply(m *Material, shader *ShaderProgram, textures map[string]uint32) {
+	fresh := NewMaterial(shader)
+	fresh.Textures = m.Textures[:0]
+	*m = *fresh
+
+	for _, tex := range def.Textures {
+		m.SetTexture(tex.Uniform, textures[tex.Path])
+	}
+	for name, f := range def.Floats {
+		m.Floats[name] = f
+	}
+	for name, v := range def.Vec2s {
+		m.Vec2s[name] = v
+	}
+	for name, v := range def.Vec3s {
+		m.Vec3s[name] = v
+	}
+	for name, v := range def.Vec4s {
+		m.Vec4s[name] = v
+	}
+	for name, mat := range def.Mat4s {
+		m.Mat4s[name] = mat
+	}
+
+	switch strings.ToLower(def.Blend) {
+	case "alpha":
+		m.Blend, m.BlendSrc, m.BlendDst = true, gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA
+	case "additive":
+		m.Blend, m.BlendSrc, m.BlendDst = true, gl.SRC_ALPHA, gl.ONE
+	default:
+		m.Blend = false
+	}
+	switch strings.ToLower(def.Cull) {
+	case "front":
+		m.CullFace = gl.FRONT
+	case "none":
+		m.CullFace = 0
+	default:
+		m.CullFace = gl.BACK
+	}
+	m.DepthTest = def.DepthTest == nil || *def.DepthTest
+	m.DepthWrite = def.DepthWrite == nil || *def.DepthWrite
+}
+
+// TexturePaths lists the textures the def refers to, in slot order.
+func (def *MaterialDef) TexturePaths() []string {
+	paths := make([]string, 0, len(def.Textures))
+	for _, tex := range def.Textures {
+		paths = append(paths, tex.Path)
+	}
+	return paths
+}
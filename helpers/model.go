@@ -0,0 +1,176 @@
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/dcrosby42/go-game-sandbox/geom"
+	"github.com/dcrosby42/go-game-sandbox/obj"
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// CreateRenderable uploads indexed triangles: verts and normals with 3 floats
+// per vertex, uvs with 2. Its Bounds are those of the verts.
+func CreateRenderable(verts, uvs, normals []float32, indexes []uint32) *Renderable {
+	r := NewRenderable()
+	r.upload(verts, uvs, normals, indexes)
+	return r
+}
+
+// upload fills in r's VAO and VBOs, FaceCount and Bounds
+func (r *Renderable) upload(verts, uvs, normals []float32, indexes []uint32) {
+	const floatSize = 4
+	const uintSize = 4
+
+	if len(verts) == 0 || len(indexes) == 0 {
+		return
+	}
+	gl.GenVertexArrays(1, &r.Vao)
+	r.FaceCount = len(indexes) / 3
+
+	// create a VBO to hold the vertex data
+	gl.GenBuffers(1, &r.VertVBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, r.VertVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, floatSize*len(verts), gl.Ptr(&verts[0]), gl.STATIC_DRAW)
+
+	// create a VBO to hold the uv data
+	if len(uvs) > 0 {
+		gl.GenBuffers(1, &r.UvVBO)
+		gl.BindBuffer(gl.ARRAY_BUFFER, r.UvVBO)
+		gl.BufferData(gl.ARRAY_BUFFER, floatSize*len(uvs), gl.Ptr(&uvs[0]), gl.STATIC_DRAW)
+	}
+
+	// create a VBO to hold the normals data
+	if len(normals) > 0 {
+		gl.GenBuffers(1, &r.NormsVBO)
+		gl.BindBuffer(gl.ARRAY_BUFFER, r.NormsVBO)
+		gl.BufferData(gl.ARRAY_BUFFER, floatSize*len(normals), gl.Ptr(&normals[0]), gl.STATIC_DRAW)
+	}
+
+	// create a VBO to hold the face indexes
+	gl.GenBuffers(1, &r.ElementsVBO)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, r.ElementsVBO)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, uintSize*len(indexes), gl.Ptr(&indexes[0]), gl.STATIC_DRAW)
+
+	r.SetBounds(boundsOf(verts))
+}
+
+// Delete frees the Renderable's VAO and VBOs.
+func (r *Renderable) Delete() {
+	if r.Vao != 0 {
+		gl.DeleteVertexArrays(1, &r.Vao)
+		r.Vao = 0
+	}
+	for _, vbo := range []*uint32{&r.VertVBO, &r.UvVBO, &r.NormsVBO, &r.ElementsVBO, &r.JointsVBO, &r.WeightsVBO} {
+		if *vbo != 0 {
+			gl.DeleteBuffers(1, vbo)
+			*vbo = 0
+		}
+	}
+	r.FaceCount = 0
+}
+
+func boundsOf(verts []float32) geom.AABB {
+	var box geom.AABB
+	for i := 0; i+2 < len(verts); i += 3 {
+		p := mgl.Vec3{verts[i], verts[i+1], verts[i+2]}
+		if i == 0 {
+			box = geom.AABB{Min: p, Max: p}
+		} else {
+			box = box.Union(geom.AABB{Min: p, Max: p})
+		}
+	}
+	return box
+}
+
+// ModelPart is one Renderable of a loaded model, along with the material
+// it's drawn with in the model file.
+type ModelPart struct {
+	Name       string
+	Renderable *Renderable
+
+	// Material is nil if the model didn't give one
+	Material *obj.Material
+
+	// DiffuseMap is the GL texture for the Material's diffuse map, or 0
+	DiffuseMap uint32
+}
+
+// LoadOBJ loads a Wavefront OBJ file, and its materials and their diffuse maps,
+// making a ModelPart for each group and material. Each Renderable's Color is
+// its material's diffuse color and opacity. Requires a current GL context.
+func LoadOBJ(path string) ([]ModelPart, error) {
+	model, err := obj.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	textures := make(map[string]uint32)
+	var parts []ModelPart
+	for _, mesh := range model.Meshes {
+		part := ModelPart{
+			Name:       mesh.Group,
+			Renderable: CreateRenderable(mesh.Positions, mesh.UVs, mesh.Normals, mesh.Indices),
+		}
+		part.Renderable.Color = mgl.Vec4{1, 1, 1, 1}
+		if mesh.Material != "" {
+			m, ok := model.Materials[mesh.Material]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown material %q", path, mesh.Material)
+			}
+			part.Material = m
+			part.Renderable.Color = m.Diffuse.Vec4(m.Opacity)
+			if m.DiffuseMap != "" {
+				tex, ok := textures[m.DiffuseMap]
+				if !ok {
+					tex, err = LoadImageToTexture(m.DiffuseMap)
+					if err != nil {
+						return nil, fmt.Errorf("%s: material %q: %s", path, m.Name, err)
+					}
+					textures[m.DiffuseMap] = tex
+				}
+				part.DiffuseMap = tex
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// NewMaterial makes a Material for drawing the part with shader, see newDiffuseMaterial.
+func (p ModelPart) NewMaterial(shader *ShaderProgram) *Material {
+	if p.Material == nil {
+		return newDiffuseMaterial(shader, p.DiffuseMap, mgl.Vec3{}, 0)
+	}
+	m := newDiffuseMaterial(shader, p.DiffuseMap, p.Material.Specular, p.Material.Shininess)
+	m.Blend = p.Material.Opacity < 1
+	return m
+}
+
+// newDiffuseMaterial makes a Material with the uniforms of box3's
+// diffuse_texture shader. Without a diffuse map, white is given as the texture
+// so the Renderable's Color shows.
+func newDiffuseMaterial(shader *ShaderProgram, diffuseMap uint32, specular mgl.Vec3, shininess float32) *Material {
+	m := NewMaterial(shader)
+	if diffuseMap == 0 {
+		diffuseMap = whiteTexture()
+	}
+	m.SetTexture("MATERIAL_TEX_0", diffuseMap)
+	m.Vec3s["MATERIAL_SPECULAR"] = specular
+	m.Floats["MATERIAL_SHININESS"] = shininess
+	return m
+}
+
+var white uint32
+
+// whiteTexture is a 1x1 white texture, made on first use.
+func whiteTexture() uint32 {
+	if white == 0 {
+		gl.GenTextures(1, &white)
+		gl.BindTexture(gl.TEXTURE_2D, white)
+		pixel := []uint8{255, 255, 255, 255}
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, 1, 1, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixel))
+	}
+	return white
+}
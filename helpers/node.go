@@ -0,0 +1,182 @@
+package helpers
+
+import (
+	"github.com/dcrosby42/go-game-sandbox/geom"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Node is an entry in a scene graph. Its Positioner places it relative to its
+// parent, so moving a Node carries its children along with it.
+//
+// World transforms are cached. Use the Set* methods to move a Node, or call
+// SetDirty after changing the Positioner's fields directly; either marks the
+// Node and everything below it for recalculation.
+type Node struct {
+	Positioner
+
+	Name string
+
+	// Renderable, if set, is drawn by Draw with the Node's world transform
+	Renderable *Renderable
+
+	parent   *Node
+	children []*Node
+
+	worldCached bool
+	world       mgl.Mat4
+}
+
+func NewNode(name string) *Node {
+	return &Node{
+		Positioner: *NewPositioner(),
+		Name:       name,
+	}
+}
+
+func (me *Node) Parent() *Node {
+	return me.parent
+}
+
+func (me *Node) Children() []*Node {
+	return me.children
+}
+
+// AddChild attaches child beneath this Node, detaching it from any previous parent.
+func (me *Node) AddChild(child *Node) {
+	for n := me; n != nil; n = n.parent {
+		if n == child {
+			panic("Node.AddChild: " + child.Name + " would become its own ancestor")
+		}
+	}
+	child.Detach()
+	child.parent = me
+	me.children = append(me.children, child)
+	child.invalidateWorld()
+}
+
+// Detach removes the Node, along with its children, from its parent.
+func (me *Node) Detach() {
+	if me.parent == nil {
+		return
+	}
+	siblings := me.parent.children
+	for i, n := range siblings {
+		if n == me {
+			me.parent.children = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	me.parent = nil
+	me.invalidateWorld()
+}
+
+func (me *Node) SetLocation(v mgl.Vec3) {
+	me.Location = v
+	me.SetDirty()
+}
+
+func (me *Node) SetScale(v mgl.Vec3) {
+	me.Scale = v
+	me.SetDirty()
+}
+
+func (me *Node) SetRotation(q mgl.Quat) {
+	me.Rotation = q
+	me.SetDirty()
+}
+
+func (me *Node) SetLocalRotation(q mgl.Quat) {
+	me.LocalRotation = q
+	me.SetDirty()
+}
+
+// SetDirty marks the local transform, and the world transforms of this Node and
+// its descendants, for recalculation.
+func (me *Node) SetDirty() {
+	me.Positioner.SetDirty()
+	me.invalidateWorld()
+}
+
+// invalidateWorld drops the cached world transforms of the subtree. A Node's
+// world transform is only ever cached after its parent's, so once we reach a
+// Node that's already dirty, everything below it is too.
+func (me *Node) invalidateWorld() {
+	if !me.worldCached {
+		return
+	}
+	me.worldCached = false
+	for _, child := range me.children {
+		child.invalidateWorld()
+	}
+}
+
+// LocalTransform places the Node relative to its parent.
+func (me *Node) LocalTransform() mgl.Mat4 {
+	return me.GetTransform()
+}
+
+// WorldTransform is the parent's world transform times the local transform.
+func (me *Node) WorldTransform() mgl.Mat4 {
+	if me.worldCached {
+		return me.world
+	}
+	if me.parent != nil {
+		me.world = me.parent.WorldTransform().Mul4(me.LocalTransform())
+	} else {
+		me.world = me.LocalTransform()
+	}
+	me.worldCached = true
+	return me.world
+}
+
+// Walk visits the Node and its descendants depth first, parents before
+// children. Returning false from fn skips the Node's children.
+func (me *Node) Walk(fn func(n *Node) bool) {
+	if !fn(me) {
+		return
+	}
+	for _, child := range me.children {
+		child.Walk(fn)
+	}
+}
+
+// Update recalculates the world transforms of any dirty Nodes in the tree.
+func (me *Node) Update() {
+	me.Walk(func(n *Node) bool {
+		n.WorldTransform()
+		return true
+	})
+}
+
+// DrawStats counts the Renderables a Draw drew and those it culled as
+// being outside the view frustum.
+type DrawStats struct {
+	Drawn, Culled int
+}
+
+func (s *DrawStats) Add(other DrawStats) {
+	s.Drawn += other.Drawn
+	s.Culled += other.Culled
+}
+
+// Draw draws the Renderables in the tree that might be in view. Renderables
+// without a Material are skipped.
+func (me *Node) Draw(perspective mgl.Mat4, view mgl.Mat4) DrawStats {
+	var stats DrawStats
+	frustum := geom.FrustumOf(perspective.Mul4(view))
+	me.Walk(func(n *Node) bool {
+		r := n.Renderable
+		if r == nil || r.Material == nil {
+			return true
+		}
+		world := n.WorldTransform()
+		if !r.InFrustum(&frustum, world) {
+			stats.Culled++
+			return true
+		}
+		r.DrawAt(perspective, view, world)
+		stats.Drawn++
+		return true
+	})
+	return stats
+}
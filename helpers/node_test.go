@@ -0,0 +1,141 @@
+package helpers
+
+import (
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func nodeAt(name string, x, y, z float32) *Node {
+	n := NewNode(name)
+	n.SetLocation(mgl.Vec3{x, y, z})
+	return n
+}
+
+func checkWorldAt(t *testing.T, n *Node, want mgl.Vec3) {
+	t.Helper()
+	got := n.WorldTransform().Col(3).Vec3()
+	if got.Sub(want).Len() > 1e-5 {
+		t.Errorf("%s is at %v in the world, want %v", n.Name, got, want)
+	}
+}
+
+func TestNodeMovingParentMovesChildren(t *testing.T) {
+	parent := nodeAt("parent", 1, 0, 0)
+	child := nodeAt("child", 0, 2, 0)
+	grandchild := nodeAt("grandchild", 0, 0, 3)
+	parent.AddChild(child)
+	child.AddChild(grandchild)
+
+	parent.Update()
+	checkWorldAt(t, child, mgl.Vec3{1, 2, 0})
+	checkWorldAt(t, grandchild, mgl.Vec3{1, 2, 3})
+
+	parent.SetLocation(mgl.Vec3{5, 0, 0})
+	checkWorldAt(t, child, mgl.Vec3{5, 2, 0})
+	checkWorldAt(t, grandchild, mgl.Vec3{5, 2, 3})
+
+	// changed directly, then marked dirty
+	parent.Location = mgl.Vec3{0, 0, -1}
+	parent.SetDirty()
+	checkWorldAt(t, grandchild, mgl.Vec3{0, 2, 2})
+
+	// a turn of the parent swings the children around
+	parent.SetLocation(mgl.Vec3{})
+	parent.SetLocalRotation(mgl.QuatRotate(mgl.DegToRad(90), mgl.Vec3{0, 0, 1}))
+	checkWorldAt(t, child, mgl.Vec3{-2, 0, 0})
+}
+
+func TestNodeWorldTransformIsCached(t *testing.T) {
+	parent := nodeAt("parent", 1, 0, 0)
+	child := nodeAt("child", 0, 2, 0)
+	parent.AddChild(child)
+	parent.Update()
+
+	// changing a field without SetDirty isn't noticed
+	parent.Location = mgl.Vec3{9, 9, 9}
+	checkWorldAt(t, child, mgl.Vec3{1, 2, 0})
+	parent.SetDirty()
+	checkWorldAt(t, child, mgl.Vec3{9, 11, 9})
+
+	// moving the child leaves the parent's cache be
+	parent.Location = mgl.Vec3{1, 0, 0}
+	child.SetLocation(mgl.Vec3{0, 3, 0})
+	checkWorldAt(t, child, mgl.Vec3{9, 12, 9})
+	checkWorldAt(t, parent, mgl.Vec3{9, 9, 9})
+}
+
+func TestNodeInvalidatedBelowUncachedNode(t *testing.T) {
+	a := nodeAt("a", 1, 0, 0)
+	b := nodeAt("b", 0, 1, 0)
+	c := nodeAt("c", 0, 0, 1)
+	a.AddChild(b)
+	b.AddChild(c)
+	checkWorldAt(t, c, mgl.Vec3{1, 1, 1})
+
+	// b is recalculated but c isn't looked at
+	b.SetLocation(mgl.Vec3{0, 2, 0})
+	checkWorldAt(t, b, mgl.Vec3{1, 2, 0})
+
+	a.SetLocation(mgl.Vec3{3, 0, 0})
+	checkWorldAt(t, c, mgl.Vec3{3, 2, 1})
+}
+
+func TestNodeDetachAndAddChild(t *testing.T) {
+	first := nodeAt("first", 1, 0, 0)
+	second := nodeAt("second", 0, 0, 5)
+	child := nodeAt("child", 0, 2, 0)
+	grandchild := nodeAt("grandchild", 0, 0, 1)
+	first.AddChild(child)
+	child.AddChild(grandchild)
+	checkWorldAt(t, grandchild, mgl.Vec3{1, 2, 1})
+
+	child.Detach()
+	if child.Parent() != nil || len(first.Children()) != 0 {
+		t.Errorf("child still attached: parent %v, first's children %v", child.Parent(), first.Children())
+	}
+	checkWorldAt(t, child, mgl.Vec3{0, 2, 0})
+	checkWorldAt(t, grandchild, mgl.Vec3{0, 2, 1})
+
+	second.AddChild(child)
+	checkWorldAt(t, grandchild, mgl.Vec3{0, 2, 6})
+
+	// adding to another parent takes it off the old one
+	first.AddChild(child)
+	if len(second.Children()) != 0 {
+		t.Errorf("second still has children %v", second.Children())
+	}
+	if child.Parent() != first {
+		t.Errorf("child's parent is %v, want first", child.Parent())
+	}
+	checkWorldAt(t, grandchild, mgl.Vec3{1, 2, 1})
+}
+
+func TestNodeAddChildCycle(t *testing.T) {
+	a := NewNode("a")
+	b := NewNode("b")
+	c := NewNode("c")
+	a.AddChild(b)
+	b.AddChild(c)
+
+	for _, tc := range []struct {
+		parent, child *Node
+	}{
+		{c, a},
+		{b, a},
+		{a, a},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("adding %s beneath %s didn't panic", tc.child.Name, tc.parent.Name)
+				}
+			}()
+			tc.parent.AddChild(tc.child)
+		}()
+	}
+	// and the tree is left as it was
+	if b.Parent() != a || c.Parent() != b || a.Parent() != nil {
+		t.Errorf("tree changed by the failed adds")
+	}
+}
@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// RenderTarget is an offscreen framebuffer with an RGBA color texture and a
+// depth renderbuffer. Draw into it between Bind and Unbind, then read it back
+// with ReadPixels or copy it to the window with BlitToScreen.
+type RenderTarget struct {
+	FBO      uint32
+	ColorTex uint32
+	DepthRBO uint32
+	Width    int
+	Height   int
+}
+
+func NewRenderTarget(width, height int) (*RenderTarget, error) {
+	rt := &RenderTarget{}
+	gl.GenFramebuffers(1, &rt.FBO)
+	gl.GenTextures(1, &rt.ColorTex)
+	gl.GenRenderbuffers(1, &rt.DepthRBO)
+	err := rt.Resize(width, height)
+	if err != nil {
+		rt.Delete()
+		return nil, err
+	}
+	return rt, nil
+}
+
+// Resize reallocates the attachments at the new size. Their contents are lost.
+func (rt *RenderTarget) Resize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("RenderTarget: invalid size %dx%d", width, height)
+	}
+	rt.Width = width
+	rt.Height = height
+
+	gl.BindTexture(gl.TEXTURE_2D, rt.ColorTex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.BindRenderbuffer(gl.RENDERBUFFER, rt.DepthRBO)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, int32(width), int32(height))
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, rt.FBO)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, rt.ColorTex, 0)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, rt.DepthRBO)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("RenderTarget: framebuffer incomplete, status 0x%x", status)
+	}
+	return nil
+}
+
+// Bind directs drawing into the target and sets the viewport to cover it.
+func (rt *RenderTarget) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, rt.FBO)
+	gl.Viewport(0, 0, int32(rt.Width), int32(rt.Height))
+}
+
+// Unbind goes back to drawing into the window.
+func (rt *RenderTarget) Unbind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// ReadPixels reads back the color attachment as a top-down image.
+func (rt *RenderTarget) ReadPixels() *image.NRGBA {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, rt.FBO)
+	gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	img := ReadPixels(rt.Width, rt.Height)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	return img
+}
+
+// BlitToScreen copies the color attachment onto the window's framebuffer,
+// stretching it to width x height.
+func (rt *RenderTarget) BlitToScreen(width, height int) {
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, rt.FBO)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, 0)
+	gl.BlitFramebuffer(
+		0, 0, int32(rt.Width), int32(rt.Height),
+		0, 0, int32(width), int32(height),
+		gl.COLOR_BUFFER_BIT, gl.LINEAR)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+func (rt *RenderTarget) Delete() {
+	gl.DeleteFramebuffers(1, &rt.FBO)
+	gl.DeleteTextures(1, &rt.ColorTex)
+	gl.DeleteRenderbuffers(1, &rt.DepthRBO)
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// ReadPixels reads back a width x height region of the currently bound
+// framebuffer. GL's origin is the bottom-left, so rows are flipped to give a
+// normal top-down image.
+func ReadPixels(width, height int) *image.NRGBA {
+	raw := make([]uint8, width*height*4)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(raw))
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rowLen := width * 4
+	for y := 0; y < height; y++ {
+		src := (height - y - 1) * rowLen
+		copy(img.Pix[y*img.Stride:y*img.Stride+rowLen], raw[src:src+rowLen])
+	}
+	return img
+}
+
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,221 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// ShaderVar describes an active uniform or vertex attribute of a linked program.
+type ShaderVar struct {
+	Name     string
+	Type     uint32 // eg gl.FLOAT_MAT4, gl.SAMPLER_2D
+	Size     int32  // array length, 1 for non-arrays
+	Location int32
+}
+
+// ShaderProgram is a linked GL program along with the locations of all its
+// active uniforms and attributes, looked up once at link time.
+type ShaderProgram struct {
+	Handle   uint32
+	Uniforms map[string]ShaderVar
+	Attribs  map[string]ShaderVar
+
+	// warned remembers uniforms we've complained about, so a bad setter
+	// called every frame only logs once
+	warned map[string]bool
+}
+
+// CompileShaderProgram compiles and links the given sources, see LoadShaderProgram.
+func CompileShaderProgram(vertShader, fragShader string) (*ShaderProgram, error) {
+	prog, err := LoadShaderProgram(vertShader, fragShader)
+	if err != nil {
+		return nil, err
+	}
+	return NewShaderProgram(prog), nil
+}
+
+// NewShaderProgram introspects an already-linked program.
+func NewShaderProgram(handle uint32) *ShaderProgram {
+	p := &ShaderProgram{
+		Handle:   handle,
+		Uniforms: make(map[string]ShaderVar),
+		Attribs:  make(map[string]ShaderVar),
+		warned:   make(map[string]bool),
+	}
+
+	var count, maxLen int32
+	gl.GetProgramiv(handle, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(handle, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLen)
+	buf := make([]uint8, maxLen+1)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveUniform(handle, uint32(i), int32(len(buf)), &length, &size, &xtype, &buf[0])
+		name := string(buf[:length])
+		loc := gl.GetUniformLocation(handle, gl.Str(name+"\x00"))
+		addVar(p.Uniforms, ShaderVar{Name: name, Type: xtype, Size: size, Location: loc})
+	}
+
+	gl.GetProgramiv(handle, gl.ACTIVE_ATTRIBUTES, &count)
+	gl.GetProgramiv(handle, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLen)
+	buf = make([]uint8, maxLen+1)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveAttrib(handle, uint32(i), int32(len(buf)), &length, &size, &xtype, &buf[0])
+		name := string(buf[:length])
+		loc := gl.GetAttribLocation(handle, gl.Str(name+"\x00"))
+		addVar(p.Attribs, ShaderVar{Name: name, Type: xtype, Size: size, Location: loc})
+	}
+
+	return p
+}
+
+// addVar files the var under its name, and arrays ("LIGHTS[0]") under their bare name too
+func addVar(vars map[string]ShaderVar, v ShaderVar) {
+	vars[v.Name] = v
+	if strings.HasSuffix(v.Name, "[0]") {
+		vars[strings.TrimSuffix(v.Name, "[0]")] = v
+	}
+}
+
+func (p *ShaderProgram) Use() {
+	gl.UseProgram(p.Handle)
+}
+
+// Delete frees the GL program.
+func (p *ShaderProgram) Delete() {
+	gl.DeleteProgram(p.Handle)
+}
+
+// UniformLocation returns the location of the named uniform, or -1 if the
+// program has no such active uniform.
+func (p *ShaderProgram) UniformLocation(name string) int32 {
+	if v, ok := p.Uniforms[name]; ok {
+		return v.Location
+	}
+	return -1
+}
+
+// AttribLocation returns the location of the named attribute, or -1 if the
+// program has no such active attribute.
+func (p *ShaderProgram) AttribLocation(name string) int32 {
+	if v, ok := p.Attribs[name]; ok {
+		return v.Location
+	}
+	return -1
+}
+
+// BindUniformBlock points the program's named uniform block at a binding
+// point, see UniformBuffer. Returns false if the program has no such block.
+func (p *ShaderProgram) BindUniformBlock(name string, binding uint32) bool {
+	index := gl.GetUniformBlockIndex(p.Handle, gl.Str(name+"\x00"))
+	if index == gl.INVALID_INDEX {
+		return false
+	}
+	gl.UniformBlockBinding(p.Handle, index, binding)
+	return true
+}
+
+// uniform finds the named uniform and checks it's one of the given types.
+// Missing uniforms are silently skipped (the shader may not use them);
+// type mismatches are logged.
+func (p *ShaderProgram) uniform(name string, types ...uint32) (int32, bool) {
+	v, ok := p.Uniforms[name]
+	if !ok || v.Location < 0 {
+		return -1, false
+	}
+	for _, t := range types {
+		if v.Type == t {
+			return v.Location, true
+		}
+	}
+	if !p.warned[name] {
+		p.warned[name] = true
+		fmt.Printf("!! ERROR ShaderProgram %d: uniform %q has type 0x%x, not 0x%x\n", p.Handle, name, v.Type, types)
+	}
+	return -1, false
+}
+
+// The setters below act on the currently used program; call Use first.
+// Each is a no-op if the program doesn't have the uniform.
+
+func (p *ShaderProgram) SetMat4(name string, m mgl.Mat4) {
+	if loc, ok := p.uniform(name, gl.FLOAT_MAT4); ok {
+		gl.UniformMatrix4fv(loc, 1, false, &m[0])
+	}
+}
+
+// SetMat4Array sets the elements of a mat4 array uniform, as many as fit.
+func (p *ShaderProgram) SetMat4Array(name string, ms []mgl.Mat4) {
+	if len(ms) == 0 {
+		return
+	}
+	if loc, ok := p.uniform(name, gl.FLOAT_MAT4); ok {
+		count := int32(len(ms))
+		if size := p.Uniforms[name].Size; count > size {
+			count = size
+		}
+		gl.UniformMatrix4fv(loc, count, false, &ms[0][0])
+	}
+}
+
+func (p *ShaderProgram) SetMat3(name string, m mgl.Mat3) {
+	if loc, ok := p.uniform(name, gl.FLOAT_MAT3); ok {
+		gl.UniformMatrix3fv(loc, 1, false, &m[0])
+	}
+}
+
+func (p *ShaderProgram) SetVec2(name string, v mgl.Vec2) {
+	if loc, ok := p.uniform(name, gl.FLOAT_VEC2); ok {
+		gl.Uniform2f(loc, v[0], v[1])
+	}
+}
+
+func (p *ShaderProgram) SetVec3(name string, v mgl.Vec3) {
+	if loc, ok := p.uniform(name, gl.FLOAT_VEC3); ok {
+		gl.Uniform3f(loc, v[0], v[1], v[2])
+	}
+}
+
+func (p *ShaderProgram) SetVec4(name string, v mgl.Vec4) {
+	if loc, ok := p.uniform(name, gl.FLOAT_VEC4); ok {
+		gl.Uniform4f(loc, v[0], v[1], v[2], v[3])
+	}
+}
+
+func (p *ShaderProgram) SetFloat(name string, f float32) {
+	if loc, ok := p.uniform(name, gl.FLOAT); ok {
+		gl.Uniform1f(loc, f)
+	}
+}
+
+func (p *ShaderProgram) SetInt(name string, i int32) {
+	if loc, ok := p.uniform(name, gl.INT, gl.BOOL); ok {
+		gl.Uniform1i(loc, i)
+	}
+}
+
+// SetTexture binds tex to the given texture unit and points the named sampler at it.
+func (p *ShaderProgram) SetTexture(name string, unit uint32, tex uint32) {
+	if loc, ok := p.uniform(name, gl.SAMPLER_2D); ok {
+		gl.ActiveTexture(gl.TEXTURE0 + unit)
+		gl.BindTexture(gl.TEXTURE_2D, tex)
+		gl.Uniform1i(loc, int32(unit))
+	}
+}
+
+// EnableAttrib feeds the named attribute from vbo, size floats per vertex.
+// A no-op if the program doesn't have the attribute.
+func (p *ShaderProgram) EnableAttrib(name string, vbo uint32, size int32) {
+	loc := p.AttribLocation(name)
+	if loc < 0 {
+		return
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.EnableVertexAttribArray(uint32(loc))
+	gl.VertexAttribPointer(uint32(loc), size, gl.FLOAT, false, 0, gl.PtrOffset(0))
+}
@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Attributes and uniforms used when drawing a skinned Renderable, see
+// box3/shaders/diffuse_texture.vert.glsl.
+const (
+	AttribJoints         = "VERTEX_JOINTS"  // vec4, indexes into the palette
+	AttribWeights        = "VERTEX_WEIGHTS" // vec4, summing to 1
+	UniformSkinned       = "SKINNED"        // bool, true while drawing a skinned Renderable
+	UniformJointMatrices = "JOINT_MATRICES" // mat4[MaxJoints]
+)
+
+// MaxJoints is the size of the shaders' joint matrix palette.
+const MaxJoints = 64
+
+// Skin deforms a Renderable's vertices by a skeleton of joint Nodes. Each
+// vertex is moved by up to four joints, by weight.
+type Skin struct {
+	Joints []*Node
+
+	// InverseBind takes each joint from model space to its space in the bind
+	// pose, the one the mesh was modelled in
+	InverseBind []mgl.Mat4
+
+	// Palette is the joint matrices last uploaded, see Update
+	Palette []mgl.Mat4
+}
+
+// NewSkin makes a Skin; inverseBind may be nil for identity matrices. The
+// shader's palette holds MaxJoints matrices, so there must be no more joints
+// than that.
+func NewSkin(joints []*Node, inverseBind []mgl.Mat4) *Skin {
+	s := &Skin{Joints: joints, InverseBind: inverseBind}
+	if s.InverseBind == nil {
+		s.InverseBind = make([]mgl.Mat4, len(joints))
+		for i := range s.InverseBind {
+			s.InverseBind[i] = mgl.Ident4()
+		}
+	}
+	s.Palette = make([]mgl.Mat4, len(joints))
+	return s
+}
+
+// Update recalculates the Palette from the joints' world transforms, for a
+// mesh drawn at model. The joints follow the skeleton rather than the mesh,
+// so model is divided back out.
+func (s *Skin) Update(model mgl.Mat4) {
+	toModel := model.Inv()
+	for i, j := range s.Joints {
+		s.Palette[i] = toModel.Mul4(j.WorldTransform()).Mul4(s.InverseBind[i])
+	}
+}
+
+// SetSkinWeights uploads the skinning attributes: four joint indexes and four
+// weights per vertex. The Renderable's Skin must be set before it's drawn.
+func (r *Renderable) SetSkinWeights(joints, weights []float32) {
+	const floatSize = 4
+	if len(joints) == 0 || len(weights) == 0 {
+		return
+	}
+	gl.GenBuffers(1, &r.JointsVBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, r.JointsVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, floatSize*len(joints), gl.Ptr(&joints[0]), gl.STATIC_DRAW)
+
+	gl.GenBuffers(1, &r.WeightsVBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, r.WeightsVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, floatSize*len(weights), gl.Ptr(&weights[0]), gl.STATIC_DRAW)
+}
@@ -4,11 +4,13 @@
 package helpers
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"image"
 	"image/draw"
 	"image/png"
+	"io"
 	"io/ioutil"
 	"math"
 	"os"
@@ -16,6 +18,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dcrosby42/go-game-sandbox/geom"
 	gl "github.com/go-gl/gl/v3.3-core/gl"
 	glfw "github.com/go-gl/glfw/v3.2/glfw"
 	mgl "github.com/go-gl/mathgl/mgl32"
@@ -46,99 +49,146 @@ var (
     colourOut = vs_diffuse;
   }`
 
-	DiffuseColorVertShader = `#version 330
+	// lightsGLSL declares the Lights uniform block, see package lighting
+	lightsGLSL = `
+	// See package lighting for the layout
+	#define MAX_LIGHTS 8
+	#define POINT_LIGHT 0
+	#define DIRECTIONAL_LIGHT 1
+	#define SPOT_LIGHT 2
+
+	struct Light {
+	  vec4 position;    // xyz, w = type
+	  vec4 direction;
+	  vec4 color;
+	  vec4 attenuation; // constant, linear, quadratic
+	  vec4 cone;        // cos(inner), cos(outer)
+	};
+
+	layout(std140) uniform Lights {
+	  vec4 AMBIENT;
+	  vec4 EYE_POSITION;
+	  ivec4 LIGHT_COUNT;
+	  Light LIGHTS[MAX_LIGHTS];
+	};
+`
+
+	// blinnPhongGLSL computes the light from one Light; expects vs_world_position and MATERIAL_SHININESS
+	blinnPhongGLSL = `
+	// blinnPhong returns the diffuse and specular light reaching this fragment from one light
+	void blinnPhong(Light light, vec3 normal, vec3 toEye, out vec3 diffuse, out vec3 specular)
+	{
+	  diffuse = vec3(0.0);
+	  specular = vec3(0.0);
+
+	  int kind = int(light.position.w);
+	  vec3 toLight;
+	  float falloff = 1.0;
+	  if (kind == DIRECTIONAL_LIGHT) {
+	    toLight = -normalize(light.direction.xyz);
+	  } else {
+	    vec3 offset = light.position.xyz - vs_world_position;
+	    float dist = length(offset);
+	    toLight = offset / dist;
+	    falloff = 1.0 / (light.attenuation.x + light.attenuation.y * dist + light.attenuation.z * dist * dist);
+	    if (kind == SPOT_LIGHT) {
+	      float theta = dot(-toLight, normalize(light.direction.xyz));
+	      falloff *= smoothstep(light.cone.y, light.cone.x, theta);
+	    }
+	  }
+
+	  float lambert = max(dot(normal, toLight), 0.0);
+	  if (lambert <= 0.0) {
+	    return;
+	  }
+	  diffuse = light.color.rgb * lambert * falloff;
+
+	  vec3 halfway = normalize(toLight + toEye);
+	  float spec = pow(max(dot(normal, halfway), 0.0), max(MATERIAL_SHININESS, 1.0));
+	  specular = light.color.rgb * spec * falloff;
+	}
+`
+
+	litVertShader = `#version 330
 	precision highp float;
 
 	uniform mat4 MVP_MATRIX;
-	uniform mat4 MV_MATRIX;
+	uniform mat4 MODEL_MATRIX;
+	uniform mat3 NORMAL_MATRIX;
 	in vec3 VERTEX_POSITION;
 	in vec3 VERTEX_NORMAL;
+	in vec2 VERTEX_UV_0;
 
-	out vec3 vs_position;
-	out vec3 vs_eye_normal;
+	out vec3 vs_world_position;
+	out vec3 vs_world_normal;
+	out vec2 vs_uv_0;
 
 	void main()
 	{
-	  vs_position = VERTEX_POSITION;
-	  vs_eye_normal = normalize(mat3(MV_MATRIX) * VERTEX_NORMAL);
+	  vs_world_position = vec3(MODEL_MATRIX * vec4(VERTEX_POSITION, 1.0));
+	  vs_world_normal = normalize(NORMAL_MATRIX * VERTEX_NORMAL);
+	  vs_uv_0 = VERTEX_UV_0;
 	  gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
 	}`
 
-	DiffuseColorFragShader = `#version 330
-	precision highp float;
-
-	uniform mat4 MV_MATRIX;
-	uniform mat4 MVP_MATRIX;
-	uniform vec4 MATERIAL_DIFFUSE;
-
-	in vec3 vs_position;
-	in vec3 vs_eye_normal;
-
+	// litFragMain sums Blinn-Phong lighting over all the lights; expects base_color()
+	litFragMain = `
 	out vec4 frag_color;
 
 	void main()
 	{
-		vec4 LIGHT_POSITION = vec4(-100.0, 100.0, 100.0, 1.0);
-		vec4 LIGHT_DIFFUSE = vec4(1.0);
-
-		vec4 eye_vertex = MV_MATRIX * vec4(vs_position, 1.0);
-		vec3 s = normalize(vec3(LIGHT_POSITION-eye_vertex));
-		float diffuseIntensity = max(dot(s,vs_eye_normal), 0.0);
-
-
-	  frag_color = MATERIAL_DIFFUSE * diffuseIntensity;
+	  vec3 normal = normalize(vs_world_normal);
+	  vec3 toEye = normalize(EYE_POSITION.xyz - vs_world_position);
+
+	  vec3 diffuse = vec3(0.0);
+	  vec3 specular = vec3(0.0);
+	  for (int i = 0; i < LIGHT_COUNT.x && i < MAX_LIGHTS; i++) {
+	    vec3 d, s;
+	    blinnPhong(LIGHTS[i], normal, toEye, d, s);
+	    diffuse += d;
+	    specular += s;
+	  }
+
+	  vec4 base = base_color();
+	  frag_color = vec4(base.rgb * (AMBIENT.rgb + diffuse) + MATERIAL_SPECULAR * specular, base.a);
 	}`
 
-	DiffuseTextureVertShader = `#version 330
-	precision highp float;
+	// DiffuseColorVertShader and DiffuseColorFragShader light a solid color with
+	// the Lights uniform block (see package lighting)
+	DiffuseColorVertShader = litVertShader
 
-	uniform mat4 MVP_MATRIX;
-	uniform mat4 MV_MATRIX;
-	in vec3 VERTEX_POSITION;
-	in vec3 VERTEX_NORMAL;
-	in vec2 VERTEX_UV_0;
+	DiffuseColorFragShader = `#version 330
+	precision highp float;
+	` + lightsGLSL + `
+	uniform vec4 MATERIAL_DIFFUSE;
+	uniform vec3 MATERIAL_SPECULAR;
+	uniform float MATERIAL_SHININESS;
 
-	out vec3 vs_position;
-	out vec3 vs_eye_normal;
-	out vec2 vs_uv_0;
+	in vec3 vs_world_position;
+	in vec3 vs_world_normal;
+	in vec2 vs_uv_0;
+	` + blinnPhongGLSL + `
+	vec4 base_color() { return MATERIAL_DIFFUSE; }
+	` + litFragMain
 
-	void main()
-	{
-	  vs_position = VERTEX_POSITION;
-		vs_uv_0 = VERTEX_UV_0;
-	  vs_eye_normal = normalize(mat3(MV_MATRIX) * VERTEX_NORMAL);
-	  gl_Position = MVP_MATRIX * vec4(VERTEX_POSITION, 1.0);
-	}`
+	// DiffuseTextureVertShader and DiffuseTextureFragShader light a textured
+	// surface with the Lights uniform block (see package lighting)
+	DiffuseTextureVertShader = litVertShader
 
 	DiffuseTextureFragShader = `#version 330
 	precision highp float;
+	` + lightsGLSL + `
 	uniform sampler2D MATERIAL_TEX_0;
-
-	uniform mat4 MV_MATRIX;
-	uniform mat4 MVP_MATRIX;
 	uniform vec4 MATERIAL_DIFFUSE;
+	uniform vec3 MATERIAL_SPECULAR;
+	uniform float MATERIAL_SHININESS;
 
-	in vec3 vs_position;
-	in vec3 vs_eye_normal;
+	in vec3 vs_world_position;
+	in vec3 vs_world_normal;
 	in vec2 vs_uv_0;
-
-	out vec4 frag_color;
-
-	void main()
-	{
-		vec4 LIGHT_POSITION = vec4(-100.0, 100.0, 100.0, 1.0);
-		vec4 LIGHT_DIFFUSE = vec4(1.0);
-
-		vec4 eye_vertex = MV_MATRIX * vec4(vs_position, 1.0);
-		vec3 s = normalize(vec3(LIGHT_POSITION-eye_vertex));
-		float diffuseIntensity = max(dot(s,vs_eye_normal), 0.0);
-
-    vec4 ambient = vec4(0.1,0.1,0.1,0.1);
-
-
-	  frag_color = MATERIAL_DIFFUSE * texture(MATERIAL_TEX_0, vs_uv_0) * diffuseIntensity;
-		frag_color = frag_color + ambient;
-	}`
+	` + blinnPhongGLSL + `
+	vec4 base_color() { return MATERIAL_DIFFUSE * texture(MATERIAL_TEX_0, vs_uv_0); }
+	` + litFragMain
 )
 
 // GLFW event handling must run on the main OS thread
@@ -295,13 +345,11 @@ func (app *ExampleApp) RenderLoop() {
 
 // Renderable is an object that can be drawn in the render loop
 type Renderable struct {
-	// Shader is the shader program to use to draw the renderable
-	Shader uint32
+	// Material is the shader, uniforms and GL state to draw with; it may be
+	// shared with other Renderables
+	Material *Material
 
-	// Tex0 is the first texture to be bound to the shader
-	Tex0 uint32
-
-	// Color is a material color for the object passed to the shader when drawn.
+	// Color is a per-object color passed to the shader as MATERIAL_DIFFUSE when drawn.
 	Color mgl.Vec4
 
 	// Vao is the VAO object used to draw the object
@@ -319,6 +367,13 @@ type Renderable struct {
 	// ElementsVBO is the VBO
 	ElementsVBO uint32
 
+	// JointsVBO and WeightsVBO hold the skinning attributes, if Skin is set
+	JointsVBO  uint32
+	WeightsVBO uint32
+
+	// Skin, if set, deforms the vertices by its joints when drawn
+	Skin *Skin
+
 	// FaceCount is the number of faces to draw for the object
 	FaceCount int
 
@@ -333,6 +388,11 @@ type Renderable struct {
 
 	// LocalRotation is rotation applied to the object in local space
 	LocalRotation mgl.Quat
+
+	// Bounds and BoundingSphere enclose the vertices, in model space. They're
+	// used for culling; a Renderable with a zero BoundingSphere is never culled.
+	Bounds         geom.AABB
+	BoundingSphere geom.Sphere
 }
 
 // NewRenderable creates a new Renderable object.
@@ -352,115 +412,64 @@ func (r *Renderable) GetTransformMat4() mgl.Mat4 {
 	return modelTransform
 }
 
-func (r *Renderable) Draw(perspective mgl.Mat4, view mgl.Mat4) {
-	gl.UseProgram(r.Shader)
-	gl.BindVertexArray(r.Vao)
-
-	model := r.GetTransformMat4()
-
-	var mvp mgl.Mat4
-	shaderMvp := getUniformLocation(r.Shader, "MVP_MATRIX")
-	if shaderMvp >= 0 {
-		mvp = perspective.Mul4(view).Mul4(model)
-		gl.UniformMatrix4fv(shaderMvp, 1, false, &mvp[0])
-	}
-
-	shaderMv := getUniformLocation(r.Shader, "MV_MATRIX")
-	if shaderMv >= 0 {
-		mv := view.Mul4(model)
-		gl.UniformMatrix4fv(shaderMv, 1, false, &mv[0])
-	}
-
-	shaderTex0 := getUniformLocation(r.Shader, "DIFFUSE_TEX")
-	if shaderTex0 >= 0 {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, r.Tex0)
-		gl.Uniform1i(shaderTex0, 0)
-	}
-
-	shaderColor := getUniformLocation(r.Shader, "MATERIAL_DIFFUSE")
-	if shaderColor >= 0 {
-		gl.Uniform4f(shaderColor, r.Color[0], r.Color[1], r.Color[2], r.Color[3])
-	}
+// SetBounds sets Bounds, and a BoundingSphere around them.
+func (r *Renderable) SetBounds(box geom.AABB) {
+	r.Bounds = box
+	r.BoundingSphere = geom.SphereAround(box)
+}
 
-	shaderTex1 := getUniformLocation(r.Shader, "MATERIAL_TEX_0")
-	if shaderTex1 >= 0 {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, r.Tex0)
-		gl.Uniform1i(shaderTex1, 0)
+// InFrustum reports whether the Renderable, placed by model, might be visible:
+// the cheap sphere test first, then the box. Skinned Renderables move away
+// from their Bounds, so they're never culled.
+func (r *Renderable) InFrustum(frustum *geom.Frustum, model mgl.Mat4) bool {
+	if r.BoundingSphere.Radius == 0 || r.Skin != nil {
+		return true
 	}
-
-	shaderCameraWorldPos := getUniformLocation(r.Shader, "CAMERA_WORLD_POSITION")
-	if shaderCameraWorldPos >= 0 {
-		gl.Uniform3f(shaderCameraWorldPos, -view[12], -view[13], -view[14])
+	if !frustum.IntersectsSphere(r.BoundingSphere.Transform(model)) {
+		return false
 	}
+	return frustum.IntersectsAABB(r.Bounds.Transform(model))
+}
 
-	shaderPosition := getAttribLocation(r.Shader, "VERTEX_POSITION")
-	if shaderPosition >= 0 {
-		gl.BindBuffer(gl.ARRAY_BUFFER, r.VertVBO)
-		gl.EnableVertexAttribArray(uint32(shaderPosition))
-		gl.VertexAttribPointer(uint32(shaderPosition), 3, gl.FLOAT, false, 0, gl.PtrOffset(0))
-	}
+func (r *Renderable) Draw(perspective mgl.Mat4, view mgl.Mat4) {
+	r.DrawAt(perspective, view, r.GetTransformMat4())
+}
 
-	shaderNormal := getAttribLocation(r.Shader, "VERTEX_NORMAL")
-	if shaderNormal >= 0 {
-		gl.BindBuffer(gl.ARRAY_BUFFER, r.NormsVBO)
-		gl.EnableVertexAttribArray(uint32(shaderNormal))
-		gl.VertexAttribPointer(uint32(shaderNormal), 3, gl.FLOAT, false, 0, gl.PtrOffset(0))
-	}
+// DrawAt draws with the given model transform in place of the Renderable's
+// own, eg a Node's world transform.
+func (r *Renderable) DrawAt(perspective mgl.Mat4, view mgl.Mat4, model mgl.Mat4) {
+	r.Material.Bind()
+	shader := r.Material.Shader
+	gl.BindVertexArray(r.Vao)
 
-	shaderVertUv := getAttribLocation(r.Shader, "VERTEX_UV_0")
-	if shaderVertUv >= 0 {
-		gl.BindBuffer(gl.ARRAY_BUFFER, r.UvVBO)
-		gl.EnableVertexAttribArray(uint32(shaderVertUv))
-		gl.VertexAttribPointer(uint32(shaderVertUv), 2, gl.FLOAT, false, 0, gl.PtrOffset(0))
-	}
+	shader.SetInt(UniformInstanced, 0)
+	if r.Skin != nil {
+		r.Skin.Update(model)
+		shader.SetInt(UniformSkinned, 1)
+		shader.SetMat4Array(UniformJointMatrices, r.Skin.Palette)
+		shader.EnableAttrib(AttribJoints, r.JointsVBO, 4)
+		shader.EnableAttrib(AttribWeights, r.WeightsVBO, 4)
+	} else {
+		shader.SetInt(UniformSkinned, 0)
+	}
+	shader.SetMat4(UniformMVP, perspective.Mul4(view).Mul4(model))
+	shader.SetMat4(UniformMV, view.Mul4(model))
+	shader.SetMat4(UniformModel, model)
+	shader.SetMat3(UniformNormalMatrix, model.Mat3().Inv().Transpose())
+	shader.SetVec4(UniformColor, r.Color)
+	shader.SetVec3(UniformCameraPosition, mgl.Vec3{-view[12], -view[13], -view[14]})
+
+	shader.EnableAttrib("VERTEX_POSITION", r.VertVBO, 3)
+	shader.EnableAttrib("VERTEX_NORMAL", r.NormsVBO, 3)
+	shader.EnableAttrib("VERTEX_UV_0", r.UvVBO, 2)
 
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, r.ElementsVBO)
 	gl.DrawElements(gl.TRIANGLES, int32(r.FaceCount*3), gl.UNSIGNED_INT, gl.PtrOffset(0))
 	gl.BindVertexArray(0)
 }
 
-// setup a cache for the uniform and attribute getter functions
-var (
-	uniCache  = make(map[string]int32)
-	attrCache = make(map[string]int32)
-)
-
-func getUniformLocation(prog uint32, name string) int32 {
-	// attempt to get it from the cache first
-	ul, found := uniCache[name]
-	if found {
-		return ul
-	}
-
-	// pull the location from the shader and cache it
-	uniGLName := name + "\x00"
-	ul = gl.GetUniformLocation(prog, gl.Str(uniGLName))
-
-	// cache even if it returns -1 so that it doesn't repeatedly check
-	uniCache[name] = ul
-	return ul
-}
-
-func getAttribLocation(prog uint32, name string) int32 {
-	// attempt to get it from the cache first
-	al, found := attrCache[name]
-	if found {
-		return al
-	}
-
-	// pull the location from the shader and cache it
-	attrGLName := name + "\x00"
-	al = gl.GetAttribLocation(prog, gl.Str(attrGLName))
-
-	// cache even if it returns -1 so that it doesn't repeatedly check
-	attrCache[name] = al
-	return al
-}
-
-func MustMakeDiffuseShader() uint32 {
-	diffuseShader, err := LoadShaderProgram(DiffuseTextureVertShader, DiffuseTextureFragShader)
+func MustMakeDiffuseShader() *ShaderProgram {
+	diffuseShader, err := CompileShaderProgram(DiffuseTextureVertShader, DiffuseTextureFragShader)
 	if err != nil {
 		panic("Failed to compile the diffuse shader! " + err.Error())
 	}
@@ -555,9 +564,13 @@ func loadFile(filePath string) (rgba_flipped *image.NRGBA, e error) {
 	if err != nil {
 		return nil, fmt.Errorf("Failed to open the texture file: %v\n", err)
 	}
+	defer imgFile.Close()
+	return decodeTexture(imgFile)
+}
 
-	img, err := png.Decode(imgFile)
-	imgFile.Close()
+// decodeTexture decodes a PNG and flips it for GL.
+func decodeTexture(r io.Reader) (rgba_flipped *image.NRGBA, e error) {
+	img, err := png.Decode(r)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to decode the texture: %v\n", err)
 	}
@@ -591,6 +604,31 @@ func MustLoadTexture(fname string) uint32 {
 }
 
 func LoadImageToTexture(filePath string) (glTex uint32, e error) {
+	rgba_flipped, err := DecodeTextureFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return UploadTexture(rgba_flipped), nil
+}
+
+// LoadImageDataToTexture is LoadImageToTexture for a PNG that's already in
+// memory, eg embedded in a model file.
+func LoadImageDataToTexture(data []byte) (glTex uint32, e error) {
+	rgba_flipped, err := decodeTexture(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	return UploadTexture(rgba_flipped), nil
+}
+
+// DecodeTextureFile reads and decodes a PNG into pixels ready for UploadTexture.
+// It makes no GL calls, so it's safe to run off the main thread.
+func DecodeTextureFile(filePath string) (*image.NRGBA, error) {
+	return loadFile(filePath)
+}
+
+// UploadTexture copies decoded pixels into a new mipmapped GL texture.
+func UploadTexture(rgba_flipped *image.NRGBA) (glTex uint32) {
 	gl.GenTextures(1, &glTex)
 	gl.ActiveTexture(gl.TEXTURE0)
 	gl.BindTexture(gl.TEXTURE_2D, glTex)
@@ -599,16 +637,12 @@ func LoadImageToTexture(filePath string) (glTex uint32, e error) {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
-	rgba_flipped, err := loadFile(filePath)
-	if err != nil {
-		return glTex, err
-	}
-
-	imageSize := int32(rgba_flipped.Bounds().Max.X)
+	width := int32(rgba_flipped.Bounds().Dx())
+	height := int32(rgba_flipped.Bounds().Dy())
 
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, imageSize, imageSize, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba_flipped.Pix))
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba_flipped.Pix))
 	gl.GenerateMipmap(gl.TEXTURE_2D)
-	return glTex, nil
+	return glTex
 }
 
 // CreateCube makes a new Renderable object with the specified dimensions for the cube.
@@ -685,6 +719,7 @@ func CreateCube(xmin, ymin, zmin, xmax, ymax, zmax float32) *Renderable {
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, r.ElementsVBO)
 	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, uintSize*len(indexes), gl.Ptr(&indexes[0]), gl.STATIC_DRAW)
 
+	r.SetBounds(geom.AABB{Min: mgl.Vec3{xmin, ymin, zmin}, Max: mgl.Vec3{xmax, ymax, zmax}})
 	return r
 }
 
@@ -762,6 +797,8 @@ func CreateSphere(radius float32, rings int, sectors int) *Renderable {
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, r.ElementsVBO)
 	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, uintSize*len(indexes), gl.Ptr(&indexes[0]), gl.STATIC_DRAW)
 
+	r.Bounds = geom.BoxAround(mgl.Vec3{}, mgl.Vec3{radius, radius, radius})
+	r.BoundingSphere = geom.Sphere{Radius: radius}
 	return r
 }
 
@@ -792,7 +829,41 @@ func CreatePlaneXZ(x0, z0, x1, z1 float32, scaleUVs float32) *Renderable {
 		0.0, 1.0, 0.0,
 	}
 
-	return createPlane(x0, z0, x1, z1, verts, indexes, uvs, normals)
+	r := createPlane(x0, z0, x1, z1, verts, indexes, uvs, normals)
+	r.SetBounds(geom.AABB{Min: mgl.Vec3{x0, 0, z0}, Max: mgl.Vec3{x1, 0, z1}})
+	return r
+}
+
+// CreatePlaneXY makes a 2d Renderable object on the XY plane, facing +Z, where
+// (x0,y0) is the lower left and (x1,y1) is the upper right coordinate.
+func CreatePlaneXY(x0, y0, x1, y1 float32, scaleUVs float32) *Renderable {
+	verts := [12]float32{
+		x0, y0, 0.0,
+		x1, y0, 0.0,
+		x0, y1, 0.0,
+		x1, y1, 0.0,
+	}
+	indexes := [6]uint32{
+		0, 1, 2,
+		1, 3, 2,
+	}
+	uvs := [8]float32{
+		0.0, 0.0,
+		scaleUVs, 0.0,
+		0.0, scaleUVs,
+		scaleUVs, scaleUVs,
+	}
+
+	normals := [12]float32{
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+	}
+
+	r := createPlane(x0, y0, x1, y1, verts, indexes, uvs, normals)
+	r.SetBounds(geom.AABB{Min: mgl.Vec3{x0, y0, 0}, Max: mgl.Vec3{x1, y1, 0}})
+	return r
 }
 
 func createPlane(x0, y0, x1, y1 float32, verts [12]float32, indexes [6]uint32, uvs [8]float32, normals [12]float32) *Renderable {
@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	gl "github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// UniformBuffer is a uniform buffer object attached to a fixed binding point.
+// Shader programs get at it with ShaderProgram.BindUniformBlock.
+type UniformBuffer struct {
+	Handle  uint32
+	Binding uint32
+	Size    int
+}
+
+func NewUniformBuffer(binding uint32, size int) *UniformBuffer {
+	ub := &UniformBuffer{Binding: binding, Size: size}
+	gl.GenBuffers(1, &ub.Handle)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, ub.Handle)
+	gl.BufferData(gl.UNIFORM_BUFFER, size, nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, binding, ub.Handle)
+	return ub
+}
+
+// Update replaces the buffer's contents. data should be ub.Size bytes.
+func (ub *UniformBuffer) Update(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	if len(data) > ub.Size {
+		data = data[:ub.Size]
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, ub.Handle)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(data), gl.Ptr(&data[0]))
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+}
+
+func (ub *UniformBuffer) Delete() {
+	gl.DeleteBuffers(1, &ub.Handle)
+}
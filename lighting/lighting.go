@@ -0,0 +1,151 @@
+// Package lighting describes the lights in a scene and packs them into the
+// std140 layout of the Lights uniform block used by the shipped shaders:
+//
+//	struct Light {
+//	  vec4 position;    // xyz world position, w = Kind
+//	  vec4 direction;   // xyz world direction the light points in
+//	  vec4 color;       // rgb = Color * Intensity
+//	  vec4 attenuation; // x = constant, y = linear, z = quadratic
+//	  vec4 cone;        // x = cos(InnerCone), y = cos(OuterCone)
+//	};
+//	layout(std140) uniform Lights {
+//	  vec4 AMBIENT;        // rgb
+//	  vec4 EYE_POSITION;   // xyz world position of the camera
+//	  ivec4 LIGHT_COUNT;   // x
+//	  Light LIGHTS[MAX_LIGHTS];
+//	};
+//
+// It makes no GL calls.
+package lighting
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// MaxLights must match MAX_LIGHTS in the shaders
+const MaxLights = 8
+
+// BlockName is the name of the uniform block in the shaders
+const BlockName = "Lights"
+
+const (
+	vec4Size   = 16
+	lightSize  = 5 * vec4Size
+	headerSize = 3 * vec4Size
+
+	// BlockSize is the size in bytes of the packed Lights block
+	BlockSize = headerSize + MaxLights*lightSize
+)
+
+type Kind int32
+
+const (
+	Point Kind = iota
+	Directional
+	Spot
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Point:
+		return "Point"
+	case Directional:
+		return "Directional"
+	case Spot:
+		return "Spot"
+	}
+	return fmt.Sprintf("Kind(%d)", int32(k))
+}
+
+type Light struct {
+	Kind      Kind
+	Color     mgl.Vec3
+	Intensity float32
+
+	// Position is ignored for Directional lights
+	Position mgl.Vec3
+
+	// Direction the light shines in, for Directional and Spot lights
+	Direction mgl.Vec3
+
+	// Attenuation is the constant, linear and quadratic falloff with distance,
+	// for Point and Spot lights
+	Attenuation mgl.Vec3
+
+	// InnerCone, OuterCone are the half-angles (radians) of a Spot light's
+	// full-brightness cone and of where it fades out to nothing
+	InnerCone, OuterCone float32
+}
+
+// DefaultAttenuation reaches out to about 50 units
+var DefaultAttenuation = mgl.Vec3{1, 0.09, 0.032}
+
+func NewPoint(position, color mgl.Vec3, intensity float32) Light {
+	return Light{
+		Kind:        Point,
+		Color:       color,
+		Intensity:   intensity,
+		Position:    position,
+		Attenuation: DefaultAttenuation,
+	}
+}
+
+func NewDirectional(direction, color mgl.Vec3, intensity float32) Light {
+	return Light{
+		Kind:      Directional,
+		Color:     color,
+		Intensity: intensity,
+		Direction: direction.Normalize(),
+	}
+}
+
+func NewSpot(position, direction, color mgl.Vec3, intensity, innerCone, outerCone float32) Light {
+	return Light{
+		Kind:        Spot,
+		Color:       color,
+		Intensity:   intensity,
+		Position:    position,
+		Direction:   direction.Normalize(),
+		Attenuation: DefaultAttenuation,
+		InnerCone:   innerCone,
+		OuterCone:   outerCone,
+	}
+}
+
+// Pack lays out the ambient color, eye position and lights as the Lights
+// uniform block. Lights past MaxLights are dropped.
+func Pack(ambient, eye mgl.Vec3, lights []Light) []byte {
+	if len(lights) > MaxLights {
+		lights = lights[:MaxLights]
+	}
+	buf := make([]byte, BlockSize)
+	putVec4(buf[0:], ambient[0], ambient[1], ambient[2], 1)
+	putVec4(buf[vec4Size:], eye[0], eye[1], eye[2], 1)
+	binary.LittleEndian.PutUint32(buf[2*vec4Size:], uint32(len(lights)))
+
+	for i, l := range lights {
+		off := headerSize + i*lightSize
+		color := l.Color.Mul(l.Intensity)
+		putVec4(buf[off:], l.Position[0], l.Position[1], l.Position[2], float32(l.Kind))
+		putVec4(buf[off+vec4Size:], l.Direction[0], l.Direction[1], l.Direction[2], 0)
+		putVec4(buf[off+2*vec4Size:], color[0], color[1], color[2], 1)
+		putVec4(buf[off+3*vec4Size:], l.Attenuation[0], l.Attenuation[1], l.Attenuation[2], 0)
+		putVec4(buf[off+4*vec4Size:], cos(l.InnerCone), cos(l.OuterCone), 0, 0)
+	}
+	return buf
+}
+
+func putVec4(buf []byte, x, y, z, w float32) {
+	binary.LittleEndian.PutUint32(buf[0:], math.Float32bits(x))
+	binary.LittleEndian.PutUint32(buf[4:], math.Float32bits(y))
+	binary.LittleEndian.PutUint32(buf[8:], math.Float32bits(z))
+	binary.LittleEndian.PutUint32(buf[12:], math.Float32bits(w))
+}
+
+func cos(a float32) float32 {
+	return float32(math.Cos(float64(a)))
+}
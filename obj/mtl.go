@@ -0,0 +1,135 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Material is a newmtl entry from an MTL file.
+type Material struct {
+	Name string
+
+	// Ambient (Ka), Diffuse (Kd) and Specular (Ks) colors
+	Ambient, Diffuse, Specular mgl.Vec3
+
+	// Shininess is the specular exponent (Ns)
+	Shininess float32
+
+	// Opacity is d, or 1 - Tr; 1 is opaque
+	Opacity float32
+
+	// DiffuseMap is the map_Kd texture file; Load makes it relative to the
+	// working directory rather than the MTL file
+	DiffuseMap string
+}
+
+// ParseMTL reads an MTL file into Materials by name.
+func ParseMTL(r io.Reader) (map[string]*Material, error) {
+	materials := make(map[string]*Material)
+	var current *Material
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		args := fields[1:]
+		if fields[0] == "newmtl" {
+			current = &Material{
+				Name:    strings.Join(args, " "),
+				Diffuse: mgl.Vec3{1, 1, 1},
+				Opacity: 1,
+			}
+			materials[current.Name] = current
+			continue
+		}
+		if current == nil {
+			continue // nothing to apply it to
+		}
+		err := current.set(fields[0], args)
+		if err != nil {
+			return nil, fmt.Errorf("mtl: line %d: %s", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("mtl: %s", err)
+	}
+	return materials, nil
+}
+
+// LoadMTL reads the MTL file at path. Texture paths are made relative to the
+// working directory.
+func LoadMTL(path string) (map[string]*Material, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	materials, err := ParseMTL(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	dir := filepath.Dir(path)
+	for _, m := range materials {
+		if m.DiffuseMap != "" && !filepath.IsAbs(m.DiffuseMap) {
+			m.DiffuseMap = filepath.Join(dir, m.DiffuseMap)
+		}
+	}
+	return materials, nil
+}
+
+func (me *Material) set(statement string, args []string) error {
+	switch statement {
+	case "Ka", "Kd", "Ks":
+		v, err := parseFloats(args, 3)
+		if err != nil {
+			return err
+		}
+		color := mgl.Vec3{v[0], v[1], v[2]}
+		switch statement {
+		case "Ka":
+			me.Ambient = color
+		case "Kd":
+			me.Diffuse = color
+		default:
+			me.Specular = color
+		}
+	case "Ns":
+		v, err := parseFloats(args, 1)
+		if err != nil {
+			return err
+		}
+		me.Shininess = v[0]
+	case "d":
+		v, err := parseFloats(args, 1)
+		if err != nil {
+			return err
+		}
+		me.Opacity = v[0]
+	case "Tr":
+		v, err := parseFloats(args, 1)
+		if err != nil {
+			return err
+		}
+		me.Opacity = 1 - v[0]
+	case "map_Kd":
+		if len(args) == 0 {
+			return fmt.Errorf("map_Kd needs a file")
+		}
+		// options such as -s come first; the file is last
+		me.DiffuseMap = args[len(args)-1]
+	}
+	return nil
+}
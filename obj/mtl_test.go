@@ -0,0 +1,72 @@
+package obj
+
+import (
+	"strings"
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func TestParseMTL(t *testing.T) {
+	materials, err := LoadMTL("testdata/cube.mtl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(materials) != 2 {
+		t.Fatalf("got %d materials, want 2", len(materials))
+	}
+
+	crate := materials["crate"]
+	if crate == nil {
+		t.Fatal("no crate material")
+	}
+	if want := (mgl.Vec3{0.1, 0.1, 0.1}); crate.Ambient != want {
+		t.Errorf("crate Ka %v, want %v", crate.Ambient, want)
+	}
+	if want := (mgl.Vec3{0.8, 0.7, 0.6}); crate.Diffuse != want {
+		t.Errorf("crate Kd %v, want %v", crate.Diffuse, want)
+	}
+	if want := (mgl.Vec3{0.3, 0.3, 0.3}); crate.Specular != want {
+		t.Errorf("crate Ks %v, want %v", crate.Specular, want)
+	}
+	if crate.Shininess != 32 || crate.Opacity != 1 {
+		t.Errorf("crate Ns %v d %v, want 32 1", crate.Shininess, crate.Opacity)
+	}
+
+	painted := materials["painted"]
+	if painted == nil {
+		t.Fatal("no painted material")
+	}
+	if painted.Opacity != 0.75 {
+		t.Errorf("painted opacity %v, want 0.75 from Tr", painted.Opacity)
+	}
+	if painted.DiffuseMap != "" {
+		t.Errorf("painted has diffuse map %q", painted.DiffuseMap)
+	}
+}
+
+func TestParseMTLDefaults(t *testing.T) {
+	materials, err := ParseMTL(strings.NewReader("Kd 1 0 0\nnewmtl plain\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := materials["plain"]
+	if plain == nil {
+		t.Fatal("no plain material")
+	}
+	if plain.Diffuse != (mgl.Vec3{1, 1, 1}) || plain.Opacity != 1 {
+		t.Errorf("defaults Kd %v d %v, want white and opaque", plain.Diffuse, plain.Opacity)
+	}
+}
+
+func TestParseMTLErrors(t *testing.T) {
+	for _, src := range []string{
+		"newmtl a\nKd 1 1\n",
+		"newmtl a\nNs shiny\n",
+		"newmtl a\nmap_Kd\n",
+	} {
+		if _, err := ParseMTL(strings.NewReader(src)); err == nil {
+			t.Errorf("no error parsing %q", src)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package obj reads Wavefront OBJ models and their MTL material libraries into
+// indexed vertex data, ready to upload as Renderables, see helpers.LoadOBJ.
+//
+// Supported are positions, texture coords and normals; faces of any number of
+// vertices, which are triangulated as fans (so should be convex); negative
+// (relative) indices; groups and objects; and usemtl/mtllib. Lines, points,
+// smoothing groups and free-form geometry are ignored.
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+// Model is a parsed OBJ file, split into a Mesh for each group and material.
+type Model struct {
+	Meshes []*Mesh
+
+	// MaterialLibs are the mtllib files named in the OBJ, as written
+	MaterialLibs []string
+
+	// Materials are filled in by Load from the MaterialLibs, by name
+	Materials map[string]*Material
+}
+
+// Mesh is the faces of one group that share a material, as triangles over
+// de-duplicated vertices: every distinct position/uv/normal combination is
+// one vertex.
+type Mesh struct {
+	// Group is the g (or o) name the faces were under, "" if none
+	Group string
+	// Material is the usemtl name the faces were under, "" if none
+	Material string
+
+	// Positions and Normals have 3 floats per vertex, UVs 2
+	Positions []float32
+	Normals   []float32
+	UVs       []float32
+	Indices   []uint32
+}
+
+func (me *Mesh) VertexCount() int {
+	return len(me.Positions) / 3
+}
+
+// vertexKey identifies a distinct vertex: indices into the file's v, vt and vn
+// lists (-1 if absent), plus the generated normal for faces without any.
+type vertexKey struct {
+	v, vt, vn int
+	flat      mgl.Vec3
+}
+
+type meshKey struct {
+	group, material string
+}
+
+type parser struct {
+	positions []mgl.Vec3
+	uvs       []mgl.Vec2
+	normals   []mgl.Vec3
+
+	model    *Model
+	group    string
+	material string
+	meshes   map[meshKey]*Mesh
+	vertices map[*Mesh]map[vertexKey]uint32
+}
+
+// Parse reads an OBJ file. Material libraries aren't read; see Load.
+func Parse(r io.Reader) (*Model, error) {
+	p := &parser{
+		model:    &Model{Materials: make(map[string]*Material)},
+		meshes:   make(map[meshKey]*Mesh),
+		vertices: make(map[*Mesh]map[vertexKey]uint32),
+	}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if err := p.line(scanner.Text()); err != nil {
+			return nil, fmt.Errorf("obj: line %d: %s", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("obj: %s", err)
+	}
+	return p.model, nil
+}
+
+// Load reads the OBJ file at path, along with its material libraries, which
+// are found relative to it.
+func Load(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	model, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	dir := filepath.Dir(path)
+	for _, lib := range model.MaterialLibs {
+		materials, err := LoadMTL(filepath.Join(dir, lib))
+		if err != nil {
+			return nil, err
+		}
+		for name, m := range materials {
+			model.Materials[name] = m
+		}
+	}
+	return model, nil
+}
+
+func (me *parser) line(line string) error {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	args := fields[1:]
+	switch fields[0] {
+	case "v":
+		v, err := parseFloats(args, 3)
+		if err != nil {
+			return err
+		}
+		me.positions = append(me.positions, mgl.Vec3{v[0], v[1], v[2]})
+	case "vt":
+		v, err := parseFloats(args, 1)
+		if err != nil {
+			return err
+		}
+		uv := mgl.Vec2{v[0]}
+		if len(v) > 1 {
+			uv[1] = v[1]
+		}
+		me.uvs = append(me.uvs, uv)
+	case "vn":
+		v, err := parseFloats(args, 3)
+		if err != nil {
+			return err
+		}
+		me.normals = append(me.normals, mgl.Vec3{v[0], v[1], v[2]})
+	case "f":
+		return me.face(args)
+	case "g", "o":
+		me.group = strings.Join(args, " ")
+	case "usemtl":
+		me.material = strings.Join(args, " ")
+	case "mtllib":
+		me.model.MaterialLibs = append(me.model.MaterialLibs, args...)
+	}
+	return nil
+}
+
+// face triangulates a face as a fan around its first vertex.
+func (me *parser) face(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face needs at least 3 vertices, has %d", len(args))
+	}
+	keys := make([]vertexKey, len(args))
+	needNormal := false
+	for i, arg := range args {
+		k, err := me.faceVertex(arg)
+		if err != nil {
+			return err
+		}
+		keys[i] = k
+		if k.vn < 0 {
+			needNormal = true
+		}
+	}
+	if needNormal {
+		// faces without normals are shaded flat
+		a := me.positions[keys[0].v]
+		n := me.positions[keys[1].v].Sub(a).Cross(me.positions[keys[2].v].Sub(a))
+		if n.Len() > 0 {
+			n = n.Normalize()
+		}
+		for i := range keys {
+			if keys[i].vn < 0 {
+				keys[i].flat = n
+			}
+		}
+	}
+
+	mesh := me.mesh()
+	indices := make([]uint32, len(keys))
+	for i, k := range keys {
+		indices[i] = me.vertex(mesh, k)
+	}
+	for i := 1; i+1 < len(indices); i++ {
+		mesh.Indices = append(mesh.Indices, indices[0], indices[i], indices[i+1])
+	}
+	return nil
+}
+
+// faceVertex parses v, v/vt, v//vn or v/vt/vn into 0-based indices.
+func (me *parser) faceVertex(arg string) (vertexKey, error) {
+	k := vertexKey{v: -1, vt: -1, vn: -1}
+	parts := strings.Split(arg, "/")
+	if len(parts) > 3 {
+		return k, fmt.Errorf("bad face vertex %q", arg)
+	}
+	var err error
+	if k.v, err = resolveIndex(parts[0], len(me.positions)); err != nil {
+		return k, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if k.vt, err = resolveIndex(parts[1], len(me.uvs)); err != nil {
+			return k, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if k.vn, err = resolveIndex(parts[2], len(me.normals)); err != nil {
+			return k, err
+		}
+	}
+	return k, nil
+}
+
+// resolveIndex turns a 1-based, or negative relative, OBJ index into a 0-based one.
+func resolveIndex(s string, count int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad index %q", s)
+	}
+	if i < 0 {
+		i += count
+	} else {
+		i--
+	}
+	if i < 0 || i >= count {
+		return 0, fmt.Errorf("index %s out of range (have %d)", s, count)
+	}
+	return i, nil
+}
+
+// mesh returns the Mesh for the current group and material.
+func (me *parser) mesh() *Mesh {
+	key := meshKey{me.group, me.material}
+	mesh, ok := me.meshes[key]
+	if !ok {
+		mesh = &Mesh{Group: me.group, Material: me.material}
+		me.meshes[key] = mesh
+		me.vertices[mesh] = make(map[vertexKey]uint32)
+		me.model.Meshes = append(me.model.Meshes, mesh)
+	}
+	return mesh
+}
+
+// vertex returns the index of the vertex in the mesh, adding it if it's new.
+func (me *parser) vertex(mesh *Mesh, k vertexKey) uint32 {
+	seen := me.vertices[mesh]
+	if i, ok := seen[k]; ok {
+		return i
+	}
+	i := uint32(mesh.VertexCount())
+	seen[k] = i
+
+	p := me.positions[k.v]
+	mesh.Positions = append(mesh.Positions, p[0], p[1], p[2])
+	var uv mgl.Vec2
+	if k.vt >= 0 {
+		uv = me.uvs[k.vt]
+	}
+	mesh.UVs = append(mesh.UVs, uv[0], uv[1])
+	n := k.flat
+	if k.vn >= 0 {
+		n = me.normals[k.vn]
+	}
+	mesh.Normals = append(mesh.Normals, n[0], n[1], n[2])
+	return i
+}
+
+// parseFloats parses at least min floats from args.
+func parseFloats(args []string, min int) ([]float32, error) {
+	if len(args) < min {
+		return nil, fmt.Errorf("need %d numbers, have %d", min, len(args))
+	}
+	v := make([]float32, len(args))
+	for i, a := range args {
+		f, err := strconv.ParseFloat(a, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", a)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
@@ -0,0 +1,162 @@
+package obj
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mgl "github.com/go-gl/mathgl/mgl32"
+)
+
+func TestLoadCube(t *testing.T) {
+	model, err := Load("testdata/cube.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Meshes) != 2 {
+		t.Fatalf("got %d meshes, want 2", len(model.Meshes))
+	}
+
+	sides, caps := model.Meshes[0], model.Meshes[1]
+	if sides.Group != "sides" || sides.Material != "crate" {
+		t.Errorf("first mesh is %q/%q, want sides/crate", sides.Group, sides.Material)
+	}
+	if caps.Group != "caps" || caps.Material != "painted" {
+		t.Errorf("second mesh is %q/%q, want caps/painted", caps.Group, caps.Material)
+	}
+
+	// each quad is two triangles over its own four vertices, since no two
+	// sides share a normal
+	for _, m := range []struct {
+		mesh  *Mesh
+		quads int
+	}{{sides, 4}, {caps, 2}} {
+		if got, want := len(m.mesh.Indices), m.quads*6; got != want {
+			t.Errorf("%s: got %d indices, want %d", m.mesh.Group, got, want)
+		}
+		if got, want := m.mesh.VertexCount(), m.quads*4; got != want {
+			t.Errorf("%s: got %d vertices, want %d", m.mesh.Group, got, want)
+		}
+		if len(m.mesh.Normals) != len(m.mesh.Positions) || len(m.mesh.UVs) != m.mesh.VertexCount()*2 {
+			t.Errorf("%s: attribute lengths don't match: %d positions, %d normals, %d uvs",
+				m.mesh.Group, len(m.mesh.Positions), len(m.mesh.Normals), len(m.mesh.UVs))
+		}
+		for _, i := range m.mesh.Indices {
+			if int(i) >= m.mesh.VertexCount() {
+				t.Fatalf("%s: index %d out of range", m.mesh.Group, i)
+			}
+		}
+	}
+
+	// the front face: first triangle is 1 2 3, with its uvs and normal
+	if got, want := sides.Indices[:6], []uint32{0, 1, 2, 0, 2, 3}; !equalIndices(got, want) {
+		t.Errorf("front face indices %v, want %v", got, want)
+	}
+	if got, want := vec3At(sides.Positions, 2), (mgl.Vec3{0.5, 0.5, 0.5}); got != want {
+		t.Errorf("vertex 2 at %v, want %v", got, want)
+	}
+	if got, want := vec3At(sides.Normals, 2), (mgl.Vec3{0, 0, 1}); got != want {
+		t.Errorf("vertex 2 normal %v, want %v", got, want)
+	}
+	if got, want := (mgl.Vec2{sides.UVs[4], sides.UVs[5]}), (mgl.Vec2{1, 1}); got != want {
+		t.Errorf("vertex 2 uv %v, want %v", got, want)
+	}
+
+	if len(model.MaterialLibs) != 1 || model.MaterialLibs[0] != "cube.mtl" {
+		t.Errorf("material libs %v, want [cube.mtl]", model.MaterialLibs)
+	}
+	crate, ok := model.Materials["crate"]
+	if !ok {
+		t.Fatalf("no crate material in %v", model.Materials)
+	}
+	if want := filepath.Join("testdata", "textures", "crate.png"); crate.DiffuseMap != want {
+		t.Errorf("crate diffuse map %q, want %q", crate.DiffuseMap, want)
+	}
+}
+
+func TestParsePentagon(t *testing.T) {
+	model, err := Load("testdata/pentagon.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Meshes) != 1 {
+		t.Fatalf("got %d meshes, want 1", len(model.Meshes))
+	}
+	mesh := model.Meshes[0]
+	if mesh.Group != "pentagon" {
+		t.Errorf("group %q, want pentagon", mesh.Group)
+	}
+
+	// a fan of 3 triangles around the first vertex, sharing all 5 vertices
+	if got, want := mesh.Indices, []uint32{0, 1, 2, 0, 2, 3, 0, 3, 4}; !equalIndices(got, want) {
+		t.Errorf("indices %v, want %v", got, want)
+	}
+	if mesh.VertexCount() != 5 {
+		t.Errorf("got %d vertices, want 5", mesh.VertexCount())
+	}
+
+	// no normals in the file, so it's shaded flat, facing +Z (counter-clockwise)
+	for i := 0; i < mesh.VertexCount(); i++ {
+		if n := vec3At(mesh.Normals, i); !n.ApproxEqual(mgl.Vec3{0, 0, 1}) {
+			t.Errorf("vertex %d normal %v, want +Z", i, n)
+		}
+	}
+}
+
+func TestParseDedupesSharedVertices(t *testing.T) {
+	// two triangles making a quad, sharing an edge with the same uv and normal
+	src := `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vn 0 0 1
+f 1//1 2//1 3//1
+f 1//1 3//1 4//1
+`
+	model, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mesh := model.Meshes[0]
+	if mesh.VertexCount() != 4 {
+		t.Errorf("got %d vertices, want 4", mesh.VertexCount())
+	}
+	if got, want := mesh.Indices, []uint32{0, 1, 2, 0, 2, 3}; !equalIndices(got, want) {
+		t.Errorf("indices %v, want %v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Load("testdata/bad_index.obj")
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("got error %v, want one for line 4", err)
+	}
+
+	for _, src := range []string{
+		"v 0 0\n",
+		"v 0 0 0\nv 1 0 0\nf 1 2\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/x 2 3\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1/1/1 2 3\n",
+	} {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("no error parsing %q", src)
+		}
+	}
+}
+
+func vec3At(floats []float32, i int) mgl.Vec3 {
+	return mgl.Vec3{floats[i*3], floats[i*3+1], floats[i*3+2]}
+}
+
+func equalIndices(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
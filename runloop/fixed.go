@@ -0,0 +1,116 @@
+package runloop
+
+import (
+	"errors"
+	"math"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// Clock returns the current time in seconds.
+type Clock func() float64
+
+// FixedStep advances a simulation in constant-size steps regardless of frame rate.
+// Elapsed real time accumulates between frames and is consumed Step seconds at a
+// time; whatever is left over is reported as an interpolation alpha in [0,1) so
+// drawing can blend between the previous and current simulation states.
+type FixedStep struct {
+	// Step is the simulation time step in seconds
+	Step float64
+
+	// MaxSteps caps the number of updates run per Advance. When the simulation
+	// falls further behind than this, the extra time is dropped rather than
+	// letting the loop spiral trying to catch up.
+	MaxSteps int
+
+	// Clock supplies the current time. Defaults to glfw.GetTime.
+	Clock Clock
+
+	// SimTime is the total simulated time, in seconds
+	SimTime float64
+
+	accumulator float64
+	lastTime    float64
+	started     bool
+}
+
+func NewFixedStep(step float64) *FixedStep {
+	return &FixedStep{
+		Step:     step,
+		MaxSteps: 5,
+		Clock:    glfw.GetTime,
+	}
+}
+
+// Start marks the current Clock time as the beginning of the run. Advance
+// calls it automatically if it hasn't been called yet.
+func (me *FixedStep) Start() {
+	me.lastTime = me.Clock()
+	me.started = true
+}
+
+// Advance measures the time elapsed since the previous call and runs update
+// once per whole Step of it, passing the simulation time and dt=Step.
+// Returns the interpolation alpha (leftover time / Step), or the first error
+// returned by update.
+func (me *FixedStep) Advance(update func(t, dt float64) error) (float64, error) {
+	if !me.started {
+		me.Start()
+	}
+	now := me.Clock()
+	elapsed := now - me.lastTime
+	me.lastTime = now
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	me.accumulator += elapsed
+
+	steps := 0
+	for me.accumulator >= me.Step {
+		if me.MaxSteps > 0 && steps >= me.MaxSteps {
+			// Too far behind; drop the backlog but keep the fractional remainder
+			me.accumulator = math.Mod(me.accumulator, me.Step)
+			break
+		}
+		me.SimTime += me.Step
+		me.accumulator -= me.Step
+		steps++
+		err := update(me.SimTime, me.Step)
+		if err != nil {
+			return me.Alpha(), err
+		}
+	}
+	return me.Alpha(), nil
+}
+
+// Alpha is how far (0..1) real time has progressed between the last simulation step and the next one.
+func (me *FixedStep) Alpha() float64 {
+	return me.accumulator / me.Step
+}
+
+// errStop lets Run bail out of Advance when update asks to stop
+var errStop = errors.New("stop")
+
+// Run calls Advance with update, then draw with the interpolation alpha, in a
+// loop until shouldStop returns true, or update returns shouldContinue=false
+// or an error.
+// Returns the err (if any) from the update func.
+func (me *FixedStep) Run(shouldStop func() bool, update func(t, dt float64) (bool, error), draw func(alpha float64)) error {
+	for !shouldStop() {
+		alpha, err := me.Advance(func(t, dt float64) error {
+			shouldContinue, err := update(t, dt)
+			if err == nil && !shouldContinue {
+				return errStop
+			}
+			return err
+		})
+		if err == errStop {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		draw(alpha)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package runloop
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// fakeClock only moves when told to
+type fakeClock struct {
+	t float64
+}
+
+func (me *fakeClock) now() float64 {
+	return me.t
+}
+
+func newTestLoop(step float64) (*FixedStep, *fakeClock) {
+	clock := &fakeClock{}
+	loop := NewFixedStep(step)
+	loop.Clock = clock.now
+	loop.Start()
+	return loop, clock
+}
+
+func TestAdvanceSteps(t *testing.T) {
+	loop, clock := newTestLoop(0.25)
+	for _, tc := range []struct {
+		elapsed float64
+		steps   int
+		alpha   float64
+	}{
+		{0, 0, 0},
+		{0.1, 0, 0.4},
+		{0.15, 1, 0},    // 0.25 accumulated
+		{0.6, 2, 0.4},   // 0.6: two steps and 0.1 left
+		{0.2, 1, 0.2},   // 0.3: one step and 0.05 left
+		{0.7, 3, 0},     // 0.75
+		{0.125, 0, 0.5}, // half a step
+		{-1, 0, 0.5},    // a clock going backwards counts for nothing
+		{0.125, 1, 0},   // the other half
+	} {
+		clock.t += tc.elapsed
+		var times []float64
+		alpha, err := loop.Advance(func(gt, dt float64) error {
+			if dt != 0.25 {
+				t.Errorf("dt %f, want 0.25", dt)
+			}
+			times = append(times, gt)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(times) != tc.steps {
+			t.Errorf("after %+v: ran %d steps, want %d", tc.elapsed, len(times), tc.steps)
+		}
+		if math.Abs(alpha-tc.alpha) > 1e-9 {
+			t.Errorf("after %+v: alpha %f, want %f", tc.elapsed, alpha, tc.alpha)
+		}
+		for i := 1; i < len(times); i++ {
+			if times[i] != times[i-1]+0.25 {
+				t.Errorf("step times %v aren't a step apart", times)
+			}
+		}
+		if len(times) > 0 && times[len(times)-1] != loop.SimTime {
+			t.Errorf("last step at %f, SimTime %f", times[len(times)-1], loop.SimTime)
+		}
+	}
+	if loop.SimTime != 2 {
+		t.Errorf("SimTime %f, want 2", loop.SimTime)
+	}
+}
+
+func TestAdvanceMaxSteps(t *testing.T) {
+	loop, clock := newTestLoop(0.25)
+	loop.MaxSteps = 4
+
+	// a long stall: 10.1 seconds behind is 40 steps, but only 4 are run
+	clock.t = 10.1
+	steps := 0
+	alpha, _ := loop.Advance(func(gt, dt float64) error {
+		steps++
+		return nil
+	})
+	if steps != 4 {
+		t.Errorf("ran %d steps, want MaxSteps 4", steps)
+	}
+	if loop.SimTime != 1 {
+		t.Errorf("SimTime %f, want 1", loop.SimTime)
+	}
+	// the backlog is dropped, keeping the fraction of a step
+	if math.Abs(alpha-0.4) > 1e-6 {
+		t.Errorf("alpha %f, want 0.4", alpha)
+	}
+
+	// and the next frame carries on as normal
+	clock.t += 0.25
+	steps = 0
+	loop.Advance(func(gt, dt float64) error {
+		steps++
+		return nil
+	})
+	if steps != 1 {
+		t.Errorf("ran %d steps after catching up, want 1", steps)
+	}
+}
+
+func TestAdvanceError(t *testing.T) {
+	loop, clock := newTestLoop(0.25)
+	clock.t = 1
+	boom := errors.New("boom")
+	steps := 0
+	_, err := loop.Advance(func(gt, dt float64) error {
+		steps++
+		if steps == 2 {
+			return boom
+		}
+		return nil
+	})
+	if err != boom {
+		t.Errorf("got err %v, want %v", err, boom)
+	}
+	if steps != 2 {
+		t.Errorf("ran %d steps, want it to stop at the error on the 2nd", steps)
+	}
+}
+
+func TestRun(t *testing.T) {
+	loop, clock := newTestLoop(0.25)
+	var alphas []float64
+	steps := 0
+	err := loop.Run(
+		func() bool { return false },
+		func(gt, dt float64) (bool, error) {
+			steps++
+			return steps < 3, nil
+		},
+		func(alpha float64) {
+			alphas = append(alphas, alpha)
+			clock.t += 0.2
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps != 3 {
+		t.Errorf("ran %d steps, want 3", steps)
+	}
+	// drawn at 0, 0.2, 0.4 and 0.6 seconds, with a step run at 0.4 and 0.6;
+	// the third, at 0.8, stops it before drawing
+	if want := []float64{0, 0.8, 0.6, 0.4}; len(alphas) != len(want) {
+		t.Errorf("drew with alphas %v, want %v", alphas, want)
+	} else {
+		for i := range want {
+			if math.Abs(alphas[i]-want[i]) > 1e-9 {
+				t.Errorf("drew with alphas %v, want %v", alphas, want)
+				break
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package window
 
 import (
+	"os"
+
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
@@ -10,19 +12,42 @@ type Options struct {
 	Width     int
 	Height    int
 	Resizable bool
+
+	// Hidden creates the window without showing it, for offscreen rendering
+	Hidden bool
+
+	// SoftwareGL asks Mesa for its llvmpipe software rasterizer instead of the
+	// GPU driver, so rendering is the same from machine to machine. It sets
+	// LIBGL_ALWAYS_SOFTWARE and GALLIUM_DRIVER unless they're already set.
+	SoftwareGL bool
+
+	// EGL creates the context through EGL rather than GLX/WGL/NSGL
+	EGL bool
 }
 
 func New(opts Options) (*glfw.Window, error) {
+	if opts.SoftwareGL {
+		setenvDefault("LIBGL_ALWAYS_SOFTWARE", "1")
+		setenvDefault("GALLIUM_DRIVER", "llvmpipe")
+	}
+
 	err := glfw.Init()
 	if err != nil {
 		return nil, err
 	}
 
+	glfw.DefaultWindowHints()
 	if opts.Resizable {
 		glfw.WindowHint(glfw.Resizable, glfw.True)
 	} else {
 		glfw.WindowHint(glfw.Resizable, glfw.False)
 	}
+	if opts.Hidden {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	}
+	if opts.EGL {
+		glfw.WindowHint(glfw.ContextCreationAPI, glfw.EGLContextAPI)
+	}
 	glfw.WindowHint(glfw.ContextVersionMajor, 3)
 	glfw.WindowHint(glfw.ContextVersionMinor, 3)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
@@ -35,6 +60,7 @@ func New(opts Options) (*glfw.Window, error) {
 	win.MakeContextCurrent()
 	err = gl.Init()
 	if err != nil {
+		win.Destroy()
 		return nil, err
 	}
 	glfw.SwapInterval(1) // enable vsync
@@ -46,3 +72,9 @@ func New(opts Options) (*glfw.Window, error) {
 
 	return win, nil
 }
+
+func setenvDefault(key, value string) {
+	if _, ok := os.LookupEnv(key); !ok {
+		os.Setenv(key, value)
+	}
+}